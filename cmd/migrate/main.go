@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"mini-search-platform/config"
+	"mini-search-platform/internal/database"
+	"os"
+)
+
+// migrate moves the schema of the database selected by DATABASE_DRIVER up
+// to the latest version, or down by a number of steps, and prints the
+// migrations it ran.
+func main() {
+	down := flag.Int("down", 0, "number of migrations to revert")
+	to := flag.Int("to", -1, "schema version to migrate to, the latest if -1")
+	status := flag.Bool("status", false, "only print the schema version")
+	flag.Parse()
+
+	cfg, err := config.Load(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	db, err := database.Connect(cfg)
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+
+	migrator, err := database.NewMigrator(db, cfg.Database.Driver)
+	if err != nil {
+		panic(err)
+	}
+
+	current, err := migrator.Version()
+	if err != nil {
+		panic(err)
+	}
+	if *status {
+		fmt.Printf("schema version %d, latest %d\n", current, migrator.LatestVersion())
+		return
+	}
+
+	target := *to
+	switch {
+	case *down > 0 && target >= 0:
+		fmt.Fprintln(os.Stderr, "-down and -to are mutually exclusive")
+		os.Exit(2)
+	case *down > 0:
+		target = max(current-*down, 0)
+	case target < 0:
+		target = migrator.LatestVersion()
+	}
+
+	run, err := migrator.Migrate(target)
+	for _, migration := range run {
+		fmt.Printf("migrated %d: %s\n", migration.Version, migration.Name)
+	}
+
+	var tooNew *database.SchemaTooNewError
+	if errors.As(err, &tooNew) {
+		fmt.Fprintf(os.Stderr, "%v; use a newer release to migrate this database\n", err)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migration failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	// migrations the database does not support are left out
+	version, err := migrator.Version()
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("schema version %d\n", version)
+}
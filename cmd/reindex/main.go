@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"mini-search-platform/config"
+	"mini-search-platform/internal/adapters"
+	"mini-search-platform/internal/database"
+	"mini-search-platform/internal/models"
+	"mini-search-platform/internal/search"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const (
+	// pollInterval is how often the status of a running rebuild is fetched.
+	pollInterval = time.Second
+	// requestTimeout bounds every request to the server.
+	requestTimeout = 30 * time.Second
+)
+
+// reindex rebuilds the search indexes from the relational store and swaps
+// every rebuilt index into place once it is complete. The rebuilds run in
+// the server, through POST /admin/indexes/:name/rebuild, since only the
+// server knows that changes made in the meantime must also be written to
+// the copy being rebuilt. Each tenant is reached with a temporary admin
+// token, which is deleted once its rebuilds are done or the command is
+// interrupted, and expires on its own should the command be killed.
+func main() {
+	index := flag.String("index", "all", "index to rebuild: articles, products or all")
+	tenantID := flag.Int("tenant", 0, "tenant to rebuild, all tenants if 0")
+	withCatalogues := flag.Bool("catalogues", true, "also rebuild the localized indexes of every catalogue")
+	server := flag.String("server", "", "URL of the server, derived from the http address of the configuration if empty")
+	tokenLifetime := flag.Duration("token-lifetime", 24*time.Hour, "lifetime of the temporary admin token of every tenant")
+	flag.Parse()
+
+	indexes := []string{search.ARTICLES_INDEX_NAME, search.PRODUCTS_INDEX_NAME}
+	if *index != "all" {
+		if !search.IsIndex(*index) {
+			fmt.Fprintf(os.Stderr, "unknown index '%s'\n", *index)
+			os.Exit(2)
+		}
+		indexes = []string{*index}
+	}
+
+	cfg, err := config.Load(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if *server == "" {
+		*server = serverURL(cfg.HTTP.Addr)
+	}
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+
+	repositories, err := adapters.NewRepositories(cfg, db)
+	if err != nil {
+		panic(err)
+	}
+	tenants := repositories.Tenants
+	catalogues := repositories.Catalogues
+
+	var tenantIDs []int
+	if *tenantID != 0 {
+		tenantIDs = []int{*tenantID}
+	} else {
+		allTenants, err := tenants.FindAll()
+		if err != nil {
+			panic(err)
+		}
+		for _, tenant := range allTenants {
+			tenantIDs = append(tenantIDs, tenant.ID)
+		}
+	}
+
+	// rebuilds already started keep running in the server when interrupted
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	failed := false
+	for _, tenantID := range tenantIDs {
+		if ctx.Err() != nil {
+			failed = true
+			break
+		}
+
+		targets := []*models.Catalogue{nil}
+		if *withCatalogues {
+			tenantCatalogues, err := catalogues.ForTenant(tenantID).FindAll()
+			if err != nil {
+				panic(err)
+			}
+			targets = append(targets, tenantCatalogues...)
+		}
+
+		if !reindexTenant(ctx, repositories.Tokens, *server, *tokenLifetime, tenantID, indexes, targets) {
+			failed = true
+		}
+	}
+
+	if failed {
+		stop()
+		os.Exit(1)
+	}
+}
+
+// reindexTenant rebuilds the indexes of the tenant with a temporary admin
+// token, which is deleted once the rebuilds are done.
+func reindexTenant(ctx context.Context, tokens models.APITokensRepository, server string, lifetime time.Duration, tenantID int, indexes []string, targets []*models.Catalogue) bool {
+	token, plain, err := models.NewAPIToken(tenantID, "reindex", []models.Scope{models.ScopeAdmin})
+	if err != nil {
+		panic(err)
+	}
+	token.ExpiresAt = time.Now().Add(lifetime).Format(time.RFC3339)
+	if token.ID, err = tokens.Save(token); err != nil {
+		panic(err)
+	}
+
+	succeeded := true
+	defer func() {
+		if err := tokens.Delete(tenantID, token.ID); err != nil {
+			fmt.Fprintf(os.Stderr, "tenant %d: could not delete the reindex token %d: %v\n", tenantID, token.ID, err)
+			succeeded = false
+		}
+	}()
+
+	client := &rebuildClient{server: server, token: plain, http: &http.Client{Timeout: requestTimeout}}
+	for _, catalogue := range targets {
+		for _, index := range indexes {
+			if !rebuild(ctx, client, tenantID, index, catalogue) {
+				succeeded = false
+			}
+		}
+	}
+
+	return succeeded
+}
+
+func rebuild(ctx context.Context, client *rebuildClient, tenantID int, index string, catalogue *models.Catalogue) bool {
+	label := fmt.Sprintf("tenant %d %s", tenantID, index)
+	code := ""
+	if catalogue != nil {
+		label = fmt.Sprintf("%s (catalogue %s)", label, catalogue.Code)
+		code = catalogue.Code
+	}
+
+	status, started, err := client.start(ctx, index, code)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: rebuild failed: %v\n", label, err)
+		return false
+	}
+	if !started {
+		fmt.Printf("%s: already being rebuilt, waiting for it\n", label)
+	}
+
+	indexed := -1
+	for status.State == search.RebuildRunning {
+		if status.Indexed != indexed {
+			indexed = status.Indexed
+			fmt.Printf("%s: %d/%d\n", label, status.Indexed, status.Total)
+		}
+		select {
+		case <-ctx.Done():
+			fmt.Fprintf(os.Stderr, "%s: interrupted, the rebuild goes on in the server\n", label)
+			return false
+		case <-time.After(pollInterval):
+		}
+		if status, err = client.status(ctx, index, code); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: rebuild failed: %v\n", label, err)
+			return false
+		}
+	}
+
+	if status.State != search.RebuildCompleted {
+		fmt.Fprintf(os.Stderr, "%s: rebuild failed: %s\n", label, status.Error)
+		return false
+	}
+
+	if status.Indexed != indexed {
+		fmt.Printf("%s: %d/%d\n", label, status.Indexed, status.Total)
+	}
+	fmt.Printf("%s: swapped in\n", label)
+	return true
+}
+
+// serverURL is the URL of the server listening on the address, the local
+// host if the address has none.
+func serverURL(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "http://" + addr
+	}
+	if host == "" {
+		host = "localhost"
+	}
+
+	return "http://" + net.JoinHostPort(host, port)
+}
+
+// rebuildClient calls the rebuild endpoints of the server for one tenant.
+type rebuildClient struct {
+	server string
+	token  string
+	http   *http.Client
+}
+
+// start starts a rebuild of the index and returns its status. started is
+// false if a rebuild of the index was already running, whose status is
+// returned instead.
+func (c *rebuildClient) start(ctx context.Context, index, catalogue string) (*search.RebuildStatus, bool, error) {
+	var conflict struct {
+		Rebuild *search.RebuildStatus `json:"rebuild"`
+	}
+	status := &search.RebuildStatus{}
+
+	code, err := c.do(ctx, http.MethodPost, index, catalogue, func(code int) interface{} {
+		if code == http.StatusConflict {
+			return &conflict
+		}
+		return status
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if code == http.StatusConflict {
+		return conflict.Rebuild, false, nil
+	}
+
+	return status, true, nil
+}
+
+func (c *rebuildClient) status(ctx context.Context, index, catalogue string) (*search.RebuildStatus, error) {
+	status := &search.RebuildStatus{}
+	_, err := c.do(ctx, http.MethodGet, index, catalogue, func(int) interface{} { return status })
+	return status, err
+}
+
+// do sends the request and decodes the response into the value target
+// returns for its status code. Responses other than 200, 202 and 409 are
+// returned as errors.
+func (c *rebuildClient) do(ctx context.Context, method, index, catalogue string, target func(code int) interface{}) (int, error) {
+	endpoint := fmt.Sprintf("%s/admin/indexes/%s/rebuild", c.server, url.PathEscape(index))
+	if catalogue != "" {
+		endpoint += "?catalogue=" + url.QueryEscape(catalogue)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	var body bytes.Buffer
+	if _, err := body.ReadFrom(res.Body); err != nil {
+		return 0, err
+	}
+
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusAccepted, http.StatusConflict:
+		return res.StatusCode, json.Unmarshal(body.Bytes(), target(res.StatusCode))
+	default:
+		var failure struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body.Bytes(), &failure) != nil || failure.Error == "" {
+			failure.Error = body.String()
+		}
+		return res.StatusCode, fmt.Errorf("%s %s: %d %s", method, endpoint, res.StatusCode, failure.Error)
+	}
+}
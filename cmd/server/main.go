@@ -1,68 +1,180 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"log/slog"
+	"mini-search-platform/config"
 	"mini-search-platform/internal/adapters"
 	"mini-search-platform/internal/database"
 	"mini-search-platform/internal/handlers"
 	"mini-search-platform/internal/middleware"
-	"mini-search-platform/pkg/sqlite"
+	"mini-search-platform/internal/models"
 	"os"
-	"strconv"
 	"time"
 
 	"mini-search-platform/internal/search"
+	"mini-search-platform/internal/translation"
 
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
-	db, err := sqlite.Init()
+	cfg, err := config.Load(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	slog.SetDefault(cfg.Logging.NewLogger(os.Stderr))
+	if cfg.Logging.Level != "debug" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	db, err := database.Open(cfg)
 	if err != nil {
 		panic(err)
 	}
-	defer sqlite.Close(db)
+	defer db.Close()
 
-	err = database.Create(db)
+	repositories, err := adapters.NewRepositories(cfg, db)
 	if err != nil {
 		panic(err)
 	}
+	articles := repositories.Articles
+	authors := repositories.Authors
+	tags := repositories.Tags
+	products := repositories.Products
+	variants := repositories.Variants
+	tenants := repositories.Tenants
+	tokens := repositories.Tokens
+	catalogues := repositories.Catalogues
+	translations := repositories.Translations
+	outbox := repositories.Outbox
+	searchSettings := repositories.SearchSettings
+	merchandisingRules := repositories.MerchandisingRules
 
-	articles := adapters.NewSQLliteArticleRepository(db)
-	authors := adapters.NewSQLliteAuthorsRepository(db)
-	tags := adapters.NewSQLliteTagsRepository(db)
+	engine, err := adapters.NewSearchEngine(cfg, db)
+	if err != nil {
+		panic(err)
+	}
 
-	engine := adapters.Init()
+	sync := search.NewIndexSyncManager(engine, articles, tags, products, variants, catalogues, translations, searchSettings)
+	enricher := translation.NewEnricher(adapters.NewLocalTranslator(), articles, products, catalogues, translations)
+	enricher.AttemptTimeout = time.Duration(cfg.Translation.AttemptTimeout)
+	enricher.RetryTimeout = time.Duration(cfg.Translation.RetryTimeout)
 
-	sync := search.NewIndexSyncManager(engine, articles, tags)
+	// index updates are written to the outbox together with every change
+	// and drained into the search engine in the background
+	worker := search.NewOutboxWorker(outbox, sync)
+	worker.PollInterval = time.Duration(cfg.Sync.PollInterval)
+	worker.BatchSize = cfg.Sync.BatchSize
+	worker.MaxAttempts = cfg.Sync.MaxAttempts
+	worker.BaseDelay = time.Duration(cfg.Sync.RetryBaseDelay)
+	worker.MaxDelay = time.Duration(cfg.Sync.RetryMaxDelay)
+	go worker.Run(context.Background())
 
-	searchRateLimit := 60
-	if limit := os.Getenv("SEARCH_RATE_LIMIT"); limit != "" {
-		if val, err := strconv.Atoi(limit); err == nil && val > 0 {
-			searchRateLimit = val
-		}
+	// the indexes are provisioned in the background, so that the API
+	// starts, in degraded mode, while the search engine is unreachable
+	bootstrap := search.NewBootstrap(sync, tenants)
+	bootstrap.BaseDelay = time.Duration(cfg.Search.BootstrapRetryBaseDelay)
+	bootstrap.MaxDelay = time.Duration(cfg.Search.BootstrapRetryMaxDelay)
+	// the outbox only holds changes not synced yet, so the indexes of the
+	// memory engine are rebuilt from scratch after every restart
+	rebuilds := search.NewRebuildTracker()
+	if cfg.Search.Engine == config.SearchEngineMemory || cfg.Search.FallbackEngine == config.SearchEngineMemory {
+		bootstrap.Rebuilds = rebuilds
 	}
-	rateLimiter := middleware.NewRateLimiter(searchRateLimit)
+	go bootstrap.Run(context.Background())
+
+	rateLimiter := middleware.NewRateLimiter(cfg.RateLimits.Search)
 	rateLimiter.Cleanup(5 * time.Minute)
 
+	// search-as-you-type sends a request per keystroke, so suggestions get
+	// a budget of their own
+	suggestRateLimiter := middleware.NewRateLimiter(cfg.RateLimits.Suggest)
+	suggestRateLimiter.Cleanup(5 * time.Minute)
+
 	r := gin.Default()
+	r.GET("/health", handlers.Health(engine, bootstrap))
+
+	// resource: tenants (platform admins only)
+	r.POST("/tenants", middleware.RequireAdminKey(string(cfg.HTTP.AdminAPIKey)), handlers.AddTenant(tenants, tokens))
+
+	// every other resource is scoped to the tenant of the API token and
+	// each route declares the scope the token must grant
+	api := r.Group("/", middleware.Authenticate(tokens))
+
+	// resource: tokens
+	api.POST("/tokens", middleware.RequireScope(models.ScopeAdmin), handlers.AddToken(tokens))
+	api.GET("/tokens", middleware.RequireScope(models.ScopeAdmin), handlers.ListTokens(tokens))
+	api.DELETE("/tokens/:id", middleware.RequireScope(models.ScopeAdmin), handlers.DeleteToken(tokens))
+
+	// resource: index outbox
+	api.GET("/outbox", middleware.RequireScope(models.ScopeAdmin), handlers.ListOutboxEntries(outbox))
+	api.POST("/outbox/:id/retry", middleware.RequireScope(models.ScopeAdmin), handlers.RetryOutboxEntry(outbox))
+
+	// resource: index rebuilds
+	api.POST("/admin/indexes/:name/rebuild", middleware.RequireScope(models.ScopeAdmin), handlers.RebuildIndex(sync, catalogues, rebuilds))
+	api.GET("/admin/indexes/:name/rebuild", middleware.RequireScope(models.ScopeAdmin), handlers.GetRebuildStatus(rebuilds))
+
+	// resource: index settings
+	api.GET("/admin/indexes/:name/settings", middleware.RequireScope(models.ScopeAdmin), handlers.GetSearchSettings(searchSettings))
+	api.PUT("/admin/indexes/:name/settings", middleware.RequireScope(models.ScopeAdmin), handlers.UpdateSearchSettings(searchSettings))
+	api.GET("/admin/indexes/:name/merchandising-rules", middleware.RequireScope(models.ScopeAdmin), handlers.ListMerchandisingRules(merchandisingRules))
+	api.POST("/admin/indexes/:name/merchandising-rules", middleware.RequireScope(models.ScopeAdmin), handlers.AddMerchandisingRule(merchandisingRules))
+	api.PUT("/admin/indexes/:name/merchandising-rules/:id", middleware.RequireScope(models.ScopeAdmin), handlers.UpdateMerchandisingRule(merchandisingRules))
+	api.DELETE("/admin/indexes/:name/merchandising-rules/:id", middleware.RequireScope(models.ScopeAdmin), handlers.DeleteMerchandisingRule(merchandisingRules))
+
 	// resource: articles
-	r.POST("/articles", handlers.AddArticle(articles, authors, tags, sync))
-	r.POST("/articles/batch", handlers.AddArticles(articles, authors, tags, sync))
+	api.POST("/articles", middleware.RequireScope(models.ScopeCatalogWrite), handlers.AddArticle(articles, authors, tags, enricher))
+	api.POST("/articles/batch", middleware.RequireScope(models.ScopeCatalogWrite), handlers.AddArticles(articles, authors, tags, enricher))
+	api.GET("/articles", middleware.RequireScope(models.ScopeCatalogRead), handlers.ListArticles(articles))
+	api.GET("/articles/:id", middleware.RequireScope(models.ScopeCatalogRead), handlers.GetArticle(articles))
+	api.PUT("/articles/:id", middleware.RequireScope(models.ScopeCatalogWrite), handlers.UpdateArticle(articles, authors, tags, enricher))
+	api.PATCH("/articles/:id", middleware.RequireScope(models.ScopeCatalogWrite), handlers.PatchArticle(articles, authors, tags, enricher))
+	api.DELETE("/articles/:id", middleware.RequireScope(models.ScopeCatalogWrite), handlers.DeleteArticle(articles))
 
 	// resource: authors
-	r.POST("/authors", handlers.AddAuthor(authors))
-	r.POST("/authors/batch", handlers.AddAuthors(authors))
+	api.POST("/authors", middleware.RequireScope(models.ScopeCatalogWrite), handlers.AddAuthor(authors))
+	api.POST("/authors/batch", middleware.RequireScope(models.ScopeCatalogWrite), handlers.AddAuthors(authors))
 
 	// resource: tags
-	r.POST("/tags", handlers.AddTag(tags))
-	r.PATCH("/tags/:label", handlers.UpdateTagWithLabel(tags, sync))
-	r.POST("/tags/batch", handlers.AddTagsInBatch(tags))
-	r.GET("/tags", handlers.ListAllTags(tags))
-	r.GET("/tags/:label", handlers.GetTagByLabel(tags))
-	r.GET("/tags/:label/articles", handlers.FindArticlesByLabels(articles, tags))
+	api.POST("/tags", middleware.RequireScope(models.ScopeCatalogWrite), handlers.AddTag(tags))
+	api.PATCH("/tags/:label", middleware.RequireScope(models.ScopeCatalogWrite), handlers.UpdateTagWithLabel(tags))
+	api.POST("/tags/batch", middleware.RequireScope(models.ScopeCatalogWrite), handlers.AddTagsInBatch(tags))
+	api.GET("/tags", middleware.RequireScope(models.ScopeCatalogRead), handlers.ListAllTags(tags))
+	api.GET("/tags/:label", middleware.RequireScope(models.ScopeCatalogRead), handlers.GetTagByLabel(tags))
+	api.GET("/tags/:label/articles", middleware.RequireScope(models.ScopeCatalogRead), handlers.FindArticlesByLabels(articles, tags))
+
+	// resource: products
+	api.POST("/products", middleware.RequireScope(models.ScopeCatalogWrite), handlers.AddProduct(products, enricher))
+	api.GET("/products", middleware.RequireScope(models.ScopeCatalogRead), handlers.ListProducts(products))
+	api.GET("/products/:article_id", middleware.RequireScope(models.ScopeCatalogRead), handlers.GetProduct(products, variants))
+	api.PATCH("/products/:article_id", middleware.RequireScope(models.ScopeCatalogWrite), handlers.PatchProduct(products, enricher))
+	api.DELETE("/products/:article_id", middleware.RequireScope(models.ScopeCatalogWrite), handlers.DeleteProduct(products))
+
+	// resource: variants
+	api.POST("/products/:article_id/variants", middleware.RequireScope(models.ScopeCatalogWrite), handlers.AddVariant(products, variants))
+	api.GET("/products/:article_id/variants", middleware.RequireScope(models.ScopeCatalogRead), handlers.ListVariants(products, variants))
+	api.DELETE("/products/:article_id/variants/:variant_id", middleware.RequireScope(models.ScopeCatalogWrite), handlers.DeleteVariant(products, variants))
+	api.PATCH("/variants/:variant_id", middleware.RequireScope(models.ScopeCatalogWrite), handlers.UpdateVariant(variants))
+
+	// resource: catalogues
+	api.POST("/catalogues", middleware.RequireScope(models.ScopeCatalogWrite), handlers.AddCatalogue(catalogues, enricher))
+	api.GET("/catalogues", middleware.RequireScope(models.ScopeCatalogRead), handlers.ListCatalogues(catalogues))
+	api.GET("/catalogues/:code", middleware.RequireScope(models.ScopeCatalogRead), handlers.GetCatalogue(catalogues))
+	api.GET("/catalogues/:code/translations", middleware.RequireScope(models.ScopeCatalogRead), handlers.ListTranslations(catalogues, translations))
+	api.POST("/catalogues/:code/translations/retry", middleware.RequireScope(models.ScopeCatalogWrite), handlers.RetryTranslations(catalogues, enricher))
+	api.PUT("/catalogues/:code/articles/:id", middleware.RequireScope(models.ScopeCatalogWrite), handlers.PutArticleTranslation(catalogues, articles, translations))
+	api.GET("/catalogues/:code/articles/:id", middleware.RequireScope(models.ScopeCatalogRead), handlers.GetTranslation(catalogues, translations, models.EntityArticle, "id"))
+	api.PUT("/catalogues/:code/products/:article_id", middleware.RequireScope(models.ScopeCatalogWrite), handlers.PutProductTranslation(catalogues, products, translations))
+	api.GET("/catalogues/:code/products/:article_id", middleware.RequireScope(models.ScopeCatalogRead), handlers.GetTranslation(catalogues, translations, models.EntityProduct, "article_id"))
 
 	// resource: search (with rate limiting)
-	r.GET("/search", rateLimiter.Middleware(), handlers.SearchArticles(engine))
+	api.GET("/search", middleware.RequireScope(models.ScopeSearch), rateLimiter.Middleware(), handlers.SearchArticles(engine, catalogues, merchandisingRules))
+	api.GET("/search/products", middleware.RequireScope(models.ScopeSearch), rateLimiter.Middleware(), handlers.SearchProducts(engine, catalogues, variants, merchandisingRules))
+	api.GET("/suggest", middleware.RequireScope(models.ScopeSearch), suggestRateLimiter.Middleware(), handlers.Suggest(engine, catalogues, tags, authors))
 
-	r.Run(":8080")
+	r.Run(cfg.HTTP.Addr)
 }
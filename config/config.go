@@ -1,7 +1,545 @@
 package config
 
-type AppConfig struct{}
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	SearchEngineMeilisearch = "meilisearch"
+	SearchEngineMemory      = "memory"
+	SearchEngineSQLite      = "sqlite"
+)
+
+const (
+	DatabaseSQLite   = "sqlite"
+	DatabasePostgres = "postgres"
+)
+
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// AppConfig is read from an optional YAML or TOML file, see Load, and the
+// environment variables named in the comments override it.
+type AppConfig struct {
+	HTTP        HTTPConfig        `yaml:"http" toml:"http"`
+	Database    DatabaseConfig    `yaml:"database" toml:"database"`
+	Search      SearchConfig      `yaml:"search" toml:"search"`
+	RateLimits  RateLimitsConfig  `yaml:"rate_limits" toml:"rate_limits"`
+	Sync        SyncConfig        `yaml:"sync" toml:"sync"`
+	Translation TranslationConfig `yaml:"translation" toml:"translation"`
+	Logging     LoggingConfig     `yaml:"logging" toml:"logging"`
+}
+
+type HTTPConfig struct {
+	// Addr is the address the server listens on, HTTP_ADDR.
+	Addr string `yaml:"addr" toml:"addr"`
+	// AdminAPIKey, ADMIN_API_KEY, is the bearer token creating tenants.
+	// Tenant creation is disabled if empty.
+	AdminAPIKey Secret `yaml:"admin_api_key" toml:"admin_api_key"`
+}
+
+type DatabaseConfig struct {
+	// Driver selects the relational store of the repositories, sqlite or
+	// postgres, DATABASE_DRIVER.
+	Driver string `yaml:"driver" toml:"driver"`
+	// SQLiteDSN, SQLITE_DSN, names the SQLite database.
+	SQLiteDSN string `yaml:"sqlite_dsn" toml:"sqlite_dsn"`
+	// PostgresDSN, POSTGRES_DSN, is the connection URL or key=value DSN of
+	// the PostgreSQL database.
+	PostgresDSN Secret `yaml:"postgres_dsn" toml:"postgres_dsn"`
+}
+
+type SearchConfig struct {
+	// Engine selects the search engine implementation, SEARCH_ENGINE.
+	Engine string `yaml:"engine" toml:"engine"`
+	// FallbackEngine, SEARCH_FALLBACK_ENGINE, serves searches while Engine
+	// is failing. No fallback if empty.
+	FallbackEngine string `yaml:"fallback_engine" toml:"fallback_engine"`
+	// IndexPrefix, SEARCH_INDEX_PREFIX, is prepended to the name of every
+	// index, so that several deployments can share an engine.
+	IndexPrefix string `yaml:"index_prefix" toml:"index_prefix"`
+	// BootstrapRetryBaseDelay, SEARCH_BOOTSTRAP_RETRY_BASE_DELAY, and
+	// BootstrapRetryMaxDelay, SEARCH_BOOTSTRAP_RETRY_MAX_DELAY, bound the
+	// exponential delay between attempts to provision the indexes at startup.
+	BootstrapRetryBaseDelay Duration          `yaml:"bootstrap_retry_base_delay" toml:"bootstrap_retry_base_delay"`
+	BootstrapRetryMaxDelay  Duration          `yaml:"bootstrap_retry_max_delay" toml:"bootstrap_retry_max_delay"`
+	Meilisearch             MeilisearchConfig `yaml:"meilisearch" toml:"meilisearch"`
+}
+
+type MeilisearchConfig struct {
+	// Hosts are the URLs of the Meilisearch nodes, MEILISEARCH_HOSTS as a
+	// comma separated list. Searches are spread over the healthy nodes, all
+	// other requests go to the first healthy one.
+	Hosts []string `yaml:"hosts" toml:"hosts"`
+	// MasterKey, MEILISEARCH_MASTER_KEY, authenticates the management of
+	// indexes and documents. No key if empty.
+	MasterKey Secret `yaml:"master_key" toml:"master_key"`
+	// SearchKey, MEILISEARCH_SEARCH_KEY, is a key with the search action.
+	// If set, searches are sent with tenant tokens signed by it, which only
+	// grant access to the indexes of the searching tenant.
+	SearchKey Secret `yaml:"search_key" toml:"search_key"`
+	// HealthCheckInterval, MEILISEARCH_HEALTH_CHECK_INTERVAL, is the wait
+	// between health checks of the hosts.
+	HealthCheckInterval Duration `yaml:"health_check_interval" toml:"health_check_interval"`
+	// TaskTimeout, MEILISEARCH_TASK_TIMEOUT, bounds the wait for the tasks
+	// writing documents, creating an index or changing its settings.
+	TaskTimeout Duration `yaml:"task_timeout" toml:"task_timeout"`
+}
+
+// RateLimitsConfig holds the requests allowed per minute and client IP.
+type RateLimitsConfig struct {
+	// Search limits searches, SEARCH_RATE_LIMIT.
+	Search int `yaml:"search" toml:"search"`
+	// Suggest limits suggestions, SUGGEST_RATE_LIMIT. Search-as-you-type
+	// sends one per keystroke, so it gets a budget of its own.
+	Suggest int `yaml:"suggest" toml:"suggest"`
+}
+
+// SyncConfig tunes the worker draining the index outbox into the search
+// engine.
+type SyncConfig struct {
+	// PollInterval, SYNC_POLL_INTERVAL, is the wait between polls of an
+	// empty outbox.
+	PollInterval Duration `yaml:"poll_interval" toml:"poll_interval"`
+	// BatchSize, SYNC_BATCH_SIZE, is the number of entries picked up at once.
+	BatchSize int `yaml:"batch_size" toml:"batch_size"`
+	// MaxAttempts, SYNC_MAX_ATTEMPTS, is the number of failures after which
+	// an entry is moved to the dead-letter state.
+	MaxAttempts int `yaml:"max_attempts" toml:"max_attempts"`
+	// RetryBaseDelay, SYNC_RETRY_BASE_DELAY, and RetryMaxDelay,
+	// SYNC_RETRY_MAX_DELAY, bound the exponential delay between attempts.
+	RetryBaseDelay Duration `yaml:"retry_base_delay" toml:"retry_base_delay"`
+	RetryMaxDelay  Duration `yaml:"retry_max_delay" toml:"retry_max_delay"`
+}
+
+type TranslationConfig struct {
+	// AttemptTimeout, TRANSLATION_ATTEMPT_TIMEOUT, bounds a single call to
+	// the translator.
+	AttemptTimeout Duration `yaml:"attempt_timeout" toml:"attempt_timeout"`
+	// RetryTimeout, TRANSLATION_RETRY_TIMEOUT, bounds all attempts to
+	// translate one entity.
+	RetryTimeout Duration `yaml:"retry_timeout" toml:"retry_timeout"`
+}
+
+type LoggingConfig struct {
+	// Level is debug, info, warn or error, LOG_LEVEL.
+	Level string `yaml:"level" toml:"level"`
+	// Format is text or json, LOG_FORMAT.
+	Format string `yaml:"format" toml:"format"`
+}
+
+// NewConfig returns the default configuration.
 func NewConfig() *AppConfig {
-	return &AppConfig{}
+	return &AppConfig{
+		HTTP: HTTPConfig{
+			Addr: ":8080",
+		},
+		Database: DatabaseConfig{
+			Driver:    DatabaseSQLite,
+			SQLiteDSN: "file:articles.db",
+		},
+		Search: SearchConfig{
+			Engine:                  SearchEngineMeilisearch,
+			BootstrapRetryBaseDelay: Duration(time.Second),
+			BootstrapRetryMaxDelay:  Duration(time.Minute),
+			Meilisearch: MeilisearchConfig{
+				Hosts:               []string{"http://localhost:7700"},
+				HealthCheckInterval: Duration(5 * time.Second),
+				TaskTimeout:         Duration(30 * time.Second),
+			},
+		},
+		RateLimits: RateLimitsConfig{
+			Search:  60,
+			Suggest: 300,
+		},
+		Sync: SyncConfig{
+			PollInterval:   Duration(time.Second),
+			BatchSize:      50,
+			MaxAttempts:    10,
+			RetryBaseDelay: Duration(2 * time.Second),
+			RetryMaxDelay:  Duration(10 * time.Minute),
+		},
+		Translation: TranslationConfig{
+			AttemptTimeout: Duration(5 * time.Second),
+			RetryTimeout:   Duration(10 * time.Second),
+		},
+		Logging: LoggingConfig{
+			Level:  "info",
+			Format: LogFormatText,
+		},
+	}
+}
+
+// Duration is a time.Duration written like 1s or 5m in configuration files.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+
+	return nil
+}
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// Secret is a key, password or DSN embedding a password. It is formatted
+// as [REDACTED], so that it cannot end up in logs by accident; convert it to
+// a string to use it.
+type Secret string
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+
+	return "[REDACTED]"
+}
+
+func (s Secret) GoString() string {
+	return `"` + s.String() + `"`
+}
+
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(s.String())
+}
+
+// ValidationError lists every problem of a configuration.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Load returns the default configuration, overridden by the file at path,
+// if given, and by the environment. The format of the file follows its
+// extension, .yaml, .yml or .toml. Any problem with the values is reported
+// in a single *ValidationError.
+func Load(path string) (*AppConfig, error) {
+	config := NewConfig()
+	if path != "" {
+		if err := config.readFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	problems := config.applyEnv(os.Getenv)
+	problems = append(problems, config.validate()...)
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+
+	return config, nil
+}
+
+// readFile decodes the file into the configuration. Keys the configuration
+// does not know are rejected, so that typos do not go unnoticed.
+func (c *AppConfig) readFile(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(content))
+		decoder.KnownFields(true)
+		err = decoder.Decode(c)
+		if errors.Is(err, io.EOF) {
+			// an empty file keeps the defaults
+			err = nil
+		}
+	case ".toml":
+		decoder := toml.NewDecoder(bytes.NewReader(content))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(c)
+	default:
+		return fmt.Errorf("config file %s: unknown format, expected .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// applyEnv overrides the configuration with the environment variables that
+// are set and not empty, and returns the ones that could not be parsed.
+func (c *AppConfig) applyEnv(getenv func(string) string) []string {
+	env := &environment{getenv: getenv}
+
+	env.string("HTTP_ADDR", &c.HTTP.Addr)
+	env.secret("ADMIN_API_KEY", &c.HTTP.AdminAPIKey)
+
+	env.string("DATABASE_DRIVER", &c.Database.Driver)
+	env.string("SQLITE_DSN", &c.Database.SQLiteDSN)
+	env.secret("POSTGRES_DSN", &c.Database.PostgresDSN)
+
+	env.string("SEARCH_ENGINE", &c.Search.Engine)
+	env.string("SEARCH_FALLBACK_ENGINE", &c.Search.FallbackEngine)
+	env.string("SEARCH_INDEX_PREFIX", &c.Search.IndexPrefix)
+	env.duration("SEARCH_BOOTSTRAP_RETRY_BASE_DELAY", &c.Search.BootstrapRetryBaseDelay)
+	env.duration("SEARCH_BOOTSTRAP_RETRY_MAX_DELAY", &c.Search.BootstrapRetryMaxDelay)
+	env.list("MEILISEARCH_HOSTS", &c.Search.Meilisearch.Hosts)
+	env.secret("MEILISEARCH_MASTER_KEY", &c.Search.Meilisearch.MasterKey)
+	env.secret("MEILISEARCH_SEARCH_KEY", &c.Search.Meilisearch.SearchKey)
+	env.duration("MEILISEARCH_HEALTH_CHECK_INTERVAL", &c.Search.Meilisearch.HealthCheckInterval)
+	env.duration("MEILISEARCH_TASK_TIMEOUT", &c.Search.Meilisearch.TaskTimeout)
+
+	env.int("SEARCH_RATE_LIMIT", &c.RateLimits.Search)
+	env.int("SUGGEST_RATE_LIMIT", &c.RateLimits.Suggest)
+
+	env.duration("SYNC_POLL_INTERVAL", &c.Sync.PollInterval)
+	env.int("SYNC_BATCH_SIZE", &c.Sync.BatchSize)
+	env.int("SYNC_MAX_ATTEMPTS", &c.Sync.MaxAttempts)
+	env.duration("SYNC_RETRY_BASE_DELAY", &c.Sync.RetryBaseDelay)
+	env.duration("SYNC_RETRY_MAX_DELAY", &c.Sync.RetryMaxDelay)
+
+	env.duration("TRANSLATION_ATTEMPT_TIMEOUT", &c.Translation.AttemptTimeout)
+	env.duration("TRANSLATION_RETRY_TIMEOUT", &c.Translation.RetryTimeout)
+
+	env.string("LOG_LEVEL", &c.Logging.Level)
+	env.string("LOG_FORMAT", &c.Logging.Format)
+
+	return env.problems
+}
+
+type environment struct {
+	getenv   func(string) string
+	problems []string
+}
+
+func (e *environment) string(name string, target *string) {
+	if value := e.getenv(name); value != "" {
+		*target = value
+	}
+}
+
+func (e *environment) secret(name string, target *Secret) {
+	if value := e.getenv(name); value != "" {
+		*target = Secret(value)
+	}
+}
+
+// list splits a comma separated value.
+func (e *environment) list(name string, target *[]string) {
+	value := e.getenv(name)
+	if value == "" {
+		return
+	}
+
+	*target = nil
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*target = append(*target, item)
+		}
+	}
+}
+
+func (e *environment) int(name string, target *int) {
+	value := e.getenv(name)
+	if value == "" {
+		return
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		e.problems = append(e.problems, fmt.Sprintf("%s: '%s' is not a whole number", name, value))
+		return
+	}
+	*target = parsed
+}
+
+func (e *environment) duration(name string, target *Duration) {
+	value := e.getenv(name)
+	if value == "" {
+		return
+	}
+
+	if err := target.UnmarshalText([]byte(value)); err != nil {
+		e.problems = append(e.problems, fmt.Sprintf("%s: '%s' is not a duration like 1s or 5m", name, value))
+	}
+}
+
+var indexPrefixPattern = regexp.MustCompile(`^[a-z0-9_]*$`)
+
+// validate returns every problem of the configuration, named by the keys
+// of the configuration file.
+func (c *AppConfig) validate() []string {
+	problems := []string{}
+	problem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if _, _, err := net.SplitHostPort(c.HTTP.Addr); err != nil {
+		problem("http.addr: '%s' is not a host:port address like :8080", c.HTTP.Addr)
+	}
+
+	switch c.Database.Driver {
+	case DatabaseSQLite:
+		if c.Database.SQLiteDSN == "" {
+			problem("database.sqlite_dsn: required by the sqlite driver")
+		}
+	case DatabasePostgres:
+		if c.Database.PostgresDSN == "" {
+			problem("database.postgres_dsn: required by the postgres driver")
+		}
+	default:
+		problem("database.driver: unknown driver '%s', expected %s or %s", c.Database.Driver, DatabaseSQLite, DatabasePostgres)
+	}
+
+	engines := []string{SearchEngineMeilisearch, SearchEngineMemory, SearchEngineSQLite}
+	if !contains(engines, c.Search.Engine) {
+		problem("search.engine: unknown engine '%s', expected one of %s", c.Search.Engine, strings.Join(engines, ", "))
+	}
+	switch {
+	case c.Search.FallbackEngine == "":
+	case !contains(engines, c.Search.FallbackEngine):
+		problem("search.fallback_engine: unknown engine '%s', expected one of %s", c.Search.FallbackEngine, strings.Join(engines, ", "))
+	case c.Search.FallbackEngine == c.Search.Engine:
+		problem("search.fallback_engine: has to differ from search.engine")
+	}
+	if c.usesEngine(SearchEngineSQLite) && c.Database.Driver != DatabaseSQLite {
+		problem("search.engine: the sqlite engine keeps its indexes in the sqlite database, not in %s", c.Database.Driver)
+	}
+	if !indexPrefixPattern.MatchString(c.Search.IndexPrefix) {
+		problem("search.index_prefix: '%s' may only contain lowercase letters, digits and underscores", c.Search.IndexPrefix)
+	}
+	if c.Search.BootstrapRetryBaseDelay <= 0 {
+		problem("search.bootstrap_retry_base_delay: has to be positive, got %s", c.Search.BootstrapRetryBaseDelay)
+	}
+	if c.Search.BootstrapRetryMaxDelay < c.Search.BootstrapRetryBaseDelay {
+		problem("search.bootstrap_retry_max_delay: has to be at least search.bootstrap_retry_base_delay, got %s", c.Search.BootstrapRetryMaxDelay)
+	}
+	if c.usesEngine(SearchEngineMeilisearch) {
+		problems = append(problems, c.Search.Meilisearch.validate()...)
+	}
+
+	if c.RateLimits.Search <= 0 {
+		problem("rate_limits.search: has to be positive, got %d", c.RateLimits.Search)
+	}
+	if c.RateLimits.Suggest <= 0 {
+		problem("rate_limits.suggest: has to be positive, got %d", c.RateLimits.Suggest)
+	}
+
+	if c.Sync.PollInterval <= 0 {
+		problem("sync.poll_interval: has to be positive, got %s", c.Sync.PollInterval)
+	}
+	if c.Sync.BatchSize <= 0 {
+		problem("sync.batch_size: has to be positive, got %d", c.Sync.BatchSize)
+	}
+	if c.Sync.MaxAttempts <= 0 {
+		problem("sync.max_attempts: has to be positive, got %d", c.Sync.MaxAttempts)
+	}
+	if c.Sync.RetryBaseDelay <= 0 {
+		problem("sync.retry_base_delay: has to be positive, got %s", c.Sync.RetryBaseDelay)
+	}
+	if c.Sync.RetryMaxDelay < c.Sync.RetryBaseDelay {
+		problem("sync.retry_max_delay: has to be at least sync.retry_base_delay, got %s", c.Sync.RetryMaxDelay)
+	}
+
+	if c.Translation.AttemptTimeout <= 0 {
+		problem("translation.attempt_timeout: has to be positive, got %s", c.Translation.AttemptTimeout)
+	}
+	if c.Translation.RetryTimeout < c.Translation.AttemptTimeout {
+		problem("translation.retry_timeout: has to be at least translation.attempt_timeout, got %s", c.Translation.RetryTimeout)
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Logging.Level)); err != nil {
+		problem("logging.level: unknown level '%s', expected debug, info, warn or error", c.Logging.Level)
+	}
+	if c.Logging.Format != LogFormatText && c.Logging.Format != LogFormatJSON {
+		problem("logging.format: unknown format '%s', expected %s or %s", c.Logging.Format, LogFormatText, LogFormatJSON)
+	}
+
+	return problems
+}
+
+func (c *MeilisearchConfig) validate() []string {
+	problems := []string{}
+	problem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if len(c.Hosts) == 0 {
+		problem("search.meilisearch.hosts: at least one host is required")
+	}
+	seen := map[string]bool{}
+	for _, value := range c.Hosts {
+		host, err := url.Parse(value)
+		if err != nil || (host.Scheme != "http" && host.Scheme != "https") || host.Host == "" {
+			problem("search.meilisearch.hosts: '%s' is not an http or https URL", value)
+			continue
+		}
+		if host.User != nil || host.RawQuery != "" || host.Fragment != "" {
+			problem("search.meilisearch.hosts: '%s' may only consist of scheme, host, port and path", host.Redacted())
+		}
+		if seen[host.String()] {
+			problem("search.meilisearch.hosts: '%s' is listed twice", host.Redacted())
+		}
+		seen[host.String()] = true
+	}
+	if c.SearchKey != "" && c.MasterKey == "" {
+		problem("search.meilisearch.search_key: requires search.meilisearch.master_key, which looks up the uid of the search key")
+	}
+	if c.HealthCheckInterval <= 0 {
+		problem("search.meilisearch.health_check_interval: has to be positive, got %s", c.HealthCheckInterval)
+	}
+	if c.TaskTimeout <= 0 {
+		problem("search.meilisearch.task_timeout: has to be positive, got %s", c.TaskTimeout)
+	}
+
+	return problems
+}
+
+func (c *AppConfig) usesEngine(engine string) bool {
+	return c.Search.Engine == engine || c.Search.FallbackEngine == engine
+}
+
+// NewLogger returns the logger writing to w in the configured format,
+// dropping records below the configured level.
+func (c LoggingConfig) NewLogger(w io.Writer) *slog.Logger {
+	var level slog.Level
+	level.UnmarshalText([]byte(c.Level))
+
+	options := &slog.HandlerOptions{Level: level}
+	if c.Format == LogFormatJSON {
+		return slog.New(slog.NewJSONHandler(w, options))
+	}
+
+	return slog.New(slog.NewTextHandler(w, options))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
 }
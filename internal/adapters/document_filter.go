@@ -0,0 +1,81 @@
+package adapters
+
+import (
+	"fmt"
+	"mini-search-platform/internal/search"
+	"strings"
+)
+
+// documentFilter reports whether a document matches a filter.
+type documentFilter func(fields map[string][]string) bool
+
+// compileDocumentFilter compiles the filter for the in-process engines,
+// rejecting attributes that are not filterable themselves or nested in a
+// filterable attribute.
+func compileDocumentFilter(filter search.Filter, filterable []string) (documentFilter, error) {
+	conditions := make([]documentFilter, len(filter))
+	for i, condition := range filter {
+		if !isFilterable(condition.Attribute, filterable) {
+			return nil, fmt.Errorf("attribute '%s' is not filterable", condition.Attribute)
+		}
+
+		compiled, err := compileCondition(condition)
+		if err != nil {
+			return nil, err
+		}
+		conditions[i] = compiled
+	}
+
+	return func(fields map[string][]string) bool {
+		for _, condition := range conditions {
+			if !condition(fields) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+func compileCondition(condition search.Condition) (documentFilter, error) {
+	attribute, values := condition.Attribute, condition.Values
+
+	switch condition.Operator {
+	case search.FilterIn:
+		return func(fields map[string][]string) bool {
+			for _, field := range fields[attribute] {
+				for _, value := range values {
+					if strings.EqualFold(field, value) {
+						return true
+					}
+				}
+			}
+			return false
+		}, nil
+	case search.FilterAtLeast, search.FilterAtMost:
+		if len(values) != 1 {
+			return nil, fmt.Errorf("expected a single value for '%s'", attribute)
+		}
+		return func(fields map[string][]string) bool {
+			for _, field := range fields[attribute] {
+				c := compareDocumentValues([]string{field}, values)
+				if (condition.Operator == search.FilterAtLeast && c >= 0) || (condition.Operator == search.FilterAtMost && c <= 0) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported filter operator '%s'", condition.Operator)
+}
+
+// isFilterable reports whether the attribute is filterable itself or nested
+// in a filterable attribute.
+func isFilterable(attribute string, filterable []string) bool {
+	for _, f := range filterable {
+		if attribute == f || strings.HasPrefix(attribute, f+".") {
+			return true
+		}
+	}
+	return false
+}
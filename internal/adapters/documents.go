@@ -0,0 +1,317 @@
+package adapters
+
+import (
+	"encoding/json"
+	"fmt"
+	"mini-search-platform/internal/search"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type sortKey struct {
+	attribute  string
+	descending bool
+}
+
+// parseSortKeys parses sort rules like title:asc, rejecting attributes that
+// are not sortable.
+func parseSortKeys(sortBy []string, sortable []string) ([]sortKey, error) {
+	keys := []sortKey{}
+	for _, rule := range sortBy {
+		if rule == "" {
+			continue
+		}
+
+		attribute, direction, _ := strings.Cut(rule, ":")
+		if direction != "asc" && direction != "desc" {
+			return nil, fmt.Errorf("invalid sort '%s', expected attribute:asc or attribute:desc", rule)
+		}
+		if !contains(sortable, attribute) {
+			return nil, fmt.Errorf("attribute '%s' is not sortable", attribute)
+		}
+
+		keys = append(keys, sortKey{attribute: attribute, descending: direction == "desc"})
+	}
+
+	return keys, nil
+}
+
+// compareDocumentValues compares the first value of two attributes, numerically
+// if both are numbers. Documents without the attribute come last.
+func compareDocumentValues(a, b []string) int {
+	switch {
+	case len(a) == 0 && len(b) == 0:
+		return 0
+	case len(a) == 0:
+		return 1
+	case len(b) == 0:
+		return -1
+	}
+
+	x, errX := strconv.ParseFloat(a[0], 64)
+	y, errY := strconv.ParseFloat(b[0], 64)
+	if errX == nil && errY == nil {
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+
+	return strings.Compare(strings.ToLower(a[0]), strings.ToLower(b[0]))
+}
+
+// tokenize lowercases the text and splits it into terms, dropping stop
+// words.
+func tokenize(text string, stopWords map[string]bool) []string {
+	terms := []string{}
+	for _, term := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if !stopWords[term] {
+			terms = append(terms, term)
+		}
+	}
+
+	return terms
+}
+
+func stopWordSet(words []string) map[string]bool {
+	stopWords := make(map[string]bool, len(words))
+	for _, word := range words {
+		stopWords[strings.ToLower(word)] = true
+	}
+
+	return stopWords
+}
+
+// queryTerm is a term or phrase of the query together with its synonyms. A
+// document matches an alternative if it contains all of its terms.
+type queryTerm struct {
+	alternatives [][]string
+}
+
+// synonymSet tokenizes the synonyms, keyed by the terms of the word or
+// phrase they are synonyms of.
+func synonymSet(synonyms map[string][]string, stopWords map[string]bool) map[string][][]string {
+	set := map[string][][]string{}
+	for word, alternatives := range synonyms {
+		key := strings.Join(tokenize(word, stopWords), " ")
+		if key == "" {
+			continue
+		}
+		for _, alternative := range alternatives {
+			if terms := tokenize(alternative, stopWords); len(terms) > 0 {
+				set[key] = append(set[key], terms)
+			}
+		}
+	}
+
+	return set
+}
+
+// expandQuery groups the query terms into the longest phrases having
+// synonyms and adds their synonyms as alternatives. The first alternative
+// is always the query itself.
+func expandQuery(terms []string, synonyms map[string][][]string) []queryTerm {
+	expanded := []queryTerm{}
+	for i := 0; i < len(terms); {
+		length := 1
+		for n := len(terms) - i; n > 1; n-- {
+			if _, ok := synonyms[strings.Join(terms[i:i+n], " ")]; ok {
+				length = n
+				break
+			}
+		}
+
+		phrase := terms[i : i+length]
+		alternatives := append([][]string{phrase}, synonyms[strings.Join(phrase, " ")]...)
+		expanded = append(expanded, queryTerm{alternatives: alternatives})
+		i += length
+	}
+
+	return expanded
+}
+
+// highlightTerms returns the terms of every alternative.
+func highlightTerms(query []queryTerm) []string {
+	terms := []string{}
+	for _, term := range query {
+		for _, alternative := range term.alternatives {
+			terms = append(terms, alternative...)
+		}
+	}
+
+	return terms
+}
+
+// decodeDocument encodes the document as JSON and flattens it into the
+// values of its attributes and the text of its string attributes.
+func decodeDocument(document interface{}) ([]byte, map[string][]string, map[string][]string, error) {
+	source, err := json.Marshal(document)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(source, &value); err != nil {
+		return nil, nil, nil, err
+	}
+
+	fields := map[string][]string{}
+	text := map[string][]string{}
+	flattenDocument("", value, fields, text)
+
+	return source, fields, text, nil
+}
+
+// attributeText returns the text of the attribute and its nested fields.
+func attributeText(text map[string][]string, attribute string) []string {
+	values := []string{}
+	for path, nested := range text {
+		if path == attribute || strings.HasPrefix(path, attribute+".") {
+			values = append(values, nested...)
+		}
+	}
+
+	return values
+}
+
+// flattenDocument collects the scalar values of a decoded JSON document by
+// their dotted path; arrays contribute all their elements to the same path.
+// String values are collected into text as well.
+func flattenDocument(path string, value interface{}, fields, text map[string][]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range v {
+			if path != "" {
+				key = path + "." + key
+			}
+			flattenDocument(key, nested, fields, text)
+		}
+	case []interface{}:
+		for _, nested := range v {
+			flattenDocument(path, nested, fields, text)
+		}
+	case string:
+		fields[path] = append(fields[path], v)
+		text[path] = append(text[path], v)
+	case float64:
+		fields[path] = append(fields[path], strconv.FormatFloat(v, 'f', -1, 64))
+	case bool:
+		fields[path] = append(fields[path], strconv.FormatBool(v))
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// searchLimit applies the default limit of Meilisearch.
+func searchLimit(limit int) int {
+	if limit <= 0 {
+		return 20
+	}
+
+	return limit
+}
+
+// documentPage is a page of matching documents of the in-process engines.
+type documentPage struct {
+	sources [][]byte
+	// snippets holds the highlighted snippet of every source, if any.
+	snippets []string
+	total    int
+	facets   map[string]map[string]int
+	// terms are the query terms and synonyms to highlight, prefix the
+	// query term that is highlighted as a prefix too, if any.
+	terms  []string
+	prefix string
+}
+
+func (p documentPage) articles(query string, options search.SearchOptions) (search.SearchResponse, error) {
+	response := search.SearchResponse{
+		Query:  query,
+		Hits:   []search.SearchHit{},
+		Offset: options.Offset,
+		Limit:  searchLimit(options.Limit),
+		Total:  p.total,
+		Facets: p.facets,
+	}
+
+	format := formatter{terms: p.terms, prefix: p.prefix, options: options}
+	for i, source := range p.sources {
+		var hit search.SearchHit
+		if err := json.Unmarshal(source, &hit); err != nil {
+			return response, err
+		}
+		if i < len(p.snippets) {
+			hit.Snippet = p.snippets[i]
+		}
+
+		formatted, err := format.format(source)
+		if err != nil {
+			return response, err
+		}
+		hit.Formatted = formatted
+		hit.Retain(options.AttributesToRetrieve)
+		response.Hits = append(response.Hits, hit)
+	}
+
+	return response, nil
+}
+
+func (p documentPage) products(query string, options search.SearchOptions) (search.ProductSearchResponse, error) {
+	response := search.ProductSearchResponse{
+		Query:  query,
+		Hits:   []search.ProductHit{},
+		Offset: options.Offset,
+		Limit:  searchLimit(options.Limit),
+		Total:  p.total,
+		Facets: p.facets,
+	}
+
+	for _, source := range p.sources {
+		var hit search.ProductHit
+		if err := json.Unmarshal(source, &hit); err != nil {
+			return response, err
+		}
+		response.Hits = append(response.Hits, hit)
+	}
+
+	return response, nil
+}
+
+// countFacets counts the documents per value of every facet, counting a
+// value once per document.
+func countFacets(documents []map[string][]string, names, attributes []string) map[string]map[string]int {
+	if len(names) == 0 {
+		return nil
+	}
+
+	facets := make(map[string]map[string]int, len(names))
+	for i, name := range names {
+		counts := map[string]int{}
+		for _, fields := range documents {
+			seen := map[string]bool{}
+			for _, value := range fields[attributes[i]] {
+				if !seen[value] {
+					seen[value] = true
+					counts[value]++
+				}
+			}
+		}
+		facets[name] = counts
+	}
+
+	return facets
+}
@@ -0,0 +1,48 @@
+package adapters
+
+import (
+	"database/sql"
+	"fmt"
+	"mini-search-platform/config"
+	"mini-search-platform/internal/search"
+)
+
+// NewSearchEngine returns the search engine selected by the configuration,
+// wrapped into a failover engine if a fallback engine is configured. The
+// sqlite engine keeps its indexes in db, which has to be a SQLite database,
+// see config.Load.
+func NewSearchEngine(cfg *config.AppConfig, db *sql.DB) (search.SearchEngine, error) {
+	search.IndexPrefix = cfg.Search.IndexPrefix
+
+	primary, err := newSearchEngine(cfg, cfg.Search.Engine, db)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Search.FallbackEngine == "" {
+		return primary, nil
+	}
+
+	secondary, err := newSearchEngine(cfg, cfg.Search.FallbackEngine, db)
+	if err != nil {
+		return nil, err
+	}
+
+	return search.NewFailoverEngine(primary, secondary), nil
+}
+
+func newSearchEngine(cfg *config.AppConfig, name string, db *sql.DB) (search.SearchEngine, error) {
+	switch name {
+	case config.SearchEngineMeilisearch:
+		engine, err := Init(cfg.Search.Meilisearch)
+		if err != nil {
+			return nil, err
+		}
+		return engine, nil
+	case config.SearchEngineMemory:
+		return NewMemoryEngine(), nil
+	case config.SearchEngineSQLite:
+		return NewSQLliteSearchEngine(db)
+	}
+
+	return nil, fmt.Errorf("unknown search engine '%s'", name)
+}
@@ -0,0 +1,138 @@
+package adapters
+
+import (
+	"encoding/json"
+	"mini-search-platform/internal/search"
+	"strings"
+	"unicode"
+)
+
+// formatter builds the _formatted view of hits for the in-process engines.
+// Words equal to one of the terms are highlighted, and words starting with
+// the prefix up to its length.
+type formatter struct {
+	terms   []string
+	prefix  string
+	options search.SearchOptions
+}
+
+type word struct {
+	start, end int
+	// highlight is the length of the highlighted part in bytes.
+	highlight int
+}
+
+func (f formatter) format(source []byte) (search.Formatted, error) {
+	attributes := f.options.FormattedAttributes()
+	if len(attributes) == 0 {
+		return nil, nil
+	}
+
+	var document map[string]interface{}
+	if err := json.Unmarshal(source, &document); err != nil {
+		return nil, err
+	}
+
+	formatted := search.Formatted{}
+	for _, attribute := range attributes {
+		text, ok := document[attribute].(string)
+		if !ok {
+			continue
+		}
+		formatted[attribute] = f.formatText(text, contains(f.options.Highlight, attribute), f.options.Crop[attribute])
+	}
+
+	return formatted, nil
+}
+
+// formatText highlights the matches in the text and crops it to the given
+// number of words around the first match, unless crop is 0.
+func (f formatter) formatText(text string, highlight bool, crop int) string {
+	words := f.words(text)
+
+	first, last := 0, len(words)
+	cropped := crop > 0 && crop < len(words)
+	if cropped {
+		for i, w := range words {
+			if w.highlight > 0 {
+				first = i - (crop-1)/2
+				break
+			}
+		}
+		first = max(0, min(first, len(words)-crop))
+		last = first + crop
+	}
+
+	var formatted strings.Builder
+	start, end := 0, len(text)
+	if cropped {
+		start, end = words[first].start, words[last-1].end
+		if first > 0 {
+			formatted.WriteString(search.CropMarker)
+		}
+	}
+
+	position := start
+	for _, w := range words[first:last] {
+		if !highlight || w.highlight == 0 {
+			continue
+		}
+		formatted.WriteString(text[position:w.start])
+		formatted.WriteString(f.options.HighlightPreTag)
+		formatted.WriteString(text[w.start : w.start+w.highlight])
+		formatted.WriteString(f.options.HighlightPostTag)
+		position = w.start + w.highlight
+	}
+	formatted.WriteString(text[position:end])
+
+	if cropped && last < len(words) {
+		formatted.WriteString(search.CropMarker)
+	}
+
+	return formatted.String()
+}
+
+// words splits the text into words like tokenize and marks the matching
+// ones.
+func (f formatter) words(text string) []word {
+	words := []word{}
+	start := -1
+	for i, r := range text + " " {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			words = append(words, f.match(text, word{start: start, end: i}))
+			start = -1
+		}
+	}
+
+	return words
+}
+
+func (f formatter) match(text string, w word) word {
+	lower := strings.ToLower(text[w.start:w.end])
+	if contains(f.terms, lower) {
+		w.highlight = w.end - w.start
+		return w
+	}
+
+	if f.prefix != "" && strings.HasPrefix(lower, f.prefix) {
+		// count the runes of the prefix in the original text, as
+		// lowercasing may change their length
+		n := len([]rune(f.prefix))
+		for offset := range text[w.start:w.end] {
+			if n == 0 {
+				w.highlight = offset
+				return w
+			}
+			n--
+		}
+		w.highlight = w.end - w.start
+	}
+
+	return w
+}
@@ -0,0 +1,92 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// localDictionaries hold a small fashion glossary per target language.
+var localDictionaries = map[string]map[string]string{
+	"de": {
+		"black": "schwarz", "blue": "blau", "dress": "kleid", "for": "für", "green": "grün",
+		"jacket": "jacke", "men": "herren", "red": "rot", "running": "lauf", "shirt": "hemd",
+		"shoe": "schuh", "shoes": "schuhe", "summer": "sommer", "white": "weiß", "winter": "winter",
+		"women": "damen", "and": "und", "with": "mit", "the": "die",
+	},
+	"fr": {
+		"black": "noir", "blue": "bleu", "dress": "robe", "for": "pour", "green": "vert",
+		"jacket": "veste", "men": "hommes", "red": "rouge", "running": "course", "shirt": "chemise",
+		"shoe": "chaussure", "shoes": "chaussures", "summer": "été", "white": "blanc", "winter": "hiver",
+		"women": "femmes", "and": "et", "with": "avec", "the": "le",
+	},
+	"es": {
+		"black": "negro", "blue": "azul", "dress": "vestido", "for": "para", "green": "verde",
+		"jacket": "chaqueta", "men": "hombre", "red": "rojo", "running": "correr", "shirt": "camisa",
+		"shoe": "zapato", "shoes": "zapatos", "summer": "verano", "white": "blanco", "winter": "invierno",
+		"women": "mujer", "and": "y", "with": "con", "the": "el",
+	},
+	"it": {
+		"black": "nero", "blue": "blu", "dress": "vestito", "for": "per", "green": "verde",
+		"jacket": "giacca", "men": "uomo", "red": "rosso", "running": "corsa", "shirt": "camicia",
+		"shoe": "scarpa", "shoes": "scarpe", "summer": "estate", "white": "bianco", "winter": "inverno",
+		"women": "donna", "and": "e", "with": "con", "the": "il",
+	},
+	"nl": {
+		"black": "zwart", "blue": "blauw", "dress": "jurk", "for": "voor", "green": "groen",
+		"jacket": "jas", "men": "heren", "red": "rood", "running": "hardloop", "shirt": "overhemd",
+		"shoe": "schoen", "shoes": "schoenen", "summer": "zomer", "white": "wit", "winter": "winter",
+		"women": "dames", "and": "en", "with": "met", "the": "de",
+	},
+	"pl": {
+		"black": "czarny", "blue": "niebieski", "dress": "sukienka", "for": "dla", "green": "zielony",
+		"jacket": "kurtka", "men": "męskie", "red": "czerwony", "running": "biegowe", "shirt": "koszula",
+		"shoe": "but", "shoes": "buty", "summer": "lato", "white": "biały", "winter": "zima",
+		"women": "damskie", "and": "i", "with": "z",
+	},
+}
+
+// LocalTranslator is a deterministic stand-in for a cloud translation API,
+// meant for development and tests. Words found in its glossary are replaced
+// word by word; anything else is kept as is.
+type LocalTranslator struct{}
+
+func NewLocalTranslator() *LocalTranslator {
+	return &LocalTranslator{}
+}
+
+func (t *LocalTranslator) Translate(ctx context.Context, text, sourceLanguage, targetLanguage string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	if sourceLanguage == targetLanguage {
+		return text, nil
+	}
+
+	if sourceLanguage != "en" {
+		return "", fmt.Errorf("local translator only translates from 'en', got '%s'", sourceLanguage)
+	}
+
+	dictionary, ok := localDictionaries[targetLanguage]
+	if !ok {
+		return "", fmt.Errorf("local translator does not support language '%s'", targetLanguage)
+	}
+
+	words := strings.Fields(text)
+	for i, word := range words {
+		translated, ok := dictionary[strings.ToLower(word)]
+		if !ok {
+			continue
+		}
+		if first := []rune(word)[0]; unicode.IsUpper(first) {
+			runes := []rune(translated)
+			runes[0] = unicode.ToUpper(runes[0])
+			translated = string(runes)
+		}
+		words[i] = translated
+	}
+
+	return strings.Join(words, " "), nil
+}
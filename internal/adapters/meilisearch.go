@@ -1,69 +1,296 @@
 package adapters
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"mini-search-platform/config"
 	"mini-search-platform/internal/models"
 	"mini-search-platform/internal/search"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/meilisearch/meilisearch-go"
 )
 
-var (
-	DefaultHost = "http://localhost:7700"
-
-	Client meilisearch.ServiceManager
-	Index  meilisearch.IndexManager
-)
+var Client meilisearch.ServiceManager
 
+// MeilisearchEngine serves the indexes of a single tenant. Use ForTenant to
+// obtain the engine of another tenant and ForCatalogue to obtain the engine
+// of one of its catalogues.
 type MeilisearchEngine struct {
-	Index meilisearch.IndexManager
+	Client      meilisearch.ServiceManager
+	Index       meilisearch.IndexManager
+	Products    meilisearch.IndexManager
+	tenantID    int
+	catalogue   *models.Catalogue
+	articlesUID string
+	productsUID string
+	rebuilds    *rebuilds
+	keys        *meilisearchKeys
+	taskTimeout time.Duration
 }
 
-func Init() *MeilisearchEngine {
-	Client = meilisearch.New(DefaultHost)
-	_, err := Client.CreateIndex(&meilisearch.IndexConfig{
-		Uid:        search.ARTICLES_INDEX_NAME,
-		PrimaryKey: "id",
-	})
+// Init connects to the Meilisearch nodes of the configuration and checks
+// their health in the background if there are several.
+func Init(cfg config.MeilisearchConfig) (*MeilisearchEngine, error) {
+	pool, err := newHostPool(cfg.Hosts)
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+	if len(cfg.Hosts) > 1 {
+		go pool.check(context.Background(), time.Duration(cfg.HealthCheckInterval))
+	}
+
+	httpClient := pool.httpClient()
+	newClient := func(apiKey string) meilisearch.ServiceManager {
+		return meilisearch.New(poolHost, meilisearch.WithAPIKey(apiKey), meilisearch.WithCustomClient(httpClient))
+	}
+	Client = newClient(string(cfg.MasterKey))
+	keys := newMeilisearchKeys(Client, newClient, string(cfg.MasterKey), string(cfg.SearchKey))
+
+	return newMeilisearchEngine(Client, 0, nil, &rebuilds{tasks: map[string]int64{}}, keys, time.Duration(cfg.TaskTimeout)), nil
+}
+
+func NewMeilisearchEngine(client meilisearch.ServiceManager, tenantID int) *MeilisearchEngine {
+	return newMeilisearchEngine(client, tenantID, nil, &rebuilds{tasks: map[string]int64{}}, newMeilisearchKeys(client, nil, "", ""), DefaultTaskTimeout)
+}
+
+func newMeilisearchEngine(client meilisearch.ServiceManager, tenantID int, catalogue *models.Catalogue, rebuilds *rebuilds, keys *meilisearchKeys, taskTimeout time.Duration) *MeilisearchEngine {
+	engine := &MeilisearchEngine{
+		Client:      client,
+		tenantID:    tenantID,
+		catalogue:   catalogue,
+		rebuilds:    rebuilds,
+		keys:        keys,
+		taskTimeout: taskTimeout,
+	}
+	engine.articlesUID = engine.indexName(search.ARTICLES_INDEX_NAME)
+	engine.productsUID = engine.indexName(search.PRODUCTS_INDEX_NAME)
+	engine.Index = client.Index(engine.articlesUID)
+	engine.Products = client.Index(engine.productsUID)
+
+	return engine
+}
+
+// markUnavailable wraps errors of nodes that could not be reached or failed
+// to answer into search.ErrUnavailable, see search.FailoverEngine.
+func markUnavailable(err *error) {
+	var meilisearchErr *meilisearch.Error
+	if *err == nil || !errors.As(*err, &meilisearchErr) {
+		return
+	}
+
+	switch meilisearchErr.ErrCode {
+	case meilisearch.MeilisearchTimeoutError, meilisearch.MeilisearchCommunicationError, meilisearch.MeilisearchMaxRetriesExceeded:
+	default:
+		if meilisearchErr.StatusCode < 500 {
+			return
+		}
 	}
+	*err = fmt.Errorf("%w: %w", search.ErrUnavailable, *err)
+}
 
-	Index = Client.Index(search.ARTICLES_INDEX_NAME)
-	_, err = Index.UpdateSearchableAttributes(&[]string{"title", "body", "author", "tags"})
+func (e *MeilisearchEngine) ForTenant(tenantID int) search.SearchEngine {
+	return newMeilisearchEngine(e.Client, tenantID, nil, e.rebuilds, e.keys, e.taskTimeout)
+}
+
+func (e *MeilisearchEngine) ForCatalogue(catalogue *models.Catalogue) search.SearchEngine {
+	return newMeilisearchEngine(e.Client, e.tenantID, catalogue, e.rebuilds, e.keys, e.taskTimeout)
+}
+
+// CreateIndexes creates the articles and products indexes of the tenant, or
+// of the catalogue, if they are missing and brings their attributes up to
+// date. It is idempotent and waits for the tasks to finish.
+func (e *MeilisearchEngine) CreateIndexes() (err error) {
+	defer e.keys.redact(&err)
+
+	err = e.ensureIndex(e.articlesUID, e.settings(search.ARTICLES_INDEX_NAME))
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	_, err = Index.UpdateFilterableAttributes(&[]string{"author", "tags"})
+	return e.ensureIndex(e.productsUID, e.settings(search.PRODUCTS_INDEX_NAME))
+}
+
+func (e *MeilisearchEngine) indexName(base string) string {
+	return search.IndexNameFor(e.tenantID, base, e.catalogue)
+}
+
+func (e *MeilisearchEngine) settings(base string) search.IndexSettings {
+	return search.IndexSettingsFor(base, e.catalogue)
+}
+
+// searchIndex returns the index to search, through the tenant token of the
+// tenant if a search key is configured.
+func (e *MeilisearchEngine) searchIndex(index meilisearch.IndexManager, uid string) (meilisearch.IndexManager, error) {
+	if e.keys.searchKey == "" {
+		return index, nil
+	}
+
+	client, err := e.keys.searchClient(e.tenantID)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	_, err = Index.UpdateSortableAttributes(&[]string{"author", "title"})
+	return client.Index(uid), nil
+}
+
+// UpdateSettings applies the synonyms, stop words and ranking rules of the
+// search settings; empty ones reset the index to its defaults.
+func (e *MeilisearchEngine) UpdateSettings(index string, settings *models.SearchSettings) error {
+	uid, err := e.uid(index)
 	if err != nil {
-		panic(err)
+		return err
 	}
+	tuned := e.settings(index).WithSearchSettings(settings)
 
-	return &MeilisearchEngine{Index: Index}
+	return e.write(uid, func(index meilisearch.IndexManager) (*meilisearch.TaskInfo, error) {
+		return e.updateSearchSettings(index, tuned)
+	})
 }
 
 func (e *MeilisearchEngine) IndexArticles(articles []*models.Article) error {
-	_, err := e.Index.AddDocuments(articles)
-	return err
+	return e.write(e.articlesUID, func(index meilisearch.IndexManager) (*meilisearch.TaskInfo, error) {
+		return index.AddDocuments(search.NewArticleDocuments(articles))
+	})
 }
 
-func NewMeilisearchEngine(index meilisearch.IndexManager) *MeilisearchEngine {
-	return &MeilisearchEngine{Index: index}
+func (e *MeilisearchEngine) DeleteArticles(ids []int) error {
+	identifiers := make([]string, len(ids))
+	for i, id := range ids {
+		identifiers[i] = strconv.Itoa(id)
+	}
+
+	return e.write(e.articlesUID, func(index meilisearch.IndexManager) (*meilisearch.TaskInfo, error) {
+		return index.DeleteDocuments(identifiers)
+	})
 }
 
-func (e *MeilisearchEngine) Search(query string, options search.SearchOptions) (search.SearchResponse, error) {
-	result, err := e.Index.Search(query, &meilisearch.SearchRequest{
+func (e *MeilisearchEngine) SearchProducts(query string, options search.SearchOptions) (search.ProductSearchResponse, error) {
+	return e.SearchProductsContext(context.Background(), query, options)
+}
+
+func (e *MeilisearchEngine) SearchProductsContext(ctx context.Context, query string, options search.SearchOptions) (_ search.ProductSearchResponse, err error) {
+	defer e.keys.redact(&err)
+	defer markUnavailable(&err)
+
+	settings := e.settings(search.PRODUCTS_INDEX_NAME)
+	facets, err := settings.FacetAttributes(options.Facets)
+	if err != nil {
+		return search.ProductSearchResponse{
+			Query: query,
+		}, err
+	}
+
+	filter, err := meilisearchFilter(options.Filter)
+	if err != nil {
+		return search.ProductSearchResponse{
+			Query: query,
+		}, err
+	}
+
+	index, err := e.searchIndex(e.Products, e.productsUID)
+	if err != nil {
+		return search.ProductSearchResponse{
+			Query: query,
+		}, err
+	}
+
+	result, err := index.SearchWithContext(ctx, query, &meilisearch.SearchRequest{
 		Limit:  int64(options.Limit),
 		Offset: int64(options.Offset),
-		Filter: options.Filter,
+		Filter: filter,
 		Sort:   options.Sort,
+		Facets: facets,
+	})
+	if err != nil {
+		return search.ProductSearchResponse{
+			Query: query,
+		}, err
+	}
+
+	resultJSON, err := result.MarshalJSON()
+	if err != nil {
+		return search.ProductSearchResponse{
+			Query: query,
+		}, err
+	}
+
+	var hits = struct {
+		search.ProductHits
+		FacetDistribution map[string]map[string]int `json:"facetDistribution"`
+	}{}
+	if err := json.Unmarshal(resultJSON, &hits); err != nil {
+		return search.ProductSearchResponse{
+			Query: query,
+		}, err
+	}
+
+	return search.ProductSearchResponse{
+		Hits:   hits.Hits,
+		Offset: int(result.Offset),
+		Limit:  int(result.Limit),
+		Total:  int(result.EstimatedTotalHits),
+		Query:  result.Query,
+		Facets: facetCounts(hits.FacetDistribution, options.Facets, facets),
+	}, nil
+}
+
+func (e *MeilisearchEngine) IndexProducts(products []*search.ProductDocument) error {
+	return e.write(e.productsUID, func(index meilisearch.IndexManager) (*meilisearch.TaskInfo, error) {
+		return index.AddDocuments(products)
+	})
+}
+
+func (e *MeilisearchEngine) DeleteProducts(articleIDs []string) error {
+	return e.write(e.productsUID, func(index meilisearch.IndexManager) (*meilisearch.TaskInfo, error) {
+		return index.DeleteDocuments(articleIDs)
 	})
+}
+
+func (e *MeilisearchEngine) Search(query string, options search.SearchOptions) (search.SearchResponse, error) {
+	return e.SearchContext(context.Background(), query, options)
+}
+
+func (e *MeilisearchEngine) SearchContext(ctx context.Context, query string, options search.SearchOptions) (_ search.SearchResponse, err error) {
+	defer e.keys.redact(&err)
+	defer markUnavailable(&err)
+
+	settings := e.settings(search.ARTICLES_INDEX_NAME)
+	facets, err := settings.FacetAttributes(options.Facets)
+	if err != nil {
+		return search.SearchResponse{
+			Query: query,
+		}, err
+	}
+
+	filter, err := meilisearchFilter(options.Filter)
+	if err != nil {
+		return search.SearchResponse{
+			Query: query,
+		}, err
+	}
+
+	request := &meilisearch.SearchRequest{
+		Limit:  int64(options.Limit),
+		Offset: int64(options.Offset),
+		Filter: filter,
+		Sort:   options.Sort,
+		Facets: facets,
+	}
+	formatRequest(request, options)
+
+	index, err := e.searchIndex(e.Index, e.articlesUID)
+	if err != nil {
+		return search.SearchResponse{
+			Query: query,
+		}, err
+	}
+
+	result, err := index.SearchWithContext(ctx, query, request)
 	if err != nil {
 		return search.SearchResponse{
 			Query: query,
@@ -77,18 +304,107 @@ func (e *MeilisearchEngine) Search(query string, options search.SearchOptions) (
 		}, err
 	}
 
-	var hits = search.SearchHits{}
+	var hits = struct {
+		search.SearchHits
+		FacetDistribution map[string]map[string]int `json:"facetDistribution"`
+	}{}
 	if err := json.Unmarshal(resultJSON, &hits); err != nil {
 		return search.SearchResponse{
 			Query: query,
 		}, err
 	}
 
+	formatted := options.FormattedAttributes()
+	for i := range hits.Hits {
+		// Meilisearch formats every retrieved attribute
+		for attribute := range hits.Hits[i].Formatted {
+			if !contains(formatted, attribute) {
+				delete(hits.Hits[i].Formatted, attribute)
+			}
+		}
+		hits.Hits[i].Retain(options.AttributesToRetrieve)
+	}
+
 	return search.SearchResponse{
 		Hits:   hits.Hits,
 		Offset: int(result.Offset),
 		Limit:  int(result.Limit),
 		Total:  int(result.EstimatedTotalHits),
 		Query:  result.Query,
+		Facets: facetCounts(hits.FacetDistribution, options.Facets, facets),
 	}, nil
 }
+
+// formatRequest asks for the highlighting and cropping of the options. The
+// formatted attributes are retrieved as well, so that they can be formatted.
+func formatRequest(request *meilisearch.SearchRequest, options search.SearchOptions) {
+	formatted := options.FormattedAttributes()
+	if len(formatted) == 0 {
+		request.AttributesToRetrieve = options.AttributesToRetrieve
+		return
+	}
+
+	request.AttributesToHighlight = options.Highlight
+	for attribute, length := range options.Crop {
+		request.AttributesToCrop = append(request.AttributesToCrop, fmt.Sprintf("%s:%d", attribute, length))
+	}
+	request.CropMarker = search.CropMarker
+	request.HighlightPreTag = options.HighlightPreTag
+	request.HighlightPostTag = options.HighlightPostTag
+
+	if len(options.AttributesToRetrieve) > 0 {
+		request.AttributesToRetrieve = append([]string{}, options.AttributesToRetrieve...)
+		for _, attribute := range formatted {
+			if !contains(request.AttributesToRetrieve, attribute) {
+				request.AttributesToRetrieve = append(request.AttributesToRetrieve, attribute)
+			}
+		}
+	}
+}
+
+// facetCounts keys the facet distribution of Meilisearch, which is keyed by
+// attribute, by facet name.
+func facetCounts(distribution map[string]map[string]int, names, attributes []string) map[string]map[string]int {
+	if len(names) == 0 {
+		return nil
+	}
+
+	facets := make(map[string]map[string]int, len(names))
+	for i, name := range names {
+		facets[name] = distribution[attributes[i]]
+		if facets[name] == nil {
+			facets[name] = map[string]int{}
+		}
+	}
+
+	return facets
+}
+
+// meilisearchFilter compiles the filter into the Meilisearch filter syntax.
+// Values are quoted and escaped, or validated as numbers, so that they cannot
+// alter the expression.
+func meilisearchFilter(filter search.Filter) ([]string, error) {
+	expressions := make([]string, len(filter))
+	for i, condition := range filter {
+		switch condition.Operator {
+		case search.FilterIn:
+			values := make([]string, len(condition.Values))
+			for j, value := range condition.Values {
+				values[j] = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+			}
+			expressions[i] = fmt.Sprintf("%s IN [%s]", condition.Attribute, strings.Join(values, ", "))
+		case search.FilterAtLeast, search.FilterAtMost:
+			if len(condition.Values) != 1 {
+				return nil, fmt.Errorf("expected a single value for '%s'", condition.Attribute)
+			}
+			if _, err := strconv.ParseFloat(condition.Values[0], 64); err != nil {
+				return nil, fmt.Errorf("expected a number for '%s'", condition.Attribute)
+			}
+			expressions[i] = fmt.Sprintf("%s %s %s", condition.Attribute, condition.Operator, condition.Values[0])
+		default:
+			return nil, fmt.Errorf("unsupported filter operator '%s'", condition.Operator)
+		}
+	}
+
+	return expressions, nil
+}
@@ -0,0 +1,197 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// poolHost is the host the Meilisearch clients send their requests to. The
+// host pool replaces it with the URL of one of its nodes.
+const poolHost = "http://meilisearch"
+
+// hostPool spreads the requests of the Meilisearch clients over several
+// nodes, which are expected to replicate the writes among each other.
+// Searches are sent round-robin to the healthy nodes. All other requests go
+// to the first healthy node, so that tasks are awaited on the node they
+// were enqueued on. Requests that could not reach a node are sent to the
+// next one, searches also if the connection failed midway.
+type hostPool struct {
+	hosts     []*url.URL
+	transport http.RoundTripper
+
+	mu      sync.Mutex
+	healthy []bool
+	next    int
+}
+
+func newHostPool(hosts []string) (*hostPool, error) {
+	pool := &hostPool{
+		transport: http.DefaultTransport,
+		healthy:   make([]bool, len(hosts)),
+	}
+	for i, host := range hosts {
+		parsed, err := url.Parse(strings.TrimSuffix(host, "/"))
+		if err != nil {
+			return nil, fmt.Errorf("meilisearch host %d: %w", i+1, err)
+		}
+		pool.hosts = append(pool.hosts, parsed)
+		pool.healthy[i] = true
+	}
+	if len(pool.hosts) == 0 {
+		return nil, errors.New("no meilisearch hosts")
+	}
+
+	return pool, nil
+}
+
+// httpClient returns the client to hand to the Meilisearch clients.
+func (p *hostPool) httpClient() *http.Client {
+	return &http.Client{Transport: p}
+}
+
+func (p *hostPool) RoundTrip(req *http.Request) (*http.Response, error) {
+	search := isSearchRequest(req)
+
+	var err error
+	for i, host := range p.candidates(search) {
+		attempt := req.Clone(req.Context())
+		if i > 0 && req.Body != nil {
+			if req.GetBody == nil {
+				break
+			}
+			if attempt.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+		attempt.URL.Scheme = p.hosts[host].Scheme
+		attempt.URL.Host = p.hosts[host].Host
+		attempt.URL.Path = p.hosts[host].Path + req.URL.Path
+		attempt.URL.RawPath = ""
+		attempt.Host = ""
+
+		var resp *http.Response
+		resp, err = p.transport.RoundTrip(attempt)
+		if err == nil {
+			p.mark(host, true)
+			return resp, nil
+		}
+		if req.Context().Err() != nil {
+			return nil, err
+		}
+
+		p.mark(host, false)
+		if !search && !isDialError(err) {
+			// the node may have received the request already
+			return nil, err
+		}
+	}
+
+	return nil, err
+}
+
+// candidates returns the nodes to try in order, the healthy ones first,
+// so that a request is still attempted if every node failed its last check.
+func (p *hostPool) candidates(search bool) []int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	start := 0
+	if search {
+		start = p.next
+		p.next = (p.next + 1) % len(p.hosts)
+	}
+
+	healthy := make([]int, 0, len(p.hosts))
+	unhealthy := []int{}
+	for i := range p.hosts {
+		host := i
+		if search {
+			host = (start + i) % len(p.hosts)
+		}
+		if p.healthy[host] {
+			healthy = append(healthy, host)
+		} else {
+			unhealthy = append(unhealthy, host)
+		}
+	}
+
+	return append(healthy, unhealthy...)
+}
+
+func (p *hostPool) mark(host int, healthy bool) {
+	p.mu.Lock()
+	changed := p.healthy[host] != healthy
+	p.healthy[host] = healthy
+	p.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	if healthy {
+		slog.Info("meilisearch host is available again", "host", p.hosts[host].Redacted())
+	} else {
+		slog.Warn("meilisearch host is unavailable", "host", p.hosts[host].Redacted())
+	}
+}
+
+// check checks the health of every node in the interval until the context
+// is cancelled.
+func (p *hostPool) check(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for host := range p.hosts {
+			p.mark(host, p.checkHealth(ctx, host, interval))
+		}
+	}
+}
+
+func (p *hostPool) checkHealth(ctx context.Context, host int, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.hosts[host].String()+"/health", nil)
+	if err != nil {
+		return false
+	}
+	resp, err := p.transport.RoundTrip(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode == http.StatusOK
+}
+
+// isSearchRequest tells searches, which any node can answer, from the other
+// requests.
+func isSearchRequest(req *http.Request) bool {
+	path := req.URL.Path
+	if path == "/multi-search" {
+		return true
+	}
+
+	return strings.HasPrefix(path, "/indexes/") &&
+		(strings.HasSuffix(path, "/search") || strings.HasSuffix(path, "/facet-search"))
+}
+
+// isDialError reports whether the request failed before it reached the node.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
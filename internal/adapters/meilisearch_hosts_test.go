@@ -0,0 +1,196 @@
+package adapters
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"mini-search-platform/config"
+	"mini-search-platform/internal/models"
+	"mini-search-platform/internal/search"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/meilisearch/meilisearch-go"
+)
+
+const searchKeyUID = "6062abda-a5aa-4414-ac91-ecd7944c0f8d"
+
+// fakeMeilisearch answers searches, document additions, task lookups and
+// key lookups like a Meilisearch node and records the requests it received.
+type fakeMeilisearch struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+func newFakeMeilisearch(t *testing.T) *fakeMeilisearch {
+	fake := &fakeMeilisearch{}
+	fake.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fake.mu.Lock()
+		fake.requests = append(fake.requests, r)
+		fake.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/search"):
+			w.Write([]byte(`{"hits": [], "offset": 0, "limit": 20, "estimatedTotalHits": 0, "query": "denim"}`))
+		case strings.HasSuffix(r.URL.Path, "/documents"):
+			w.WriteHeader(202)
+			w.Write([]byte(`{"taskUid": 1, "status": "enqueued", "type": "documentAdditionOrUpdate"}`))
+		case r.URL.Path == "/tasks/1":
+			w.Write([]byte(`{"uid": 1, "status": "succeeded"}`))
+		case r.URL.Path == "/keys/search-key":
+			w.Write([]byte(`{"uid": "` + searchKeyUID + `", "key": "search-key"}`))
+		default:
+			w.WriteHeader(404)
+			w.Write([]byte(`{"message": "not found", "code": "api_key_not_found", "type": "invalid_request"}`))
+		}
+	}))
+	t.Cleanup(fake.Close)
+
+	return fake
+}
+
+// paths returns the paths of the requests received, searches as "search".
+func (f *fakeMeilisearch) paths() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	paths := []string{}
+	for _, r := range f.requests {
+		if strings.HasSuffix(r.URL.Path, "/search") {
+			paths = append(paths, "search")
+		} else {
+			paths = append(paths, r.URL.Path)
+		}
+	}
+
+	return paths
+}
+
+func newTestMeilisearchEngine(t *testing.T, cfg config.MeilisearchConfig) search.SearchEngine {
+	t.Helper()
+
+	// passive failover only, the tests do not wait for health checks
+	cfg.HealthCheckInterval = config.Duration(time.Hour)
+	cfg.TaskTimeout = config.Duration(5 * time.Second)
+	engine, err := Init(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return engine.ForTenant(7)
+}
+
+func TestMeilisearchEngine_SpreadsSearchesAndSendsWritesToFirstHost(t *testing.T) {
+	first, second := newFakeMeilisearch(t), newFakeMeilisearch(t)
+	engine := newTestMeilisearchEngine(t, config.MeilisearchConfig{Hosts: []string{first.URL, second.URL}})
+
+	for i := 0; i < 4; i++ {
+		if _, err := engine.Search("denim", search.SearchOptions{Limit: 20}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := engine.IndexArticles([]*models.Article{{ID: 1, Title: "Denim"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if paths := strings.Join(first.paths(), " "); paths != "search search /indexes/tenant_7_articles/documents /tasks/1" {
+		t.Errorf("unexpected requests to the first host: %s", paths)
+	}
+	if paths := strings.Join(second.paths(), " "); paths != "search search" {
+		t.Errorf("unexpected requests to the second host: %s", paths)
+	}
+}
+
+func TestMeilisearchEngine_FailsOverToHealthyHost(t *testing.T) {
+	down, up := newFakeMeilisearch(t), newFakeMeilisearch(t)
+	down.Close()
+	engine := newTestMeilisearchEngine(t, config.MeilisearchConfig{Hosts: []string{down.URL, up.URL}})
+
+	for i := 0; i < 2; i++ {
+		if _, err := engine.Search("denim", search.SearchOptions{Limit: 20}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := engine.IndexArticles([]*models.Article{{ID: 1, Title: "Denim"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if paths := strings.Join(up.paths(), " "); paths != "search search /indexes/tenant_7_articles/documents /tasks/1" {
+		t.Errorf("expected every request to reach the healthy host, got: %s", paths)
+	}
+}
+
+func TestMeilisearchEngine_SearchesWithTenantToken(t *testing.T) {
+	node := newFakeMeilisearch(t)
+	engine := newTestMeilisearchEngine(t, config.MeilisearchConfig{
+		Hosts:     []string{node.URL},
+		MasterKey: "master-key",
+		SearchKey: "search-key",
+	})
+
+	for i := 0; i < 2; i++ {
+		if _, err := engine.Search("denim", search.SearchOptions{Limit: 20}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the uid of the search key is looked up once
+	if paths := strings.Join(node.paths(), " "); paths != "/keys/search-key search search" {
+		t.Fatalf("unexpected requests: %s", paths)
+	}
+	if auth := node.requests[0].Header.Get("Authorization"); auth != "Bearer master-key" {
+		t.Errorf("expected the key lookup to use the master key, got '%s'", auth)
+	}
+
+	token := strings.TrimPrefix(node.requests[1].Header.Get("Authorization"), "Bearer ")
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("expected a tenant token, got '%s'", token)
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var claims struct {
+		APIKeyUID   string                 `json:"apiKeyUid"`
+		SearchRules map[string]interface{} `json:"searchRules"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Fatal(err)
+	}
+	if claims.APIKeyUID != searchKeyUID {
+		t.Errorf("expected the token to name the search key, got '%s'", claims.APIKeyUID)
+	}
+	if _, ok := claims.SearchRules["tenant_7_*"]; !ok || len(claims.SearchRules) != 1 {
+		t.Errorf("expected the token to only grant the indexes of tenant 7, got %v", claims.SearchRules)
+	}
+}
+
+func TestMeilisearchEngine_RedactsKeysFromErrors(t *testing.T) {
+	node := newFakeMeilisearch(t)
+	engine := newTestMeilisearchEngine(t, config.MeilisearchConfig{
+		Hosts:     []string{node.URL},
+		MasterKey: "master-key",
+		SearchKey: "unknown-search-key",
+	})
+
+	_, err := engine.Search("denim", search.SearchOptions{Limit: 20})
+	if err == nil {
+		t.Fatal("expected the lookup of an unknown search key to fail")
+	}
+	if strings.Contains(err.Error(), "unknown-search-key") {
+		t.Errorf("error leaks the search key: %v", err)
+	}
+
+	var meilisearchErr *meilisearch.Error
+	if !errors.As(err, &meilisearchErr) || meilisearchErr.MeilisearchApiError.Code != "api_key_not_found" {
+		t.Errorf("expected the Meilisearch error to be kept, got %v", err)
+	}
+}
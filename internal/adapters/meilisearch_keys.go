@@ -0,0 +1,127 @@
+package adapters
+
+import (
+	"fmt"
+	"mini-search-platform/internal/search"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/meilisearch/meilisearch-go"
+)
+
+// Tenant tokens are valid for tenantTokenTTL and renewed once less than
+// tenantTokenRenewal of it is left.
+const (
+	tenantTokenTTL     = time.Hour
+	tenantTokenRenewal = 5 * time.Minute
+)
+
+const redacted = "[REDACTED]"
+
+// meilisearchKeys holds the keys the engines authenticate with. Indexes
+// and documents are managed with the master key. If a search key is set,
+// searches are sent with a tenant token signed by it, which only grants
+// access to the indexes of the searching tenant; otherwise they are sent
+// with the master key as well.
+type meilisearchKeys struct {
+	master    meilisearch.ServiceManager
+	newClient func(apiKey string) meilisearch.ServiceManager
+	searchKey string
+	// secrets are removed from the messages of errors, see redact
+	secrets []string
+
+	mu           sync.Mutex
+	searchKeyUID string
+	tenants      map[int]*tenantClient
+}
+
+type tenantClient struct {
+	client    meilisearch.ServiceManager
+	expiresAt time.Time
+}
+
+func newMeilisearchKeys(master meilisearch.ServiceManager, newClient func(string) meilisearch.ServiceManager, masterKey, searchKey string) *meilisearchKeys {
+	keys := &meilisearchKeys{
+		master:    master,
+		newClient: newClient,
+		searchKey: searchKey,
+		tenants:   map[int]*tenantClient{},
+	}
+	for _, key := range []string{masterKey, searchKey} {
+		if key != "" {
+			keys.secrets = append(keys.secrets, key)
+		}
+	}
+
+	return keys
+}
+
+// searchClient returns the client searching with the tenant token of the
+// tenant. The uid of the search key, which the token has to name, is looked
+// up with the master key once.
+func (k *meilisearchKeys) searchClient(tenantID int) (meilisearch.ServiceManager, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if tenant, ok := k.tenants[tenantID]; ok && time.Until(tenant.expiresAt) > tenantTokenRenewal {
+		return tenant.client, nil
+	}
+
+	if k.searchKeyUID == "" {
+		key, err := k.master.GetKey(k.searchKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up the search key: %w", err)
+		}
+		k.searchKeyUID = key.UID
+	}
+
+	expiresAt := time.Now().Add(tenantTokenTTL)
+	token, err := k.master.GenerateTenantToken(k.searchKeyUID, map[string]interface{}{
+		search.IndexName(tenantID, "*"): map[string]interface{}{},
+	}, &meilisearch.TenantTokenOptions{
+		APIKey:    k.searchKey,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tenant := &tenantClient{client: k.newClient(token), expiresAt: expiresAt}
+	k.tenants[tenantID] = tenant
+
+	return tenant.client, nil
+}
+
+// redact replaces the keys in the message of the error, so that they
+// cannot leak through logs, outbox entries or responses. Meilisearch
+// errors mention the path of the request, which contains the search key
+// when it is looked up.
+func (k *meilisearchKeys) redact(err *error) {
+	if *err == nil {
+		return
+	}
+
+	message := (*err).Error()
+	for _, secret := range k.secrets {
+		message = strings.ReplaceAll(message, secret, redacted)
+	}
+	if message != (*err).Error() {
+		*err = &redactedError{message: message, err: *err}
+	}
+}
+
+// redactedError keeps the original error for errors.As, e.g. to tell
+// Meilisearch error codes apart; its message must not be used.
+type redactedError struct {
+	message string
+	err     error
+}
+
+func (e *redactedError) Error() string {
+	return e.message
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
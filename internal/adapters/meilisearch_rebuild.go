@@ -0,0 +1,183 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"mini-search-platform/internal/search"
+	"sync"
+	"time"
+
+	"github.com/meilisearch/meilisearch-go"
+)
+
+const (
+	stagingSuffix    = "_rebuild"
+	taskPollInterval = 100 * time.Millisecond
+)
+
+// rebuilds tracks the staging indexes of running rebuilds together with the
+// last task written to each of them. It is shared by all engines derived
+// from the same client, so that regular writes reach the staging index too
+// and nothing is lost when it is swapped in.
+type rebuilds struct {
+	mu    sync.Mutex
+	tasks map[string]int64
+}
+
+func (r *rebuilds) start(staging string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tasks[staging] = -1
+}
+
+func (r *rebuilds) finish(staging string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.tasks, staging)
+}
+
+// targets returns the index itself plus its staging index while a rebuild
+// of it is running.
+func (r *rebuilds) targets(uid string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.tasks[uid+stagingSuffix]; ok {
+		return []string{uid, uid + stagingSuffix}
+	}
+	return []string{uid}
+}
+
+func (r *rebuilds) track(uid string, task *meilisearch.TaskInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.tasks[uid]; ok && task.TaskUID > r.tasks[uid] {
+		r.tasks[uid] = task.TaskUID
+	}
+}
+
+func (r *rebuilds) lastTask(staging string) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.tasks[staging]
+}
+
+// write runs the operation on the index, and on its staging index while it
+// is rebuilt, and waits for its tasks. A task that fails or does not finish
+// within the task timeout fails the write, so that the outbox retries it.
+func (e *MeilisearchEngine) write(uid string, op func(meilisearch.IndexManager) (*meilisearch.TaskInfo, error)) (err error) {
+	defer e.keys.redact(&err)
+	defer markUnavailable(&err)
+
+	for _, target := range e.rebuilds.targets(uid) {
+		task, err := op(e.Client.Index(target))
+		if err != nil {
+			return err
+		}
+		if task == nil {
+			continue
+		}
+		e.rebuilds.track(target, task)
+
+		ctx, cancel := context.WithTimeout(context.Background(), e.taskTimeout)
+		err = e.waitForTask(ctx, task.TaskUID)
+		cancel()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *MeilisearchEngine) uid(index string) (string, error) {
+	switch index {
+	case search.ARTICLES_INDEX_NAME:
+		return e.articlesUID, nil
+	case search.PRODUCTS_INDEX_NAME:
+		return e.productsUID, nil
+	}
+
+	return "", fmt.Errorf("unknown index '%s'", index)
+}
+
+func (e *MeilisearchEngine) BeginRebuild(index string) (_ search.SearchEngine, err error) {
+	defer e.keys.redact(&err)
+
+	live, err := e.uid(index)
+	if err != nil {
+		return nil, err
+	}
+	staging := live + stagingSuffix
+
+	// leftovers of an interrupted rebuild are dropped first
+	if task, err := e.Client.DeleteIndex(staging); err == nil {
+		if _, err := e.Client.WaitForTask(task.TaskUID, taskPollInterval); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := e.ensureIndex(staging, e.settings(index)); err != nil {
+		return nil, err
+	}
+
+	e.rebuilds.start(staging)
+
+	engine := newMeilisearchEngine(e.Client, e.tenantID, e.catalogue, e.rebuilds, e.keys, e.taskTimeout)
+	if index == search.ARTICLES_INDEX_NAME {
+		engine.articlesUID = staging
+		engine.Index = e.Client.Index(staging)
+	} else {
+		engine.productsUID = staging
+		engine.Products = e.Client.Index(staging)
+	}
+
+	return engine, nil
+}
+
+func (e *MeilisearchEngine) CommitRebuild(index string) (err error) {
+	defer e.keys.redact(&err)
+
+	live, err := e.uid(index)
+	if err != nil {
+		return err
+	}
+	staging := live + stagingSuffix
+
+	if last := e.rebuilds.lastTask(staging); last >= 0 {
+		if err := e.waitForTask(context.Background(), last); err != nil {
+			return err
+		}
+	}
+
+	// a lost live index is recreated so that there is something to swap with
+	if err := e.ensureIndex(live, e.settings(index)); err != nil {
+		return err
+	}
+
+	task, err := e.Client.SwapIndexes([]*meilisearch.SwapIndexesParams{{Indexes: []string{live, staging}}})
+	if err != nil {
+		return err
+	}
+	if err := e.waitForTask(context.Background(), task.TaskUID); err != nil {
+		return err
+	}
+
+	// the staging index now holds the previous documents
+	e.rebuilds.finish(staging)
+	_, err = e.Client.DeleteIndex(staging)
+	return err
+}
+
+func (e *MeilisearchEngine) AbortRebuild(index string) (err error) {
+	defer e.keys.redact(&err)
+
+	live, err := e.uid(index)
+	if err != nil {
+		return err
+	}
+	staging := live + stagingSuffix
+
+	e.rebuilds.finish(staging)
+	_, err = e.Client.DeleteIndex(staging)
+	return err
+}
@@ -0,0 +1,242 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mini-search-platform/internal/search"
+	"slices"
+	"time"
+
+	"github.com/meilisearch/meilisearch-go"
+)
+
+// DefaultTaskTimeout bounds the wait for the tasks writing documents,
+// creating indexes and changing their settings.
+const DefaultTaskTimeout = 30 * time.Second
+
+// defaultRankingRules are the ranking rules of an index without any set.
+var defaultRankingRules = []string{"words", "typo", "proximity", "attribute", "sort", "exactness"}
+
+// settingsUpdate enqueues the task of a settings change.
+type settingsUpdate func(ctx context.Context) (*meilisearch.TaskInfo, error)
+
+// ensureIndex creates the index if it is missing and applies the attributes
+// of the settings that differ from the ones of the index, waiting for the
+// tasks to finish. Stop words are only applied to a new index, since the
+// tenant's search settings extend them, see updateSearchSettings.
+func (e *MeilisearchEngine) ensureIndex(uid string, settings search.IndexSettings) error {
+	ctx, cancel := context.WithTimeout(context.Background(), e.taskTimeout)
+	defer cancel()
+
+	created := false
+	_, err := e.Client.GetIndexWithContext(ctx, uid)
+	if hasErrorCode(err, "index_not_found") {
+		task, err := e.Client.CreateIndexWithContext(ctx, &meilisearch.IndexConfig{
+			Uid:        uid,
+			PrimaryKey: settings.PrimaryKey,
+		})
+		if err != nil {
+			return err
+		}
+		// another instance may have created it in the meantime
+		if err := e.waitForTask(ctx, task.TaskUID); err != nil && !hasErrorCode(err, "index_already_exists") {
+			return err
+		}
+		created = true
+	} else if err != nil {
+		return err
+	}
+
+	index := e.Client.Index(uid)
+	actual, err := index.GetSettingsWithContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	updates := []settingsUpdate{}
+	searchable := settings.Searchable
+	if len(searchable) == 0 {
+		searchable = []string{"*"}
+	}
+	if !slices.Equal(actual.SearchableAttributes, searchable) {
+		updates = append(updates, func(ctx context.Context) (*meilisearch.TaskInfo, error) {
+			return index.UpdateSearchableAttributesWithContext(ctx, &searchable)
+		})
+	}
+	if !sameSet(actual.FilterableAttributes, settings.Filterable) {
+		updates = append(updates, func(ctx context.Context) (*meilisearch.TaskInfo, error) {
+			return index.UpdateFilterableAttributesWithContext(ctx, &settings.Filterable)
+		})
+	}
+	if !sameSet(actual.SortableAttributes, settings.Sortable) {
+		updates = append(updates, func(ctx context.Context) (*meilisearch.TaskInfo, error) {
+			return index.UpdateSortableAttributesWithContext(ctx, &settings.Sortable)
+		})
+	}
+	if !sameLocales(actual.LocalizedAttributes, settings.Locales) {
+		updates = append(updates, func(ctx context.Context) (*meilisearch.TaskInfo, error) {
+			if len(settings.Locales) == 0 {
+				return index.ResetLocalizedAttributesWithContext(ctx)
+			}
+			return index.UpdateLocalizedAttributesWithContext(ctx, []*meilisearch.LocalizedAttributes{{
+				Locales:           settings.Locales,
+				AttributePatterns: []string{"*"},
+			}})
+		})
+	}
+	if created && !sameSet(actual.StopWords, settings.StopWords) {
+		updates = append(updates, func(ctx context.Context) (*meilisearch.TaskInfo, error) {
+			return index.UpdateStopWordsWithContext(ctx, &settings.StopWords)
+		})
+	}
+
+	_, err = e.applyUpdates(ctx, updates)
+	return err
+}
+
+// updateSearchSettings applies the synonyms, stop words and ranking rules of
+// the settings that differ from the ones of the index, waiting for the tasks
+// to finish. Empty ones reset the index to its defaults. It returns the last
+// task, nil if nothing changed.
+func (e *MeilisearchEngine) updateSearchSettings(index meilisearch.IndexManager, settings search.IndexSettings) (*meilisearch.TaskInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), e.taskTimeout)
+	defer cancel()
+
+	actual, err := index.GetSettingsWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := []settingsUpdate{}
+	if !sameSynonyms(actual.Synonyms, settings.Synonyms) {
+		updates = append(updates, func(ctx context.Context) (*meilisearch.TaskInfo, error) {
+			if len(settings.Synonyms) == 0 {
+				return index.ResetSynonymsWithContext(ctx)
+			}
+			return index.UpdateSynonymsWithContext(ctx, &settings.Synonyms)
+		})
+	}
+	if !sameSet(actual.StopWords, settings.StopWords) {
+		updates = append(updates, func(ctx context.Context) (*meilisearch.TaskInfo, error) {
+			if len(settings.StopWords) == 0 {
+				return index.ResetStopWordsWithContext(ctx)
+			}
+			return index.UpdateStopWordsWithContext(ctx, &settings.StopWords)
+		})
+	}
+	rankingRules := settings.RankingRules
+	if len(rankingRules) == 0 {
+		rankingRules = defaultRankingRules
+	}
+	if !slices.Equal(actual.RankingRules, rankingRules) {
+		updates = append(updates, func(ctx context.Context) (*meilisearch.TaskInfo, error) {
+			if len(settings.RankingRules) == 0 {
+				return index.ResetRankingRulesWithContext(ctx)
+			}
+			return index.UpdateRankingRulesWithContext(ctx, &settings.RankingRules)
+		})
+	}
+
+	return e.applyUpdates(ctx, updates)
+}
+
+// applyUpdates enqueues the updates and waits for the last of their tasks,
+// which Meilisearch processes in order. It returns nil if there are none.
+func (e *MeilisearchEngine) applyUpdates(ctx context.Context, updates []settingsUpdate) (*meilisearch.TaskInfo, error) {
+	var task *meilisearch.TaskInfo
+	for _, update := range updates {
+		var err error
+		if task, err = update(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if task == nil {
+		return nil, nil
+	}
+
+	return task, e.waitForTask(ctx, task.TaskUID)
+}
+
+func (e *MeilisearchEngine) waitForTask(ctx context.Context, taskUID int64) error {
+	task, err := e.Client.WaitForTaskWithContext(ctx, taskUID, taskPollInterval)
+	if err != nil {
+		return err
+	}
+	if task.Status == meilisearch.TaskStatusFailed {
+		return &taskError{taskUID: taskUID, code: task.Error.Code, message: task.Error.Message}
+	}
+
+	return nil
+}
+
+// taskError is a task Meilisearch failed to process.
+type taskError struct {
+	taskUID int64
+	code    string
+	message string
+}
+
+func (e *taskError) Error() string {
+	return fmt.Sprintf("meilisearch task %d failed: %s", e.taskUID, e.message)
+}
+
+// hasErrorCode reports whether a request or a task failed with the
+// Meilisearch error code.
+func hasErrorCode(err error, code string) bool {
+	var requestErr *meilisearch.Error
+	if errors.As(err, &requestErr) {
+		return requestErr.MeilisearchApiError.Code == code
+	}
+
+	var taskErr *taskError
+	return errors.As(err, &taskErr) && taskErr.code == code
+}
+
+// sameSet compares the values regardless of their order and duplicates,
+// since Meilisearch keeps attributes and stop words as sets.
+func sameSet(a, b []string) bool {
+	set := func(values []string) map[string]bool {
+		m := make(map[string]bool, len(values))
+		for _, value := range values {
+			m[value] = true
+		}
+		return m
+	}
+	setA, setB := set(a), set(b)
+	if len(setA) != len(setB) {
+		return false
+	}
+	for value := range setA {
+		if !setB[value] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sameSynonyms(a, b map[string][]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for word, synonyms := range a {
+		if other, ok := b[word]; !ok || !sameSet(synonyms, other) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sameLocales compares the localized attributes of an index with the ones
+// applied for the locales, all attributes in these locales.
+func sameLocales(actual []*meilisearch.LocalizedAttributes, locales []string) bool {
+	if len(locales) == 0 {
+		return len(actual) == 0
+	}
+
+	return len(actual) == 1 &&
+		sameSet(actual[0].Locales, locales) &&
+		sameSet(actual[0].AttributePatterns, []string{"*"})
+}
@@ -0,0 +1,144 @@
+package adapters
+
+import (
+	"encoding/json"
+	"mini-search-platform/config"
+	"mini-search-platform/internal/search"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/meilisearch/meilisearch-go"
+)
+
+// fakeIndexes serves existing indexes and their settings like a Meilisearch
+// node and records the requests changing them.
+type fakeIndexes struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	settings map[string]*meilisearch.Settings
+	writes   []string
+	// failTasks makes every task fail
+	failTasks bool
+}
+
+func newFakeIndexes(t *testing.T, settings map[string]*meilisearch.Settings) *fakeIndexes {
+	fake := &fakeIndexes{settings: settings}
+	fake.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		switch {
+		case parts[0] == "tasks" && fake.failTasks:
+			w.Write([]byte(`{"uid": ` + parts[1] + `, "status": "failed", "error": {"message": "invalid document", "code": "invalid_document_fields"}}`))
+		case parts[0] == "tasks":
+			w.Write([]byte(`{"uid": ` + parts[1] + `, "status": "succeeded"}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/indexes":
+			var index struct {
+				UID string `json:"uid"`
+			}
+			json.NewDecoder(r.Body).Decode(&index)
+			fake.settings[index.UID] = &meilisearch.Settings{
+				SearchableAttributes: []string{"*"},
+				RankingRules:         defaultRankingRules,
+			}
+			fake.writes = append(fake.writes, r.Method+" "+r.URL.Path)
+			w.WriteHeader(202)
+			w.Write([]byte(`{"taskUid": 1, "status": "enqueued"}`))
+		case r.Method != http.MethodGet:
+			fake.writes = append(fake.writes, r.Method+" "+r.URL.Path)
+			w.WriteHeader(202)
+			w.Write([]byte(`{"taskUid": 1, "status": "enqueued"}`))
+		case fake.settings[parts[1]] == nil:
+			w.WriteHeader(404)
+			w.Write([]byte(`{"message": "index not found", "code": "index_not_found", "type": "invalid_request"}`))
+		case len(parts) == 2:
+			w.Write([]byte(`{"uid": "` + parts[1] + `", "primaryKey": "id"}`))
+		default:
+			json.NewEncoder(w).Encode(fake.settings[parts[1]])
+		}
+	}))
+	t.Cleanup(fake.Close)
+
+	return fake
+}
+
+// provisioned returns the settings of an index provisioned with the default
+// settings of the base index.
+func provisioned(base string) *meilisearch.Settings {
+	settings := search.IndexSettingsFor(base, nil)
+	return &meilisearch.Settings{
+		SearchableAttributes: settings.Searchable,
+		FilterableAttributes: settings.Filterable,
+		SortableAttributes:   settings.Sortable,
+		StopWords:            settings.StopWords,
+		RankingRules:         defaultRankingRules,
+	}
+}
+
+func TestMeilisearchEngine_CreateIndexesOnlyAppliesChanges(t *testing.T) {
+	articles := provisioned(search.ARTICLES_INDEX_NAME)
+	products := provisioned(search.PRODUCTS_INDEX_NAME)
+	node := newFakeIndexes(t, map[string]*meilisearch.Settings{
+		"tenant_7_articles": articles,
+		"tenant_7_products": products,
+	})
+	engine := newTestMeilisearchEngine(t, config.MeilisearchConfig{Hosts: []string{node.URL}})
+
+	if err := engine.CreateIndexes(); err != nil {
+		t.Fatal(err)
+	}
+	if len(node.writes) != 0 {
+		t.Fatalf("expected up to date indexes to be left alone, got %v", node.writes)
+	}
+
+	// filterable attributes are a set, their order does not matter
+	filterable := append([]string{"legacy"}, products.FilterableAttributes...)
+	slices.Reverse(filterable)
+	products.FilterableAttributes = filterable
+	if err := engine.CreateIndexes(); err != nil {
+		t.Fatal(err)
+	}
+	if writes := strings.Join(node.writes, " "); writes != "PUT /indexes/tenant_7_products/settings/filterable-attributes" {
+		t.Errorf("expected only the filterable attributes of the products index to be updated, got %s", writes)
+	}
+}
+
+func TestMeilisearchEngine_CreateIndexesCreatesMissingIndex(t *testing.T) {
+	node := newFakeIndexes(t, map[string]*meilisearch.Settings{
+		"tenant_7_products": provisioned(search.PRODUCTS_INDEX_NAME),
+	})
+	engine := newTestMeilisearchEngine(t, config.MeilisearchConfig{Hosts: []string{node.URL}})
+
+	if err := engine.CreateIndexes(); err != nil {
+		t.Fatal(err)
+	}
+	if len(node.writes) == 0 || node.writes[0] != "POST /indexes" {
+		t.Fatalf("expected the missing articles index to be created, got %v", node.writes)
+	}
+	for _, write := range node.writes {
+		if strings.Contains(write, "tenant_7_products") {
+			t.Errorf("expected the existing products index to be left alone, got %s", write)
+		}
+	}
+}
+
+func TestMeilisearchEngine_WritesFailWithTheirTasks(t *testing.T) {
+	node := newFakeIndexes(t, map[string]*meilisearch.Settings{})
+	engine := newTestMeilisearchEngine(t, config.MeilisearchConfig{Hosts: []string{node.URL}})
+
+	if err := engine.DeleteProducts([]string{"sku-1"}); err != nil {
+		t.Fatalf("expected the write to succeed with its task, got %v", err)
+	}
+
+	node.failTasks = true
+	if err := engine.DeleteProducts([]string{"sku-1"}); !hasErrorCode(err, "invalid_document_fields") {
+		t.Errorf("expected the failed task to fail the write, got %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package adapters
+
+import (
+	"mini-search-platform/internal/search"
+	"reflect"
+	"testing"
+)
+
+func TestMeilisearchFilter_EscapesValues(t *testing.T) {
+	filter, err := meilisearchFilter(search.Filter{
+		{Attribute: "author", Operator: search.FilterIn, Values: []string{`Jane" OR author EXISTS`, `back\slash`}},
+		{Attribute: "created_at_timestamp", Operator: search.FilterAtLeast, Values: []string{"1704067200"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		`author IN ["Jane\" OR author EXISTS", "back\\slash"]`,
+		`created_at_timestamp >= 1704067200`,
+	}
+	if !reflect.DeepEqual(filter, expected) {
+		t.Errorf("expected %q, got %q", expected, filter)
+	}
+
+	_, err = meilisearchFilter(search.Filter{
+		{Attribute: "created_at_timestamp", Operator: search.FilterAtMost, Values: []string{"0 OR author EXISTS"}},
+	})
+	if err == nil {
+		t.Error("expected a non-numeric comparison to be rejected")
+	}
+}
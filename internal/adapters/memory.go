@@ -0,0 +1,239 @@
+package adapters
+
+import (
+	"fmt"
+	"mini-search-platform/internal/models"
+	"mini-search-platform/internal/search"
+	"strconv"
+	"sync"
+)
+
+// MemoryEngine is a search engine keeping its indexes in process memory. It
+// needs no external service, which makes it a fit for local development and
+// CI. The indexes are lost on restart, so the server rebuilds them from the
+// relational store while it bootstraps, see search.Bootstrap.Rebuilds.
+type MemoryEngine struct {
+	store       *memoryStore
+	tenantID    int
+	catalogue   *models.Catalogue
+	articlesUID string
+	productsUID string
+}
+
+// memoryStore holds the indexes of all tenants. It is shared by all engines
+// derived from the same NewMemoryEngine call.
+type memoryStore struct {
+	mu      sync.RWMutex
+	indexes map[string]*memoryIndex
+}
+
+func NewMemoryEngine() *MemoryEngine {
+	return newMemoryEngine(&memoryStore{indexes: map[string]*memoryIndex{}}, 0, nil)
+}
+
+func newMemoryEngine(store *memoryStore, tenantID int, catalogue *models.Catalogue) *MemoryEngine {
+	return &MemoryEngine{
+		store:       store,
+		tenantID:    tenantID,
+		catalogue:   catalogue,
+		articlesUID: search.IndexNameFor(tenantID, search.ARTICLES_INDEX_NAME, catalogue),
+		productsUID: search.IndexNameFor(tenantID, search.PRODUCTS_INDEX_NAME, catalogue),
+	}
+}
+
+func (e *MemoryEngine) ForTenant(tenantID int) search.SearchEngine {
+	return newMemoryEngine(e.store, tenantID, nil)
+}
+
+func (e *MemoryEngine) ForCatalogue(catalogue *models.Catalogue) search.SearchEngine {
+	return newMemoryEngine(e.store, e.tenantID, catalogue)
+}
+
+// CreateIndexes creates the articles and products indexes of the tenant, or
+// of the catalogue. Existing indexes keep their documents.
+func (e *MemoryEngine) CreateIndexes() error {
+	e.store.mu.Lock()
+	defer e.store.mu.Unlock()
+
+	for base, uid := range map[string]string{
+		search.ARTICLES_INDEX_NAME: e.articlesUID,
+		search.PRODUCTS_INDEX_NAME: e.productsUID,
+	} {
+		if _, ok := e.store.indexes[uid]; !ok {
+			e.store.indexes[uid] = newMemoryIndex(search.IndexSettingsFor(base, e.catalogue))
+		}
+	}
+
+	return nil
+}
+
+func (e *MemoryEngine) IndexArticles(articles []*models.Article) error {
+	documents := []interface{}{}
+	for _, document := range search.NewArticleDocuments(articles) {
+		documents = append(documents, document)
+	}
+
+	return e.write(e.articlesUID, search.ARTICLES_INDEX_NAME, func(index *memoryIndex) error {
+		return index.add(documents)
+	})
+}
+
+func (e *MemoryEngine) DeleteArticles(ids []int) error {
+	identifiers := make([]string, len(ids))
+	for i, id := range ids {
+		identifiers[i] = strconv.Itoa(id)
+	}
+
+	return e.write(e.articlesUID, search.ARTICLES_INDEX_NAME, func(index *memoryIndex) error {
+		index.delete(identifiers)
+		return nil
+	})
+}
+
+func (e *MemoryEngine) IndexProducts(products []*search.ProductDocument) error {
+	documents := make([]interface{}, len(products))
+	for i, product := range products {
+		documents[i] = product
+	}
+
+	return e.write(e.productsUID, search.PRODUCTS_INDEX_NAME, func(index *memoryIndex) error {
+		return index.add(documents)
+	})
+}
+
+func (e *MemoryEngine) DeleteProducts(articleIDs []string) error {
+	return e.write(e.productsUID, search.PRODUCTS_INDEX_NAME, func(index *memoryIndex) error {
+		index.delete(articleIDs)
+		return nil
+	})
+}
+
+// UpdateSettings reindexes the documents of the index with the search
+// settings.
+func (e *MemoryEngine) UpdateSettings(index string, settings *models.SearchSettings) error {
+	uid, err := e.uid(index)
+	if err != nil {
+		return err
+	}
+	tuned := search.IndexSettingsFor(index, e.catalogue).WithSearchSettings(settings)
+
+	return e.write(uid, index, func(memory *memoryIndex) error {
+		return memory.configure(tuned)
+	})
+}
+
+func (e *MemoryEngine) Search(query string, options search.SearchOptions) (search.SearchResponse, error) {
+	page, err := e.search(e.articlesUID, query, options)
+	if err != nil {
+		return search.SearchResponse{Query: query}, err
+	}
+
+	return page.articles(query, options)
+}
+
+func (e *MemoryEngine) SearchProducts(query string, options search.SearchOptions) (search.ProductSearchResponse, error) {
+	page, err := e.search(e.productsUID, query, options)
+	if err != nil {
+		return search.ProductSearchResponse{Query: query}, err
+	}
+
+	return page.products(query, options)
+}
+
+func (e *MemoryEngine) search(uid, query string, options search.SearchOptions) (documentPage, error) {
+	e.store.mu.RLock()
+	defer e.store.mu.RUnlock()
+
+	index, ok := e.store.indexes[uid]
+	if !ok {
+		return documentPage{}, fmt.Errorf("index '%s' not found", uid)
+	}
+
+	return index.search(query, options)
+}
+
+// write applies the change to the index, creating it on first use as
+// Meilisearch does, and to its staging copy while a rebuild is running.
+func (e *MemoryEngine) write(uid, base string, op func(*memoryIndex) error) error {
+	e.store.mu.Lock()
+	defer e.store.mu.Unlock()
+
+	index, ok := e.store.indexes[uid]
+	if !ok {
+		index = newMemoryIndex(search.IndexSettingsFor(base, e.catalogue))
+		e.store.indexes[uid] = index
+	}
+	if err := op(index); err != nil {
+		return err
+	}
+
+	if staging, ok := e.store.indexes[uid+stagingSuffix]; ok {
+		return op(staging)
+	}
+
+	return nil
+}
+
+func (e *MemoryEngine) uid(index string) (string, error) {
+	switch index {
+	case search.ARTICLES_INDEX_NAME:
+		return e.articlesUID, nil
+	case search.PRODUCTS_INDEX_NAME:
+		return e.productsUID, nil
+	}
+
+	return "", fmt.Errorf("unknown index '%s'", index)
+}
+
+func (e *MemoryEngine) BeginRebuild(index string) (search.SearchEngine, error) {
+	live, err := e.uid(index)
+	if err != nil {
+		return nil, err
+	}
+
+	e.store.mu.Lock()
+	defer e.store.mu.Unlock()
+
+	e.store.indexes[live+stagingSuffix] = newMemoryIndex(search.IndexSettingsFor(index, e.catalogue))
+
+	staging := newMemoryEngine(e.store, e.tenantID, e.catalogue)
+	if index == search.ARTICLES_INDEX_NAME {
+		staging.articlesUID = live + stagingSuffix
+	} else {
+		staging.productsUID = live + stagingSuffix
+	}
+
+	return staging, nil
+}
+
+func (e *MemoryEngine) CommitRebuild(index string) error {
+	live, err := e.uid(index)
+	if err != nil {
+		return err
+	}
+
+	e.store.mu.Lock()
+	defer e.store.mu.Unlock()
+
+	staging, ok := e.store.indexes[live+stagingSuffix]
+	if !ok {
+		return fmt.Errorf("index '%s' is not being rebuilt", live)
+	}
+
+	e.store.indexes[live] = staging
+	delete(e.store.indexes, live+stagingSuffix)
+	return nil
+}
+
+func (e *MemoryEngine) AbortRebuild(index string) error {
+	live, err := e.uid(index)
+	if err != nil {
+		return err
+	}
+
+	e.store.mu.Lock()
+	defer e.store.mu.Unlock()
+
+	delete(e.store.indexes, live+stagingSuffix)
+	return nil
+}
@@ -0,0 +1,293 @@
+package adapters
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"mini-search-platform/internal/search"
+	"sort"
+	"strings"
+)
+
+// BM25 parameters, see https://en.wikipedia.org/wiki/Okapi_BM25
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// memoryIndex is an inverted index over the searchable attributes of its
+// documents. Terms found in earlier searchable attributes weigh more, so a
+// match in the title outranks a match in the body.
+type memoryIndex struct {
+	settings    search.IndexSettings
+	stopWords   map[string]bool
+	synonyms    map[string][][]string
+	documents   map[string]*memoryDocument
+	postings    map[string]map[string]float64
+	totalLength float64
+	sequence    int
+}
+
+type memoryDocument struct {
+	id       string
+	sequence int
+	source   []byte
+	// fields holds the values of every attribute by its dotted path, as
+	// used by filters and sorting.
+	fields map[string][]string
+	terms  map[string]float64
+	length float64
+}
+
+func newMemoryIndex(settings search.IndexSettings) *memoryIndex {
+	stopWords := stopWordSet(settings.StopWords)
+
+	return &memoryIndex{
+		settings:  settings,
+		stopWords: stopWords,
+		synonyms:  synonymSet(settings.Synonyms, stopWords),
+		documents: map[string]*memoryDocument{},
+		postings:  map[string]map[string]float64{},
+	}
+}
+
+// configure replaces the settings of the index and reindexes its documents
+// in their original order.
+func (i *memoryIndex) configure(settings search.IndexSettings) error {
+	documents := make([]*memoryDocument, 0, len(i.documents))
+	for _, doc := range i.documents {
+		documents = append(documents, doc)
+	}
+	sort.Slice(documents, func(a, b int) bool {
+		return documents[a].sequence < documents[b].sequence
+	})
+
+	sources := make([]interface{}, len(documents))
+	for n, doc := range documents {
+		sources[n] = json.RawMessage(doc.source)
+	}
+
+	configured := newMemoryIndex(settings)
+	if err := configured.add(sources); err != nil {
+		return err
+	}
+
+	*i = *configured
+	return nil
+}
+
+// add adds the documents, replacing the ones with the same primary key.
+func (i *memoryIndex) add(documents []interface{}) error {
+	for _, document := range documents {
+		source, fields, text, err := decodeDocument(document)
+		if err != nil {
+			return err
+		}
+
+		ids := fields[i.settings.PrimaryKey]
+		if len(ids) != 1 {
+			return fmt.Errorf("document has no primary key '%s'", i.settings.PrimaryKey)
+		}
+
+		doc := &memoryDocument{
+			id:       ids[0],
+			sequence: i.sequence,
+			source:   source,
+			fields:   fields,
+			terms:    map[string]float64{},
+		}
+		if previous, ok := i.documents[doc.id]; ok {
+			doc.sequence = previous.sequence
+			i.remove(previous)
+		} else {
+			i.sequence++
+		}
+
+		for rank, attribute := range i.settings.Searchable {
+			weight := float64(len(i.settings.Searchable) - rank)
+			for _, value := range attributeText(text, attribute) {
+				for _, term := range tokenize(value, i.stopWords) {
+					doc.terms[term] += weight
+					doc.length += weight
+				}
+			}
+		}
+
+		for term, frequency := range doc.terms {
+			if i.postings[term] == nil {
+				i.postings[term] = map[string]float64{}
+			}
+			i.postings[term][doc.id] = frequency
+		}
+		i.totalLength += doc.length
+		i.documents[doc.id] = doc
+	}
+
+	return nil
+}
+
+func (i *memoryIndex) delete(ids []string) {
+	for _, id := range ids {
+		if doc, ok := i.documents[id]; ok {
+			i.remove(doc)
+		}
+	}
+}
+
+func (i *memoryIndex) remove(doc *memoryDocument) {
+	for term := range doc.terms {
+		delete(i.postings[term], doc.id)
+		if len(i.postings[term]) == 0 {
+			delete(i.postings, term)
+		}
+	}
+	i.totalLength -= doc.length
+	delete(i.documents, doc.id)
+}
+
+type memoryMatch struct {
+	doc     *memoryDocument
+	matched int
+	score   float64
+}
+
+// search returns the requested page of matching documents. Like
+// Meilisearch, it ranks documents matching more query terms first, then
+// applies the sort and finally the BM25 score. The last query term also
+// matches as a prefix.
+func (i *memoryIndex) search(query string, options search.SearchOptions) (documentPage, error) {
+	keys, err := parseSortKeys(options.Sort, i.settings.Sortable)
+	if err != nil {
+		return documentPage{}, err
+	}
+
+	matches, err := compileDocumentFilter(options.Filter, i.settings.Filterable)
+	if err != nil {
+		return documentPage{}, err
+	}
+
+	facets, err := i.settings.FacetAttributes(options.Facets)
+	if err != nil {
+		return documentPage{}, err
+	}
+
+	terms := tokenize(query, i.stopWords)
+	prefix := len(terms) > 0 && !strings.HasSuffix(query, " ")
+	expanded := expandQuery(terms, i.synonyms)
+
+	results := map[string]*memoryMatch{}
+	if len(terms) == 0 {
+		for _, doc := range i.documents {
+			results[doc.id] = &memoryMatch{doc: doc}
+		}
+	}
+
+	averageLength := 0.0
+	if len(i.documents) > 0 {
+		averageLength = i.totalLength / float64(len(i.documents))
+	}
+
+	for n, term := range expanded {
+		// a query term scores with its best matching alternative
+		best := map[string]float64{}
+		for a, alternative := range term.alternatives {
+			// synonyms are not matched as a prefix
+			prefixed := prefix && n == len(expanded)-1 && a == 0
+			for id, score := range i.score(alternative, prefixed, averageLength) {
+				best[id] = math.Max(best[id], score)
+			}
+		}
+
+		for id, score := range best {
+			result, ok := results[id]
+			if !ok {
+				result = &memoryMatch{doc: i.documents[id]}
+				results[id] = result
+			}
+			result.matched++
+			result.score += score
+		}
+	}
+
+	hits := []*memoryMatch{}
+	for _, result := range results {
+		if matches(result.doc.fields) {
+			hits = append(hits, result)
+		}
+	}
+
+	sort.Slice(hits, func(a, b int) bool {
+		if hits[a].matched != hits[b].matched {
+			return hits[a].matched > hits[b].matched
+		}
+		for _, key := range keys {
+			if c := compareDocumentValues(hits[a].doc.fields[key.attribute], hits[b].doc.fields[key.attribute]); c != 0 {
+				return (c < 0) != key.descending
+			}
+		}
+		if hits[a].score != hits[b].score {
+			return hits[a].score > hits[b].score
+		}
+		return hits[a].doc.sequence < hits[b].doc.sequence
+	})
+
+	page := documentPage{total: len(hits), terms: highlightTerms(expanded)}
+	if prefix {
+		page.prefix = terms[len(terms)-1]
+	}
+	fields := make([]map[string][]string, len(hits))
+	for n, hit := range hits {
+		fields[n] = hit.doc.fields
+	}
+	page.facets = countFacets(fields, options.Facets, facets)
+
+	offset := min(max(options.Offset, 0), page.total)
+	end := min(offset+searchLimit(options.Limit), page.total)
+	for _, hit := range hits[offset:end] {
+		page.sources = append(page.sources, hit.doc.source)
+	}
+
+	return page, nil
+}
+
+// score returns the BM25 scores of the documents containing all terms. With
+// prefix set, the last term also matches as a prefix and scores with its
+// best matching expansion.
+func (i *memoryIndex) score(terms []string, prefix bool, averageLength float64) map[string]float64 {
+	var scores map[string]float64
+	for n, term := range terms {
+		expansions := []string{term}
+		if prefix && n == len(terms)-1 {
+			for candidate := range i.postings {
+				if candidate != term && strings.HasPrefix(candidate, term) {
+					expansions = append(expansions, candidate)
+				}
+			}
+		}
+
+		best := map[string]float64{}
+		for _, expansion := range expansions {
+			postings := i.postings[expansion]
+			idf := math.Log(1 + (float64(len(i.documents))-float64(len(postings))+0.5)/(float64(len(postings))+0.5))
+			for id, frequency := range postings {
+				doc := i.documents[id]
+				norm := frequency + bm25K1*(1-bm25B+bm25B*doc.length/averageLength)
+				best[id] = math.Max(best[id], idf*frequency*(bm25K1+1)/norm)
+			}
+		}
+
+		if scores == nil {
+			scores = best
+			continue
+		}
+		for id := range scores {
+			if score, ok := best[id]; ok {
+				scores[id] += score
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	return scores
+}
@@ -0,0 +1,233 @@
+package adapters
+
+import (
+	"mini-search-platform/internal/models"
+	"mini-search-platform/internal/search"
+	"testing"
+)
+
+func memoryArticle(id int, title, body, author string, labels ...string) *models.Article {
+	tags := []*models.Tag{}
+	for _, label := range labels {
+		tags = append(tags, models.NewTag(label))
+	}
+
+	article := models.NewArticle(title, body, models.NewAuthor(id, author), tags)
+	article.ID = id
+	return article
+}
+
+func hitIDs(response search.SearchResponse) []int {
+	ids := []int{}
+	for _, hit := range response.Hits {
+		ids = append(ids, hit.ID)
+	}
+	return ids
+}
+
+func TestMemoryEngine_RanksFiltersAndSorts(t *testing.T) {
+	engine := NewMemoryEngine().ForTenant(1)
+	if err := engine.CreateIndexes(); err != nil {
+		t.Fatal(err)
+	}
+
+	err := engine.IndexArticles([]*models.Article{
+		memoryArticle(1, "Summer outfits", "Light denim jackets for the summer", "Jane", "denim", "summer"),
+		memoryArticle(2, "Denim care", "How to wash denim", "John", "denim"),
+		memoryArticle(3, "Winter coats", "Warm coats and a denim scarf", "Jane", "winter"),
+		memoryArticle(4, "Garden party", "Nothing to wear", "Alex"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := engine.Search("denim", search.SearchOptions{Limit: 1, Facets: []string{"tags", "author"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the title match ranks first
+	if ids := hitIDs(response); len(ids) != 1 || ids[0] != 2 || response.Total != 3 {
+		t.Errorf("expected article 2 to rank first out of 3, got %v (total %d)", ids, response.Total)
+	}
+	// facets count all matches, not just the page
+	tags, authors := response.Facets["tags"], response.Facets["author"]
+	if tags["denim"] != 2 || tags["summer"] != 1 || tags["winter"] != 1 || authors["Jane"] != 2 || authors["John"] != 1 {
+		t.Errorf("unexpected facet counts %v", response.Facets)
+	}
+
+	response, err = engine.Search("den", search.SearchOptions{Limit: 10, Filter: search.Filter{
+		{Attribute: "author", Operator: search.FilterIn, Values: []string{"jane"}},
+		{Attribute: "tags.label", Operator: search.FilterIn, Values: []string{"summer", "autumn"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := hitIDs(response); len(ids) != 1 || ids[0] != 1 {
+		t.Errorf("expected the prefix query with filter to match article 1, got %v", ids)
+	}
+
+	response, err = engine.Search("", search.SearchOptions{Limit: 2, Offset: 1, Sort: []string{"title:desc"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := hitIDs(response); len(ids) != 2 || ids[0] != 1 || ids[1] != 4 || response.Total != 4 {
+		t.Errorf("expected the second page sorted by title to hold 1, 4, got %v (total %d)", ids, response.Total)
+	}
+
+	// a negative offset starts at the first hit
+	response, err = engine.Search("", search.SearchOptions{Limit: 2, Offset: -3, Sort: []string{"title:desc"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := hitIDs(response); len(ids) != 2 || ids[0] != 3 || ids[1] != 1 {
+		t.Errorf("expected a negative offset to return the first page 3, 1, got %v", ids)
+	}
+
+	if _, err := engine.Search("denim", search.SearchOptions{Filter: search.Filter{{Attribute: "body", Operator: search.FilterIn, Values: []string{"denim"}}}}); err == nil {
+		t.Error("expected filtering on a non-filterable attribute to fail")
+	}
+	if _, err := engine.Search("denim", search.SearchOptions{Sort: []string{"body:asc"}}); err == nil {
+		t.Error("expected sorting on a non-sortable attribute to fail")
+	}
+
+	if err := engine.DeleteArticles([]int{2}); err != nil {
+		t.Fatal(err)
+	}
+	response, err = engine.Search("wash", search.SearchOptions{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Total != 0 {
+		t.Errorf("expected the deleted article to be gone, got %v", hitIDs(response))
+	}
+}
+
+func TestMemoryEngine_RebuildSwapsStagingIndex(t *testing.T) {
+	engine := NewMemoryEngine().ForTenant(1)
+	if err := engine.IndexArticles([]*models.Article{memoryArticle(1, "Stale", "", "Jane")}); err != nil {
+		t.Fatal(err)
+	}
+
+	staging, err := engine.BeginRebuild(search.ARTICLES_INDEX_NAME)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := staging.IndexArticles([]*models.Article{memoryArticle(2, "Fresh", "", "Jane")}); err != nil {
+		t.Fatal(err)
+	}
+	// written while the rebuild runs, so it must survive the swap
+	if err := engine.IndexArticles([]*models.Article{memoryArticle(3, "Concurrent", "", "Jane")}); err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := engine.Search("", search.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Total != 2 {
+		t.Errorf("expected searches to hit the live index during the rebuild, got %v", hitIDs(response))
+	}
+
+	if err := engine.CommitRebuild(search.ARTICLES_INDEX_NAME); err != nil {
+		t.Fatal(err)
+	}
+	response, err = engine.Search("", search.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := hitIDs(response); len(ids) != 2 || ids[0] != 2 || ids[1] != 3 {
+		t.Errorf("expected the rebuilt index to hold 2 and 3, got %v", ids)
+	}
+}
+
+func TestMemoryEngine_FormatsHits(t *testing.T) {
+	engine := NewMemoryEngine().ForTenant(1)
+	if err := engine.CreateIndexes(); err != nil {
+		t.Fatal(err)
+	}
+
+	err := engine.IndexArticles([]*models.Article{
+		memoryArticle(1, "Denim jackets", "Back in stock. This summer, light Denim jackets go with everything, from shorts to dresses.", "Jane", "denim"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := engine.Search("denim jack", search.SearchOptions{
+		Highlight:            []string{"title"},
+		Crop:                 map[string]int{"body": 5},
+		HighlightPreTag:      "<em>",
+		HighlightPostTag:     "</em>",
+		AttributesToRetrieve: []string{"id", "title"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Hits) != 1 {
+		t.Fatalf("expected one hit, got %v", hitIDs(response))
+	}
+
+	hit := response.Hits[0]
+	if hit.Body != "" || hit.Author != "" || hit.Title != "Denim jackets" {
+		t.Errorf("expected only the title to be retrieved, got %+v", hit)
+	}
+	if title := hit.Formatted["title"]; title != "<em>Denim</em> <em>jack</em>ets" {
+		t.Errorf("unexpected highlighted title %q", title)
+	}
+	// the body is cropped around the first match but not highlighted
+	if body := hit.Formatted["body"]; body != "…summer, light Denim jackets go…" {
+		t.Errorf("unexpected cropped body %q", body)
+	}
+}
+
+func TestMemoryEngine_AppliesSearchSettings(t *testing.T) {
+	engine := NewMemoryEngine().ForTenant(1)
+	if err := engine.CreateIndexes(); err != nil {
+		t.Fatal(err)
+	}
+
+	err := engine.IndexArticles([]*models.Article{
+		memoryArticle(1, "Classic t-shirt", "A plain cotton shirt", "Jane"),
+		memoryArticle(2, "Denim care", "How to wash jeans", "John"),
+		memoryArticle(3, "Summer sale", "Everything must go", "Alex"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	settings := models.NewSearchSettings(search.ARTICLES_INDEX_NAME)
+	settings.Synonyms = map[string][]string{"tee": {"t-shirt"}, "jeans": {"denim"}}
+	settings.StopWords = []string{"sale"}
+	if err := engine.UpdateSettings(search.ARTICLES_INDEX_NAME, settings); err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := engine.Search("tee", search.SearchOptions{Highlight: []string{"title"}, HighlightPreTag: "<mark>", HighlightPostTag: "</mark>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := hitIDs(response); len(ids) != 1 || ids[0] != 1 {
+		t.Fatalf("expected the synonym to match article 1, got %v", ids)
+	}
+	if title := response.Hits[0].Formatted["title"]; title != "Classic <mark>t</mark>-<mark>shirt</mark>" {
+		t.Errorf("unexpected highlighted title %q", title)
+	}
+
+	// synonyms are one-way: denim does not expand to jeans
+	response, err = engine.Search("denim", search.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := hitIDs(response); len(ids) != 1 || ids[0] != 2 {
+		t.Errorf("expected only article 2 for denim, got %v", ids)
+	}
+
+	// the stop word is dropped from the query and the documents
+	response, err = engine.Search("summer sale", search.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := hitIDs(response); len(ids) != 1 || ids[0] != 3 {
+		t.Errorf("expected article 3 for summer sale, got %v", ids)
+	}
+}
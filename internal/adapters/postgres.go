@@ -0,0 +1,570 @@
+package adapters
+
+import (
+	"database/sql"
+	"fmt"
+	"mini-search-platform/internal/models"
+	"strconv"
+	"strings"
+)
+
+type PostgresAuthorsRepository struct {
+	db       *sql.DB
+	tenantID int
+}
+
+func NewPostgresAuthorsRepository(db *sql.DB) *PostgresAuthorsRepository {
+	return &PostgresAuthorsRepository{db: db}
+}
+
+func (r *PostgresAuthorsRepository) ForTenant(tenantID int) models.AuthorsRepository {
+	return &PostgresAuthorsRepository{db: r.db, tenantID: tenantID}
+}
+
+// Save keeps the id of the author if it is given, and generates the next
+// one of the tenant otherwise. Author ids are scoped to the tenant; a lock
+// per tenant keeps concurrent saves from generating the same id.
+func (r *PostgresAuthorsRepository) Save(author *models.Author) (int, error) {
+	query := `
+		INSERT INTO authors (id, tenant_id, name, created_at)
+		VALUES (
+			COALESCE($1, (SELECT COALESCE(MAX(id), 0) + 1 FROM authors WHERE tenant_id = $2)),
+			$2, $3, $4
+		)
+		RETURNING id
+	`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext('authors'), $1)`, r.tenantID); err != nil {
+		return 0, err
+	}
+
+	var id int
+	err = tx.QueryRow(query,
+		sql.NullInt64{Int64: int64(author.ID), Valid: author.ID != 0},
+		r.tenantID,
+		author.Name,
+		author.CreatedAt,
+	).Scan(&id)
+	if isUniqueViolation(err) {
+		return 0, fmt.Errorf("author %d '%s': %w", author.ID, author.Name, models.ErrAlreadyExists)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+func (r *PostgresAuthorsRepository) FindAuthorById(id int) (*models.Author, error) {
+	query := `
+		SELECT id, name, created_at
+		FROM authors
+		WHERE id = $1 AND tenant_id = $2
+	`
+	row := r.db.QueryRow(query, id, r.tenantID)
+
+	var author models.Author
+	err := row.Scan(&author.ID, &author.Name, &author.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &author, nil
+}
+
+func (r *PostgresAuthorsRepository) FindPopularByPrefix(prefix string, limit int) ([]*models.Suggestion, error) {
+	query := `
+		SELECT au.name, COUNT(ar.id) AS articles
+		FROM authors au
+		LEFT JOIN articles ar ON ar.author_id = au.id AND ar.tenant_id = au.tenant_id
+		WHERE au.tenant_id = $1 AND (LOWER(au.name) LIKE $2 ESCAPE '\' OR LOWER(au.name) LIKE $3 ESCAPE '\')
+		GROUP BY au.id
+		ORDER BY articles DESC, au.name
+		LIMIT $4
+	`
+	start, word := prefixPatterns(prefix)
+
+	return querySuggestions(r.db, query, r.tenantID, start, word, limit)
+}
+
+type PostgresArticleRepository struct {
+	db       *sql.DB
+	tenantID int
+}
+
+func NewPostgresArticleRepository(db *sql.DB) *PostgresArticleRepository {
+	return &PostgresArticleRepository{db: db}
+}
+
+func (r *PostgresArticleRepository) ForTenant(tenantID int) models.ArticleRepository {
+	return &PostgresArticleRepository{db: r.db, tenantID: tenantID}
+}
+
+func (r *PostgresArticleRepository) Save(article *models.Article) (int, error) {
+	query := `
+		INSERT INTO articles (
+			tenant_id,
+			title,
+			body,
+			author_id,
+			created_at
+		) VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(query,
+		r.tenantID,
+		article.Title,
+		article.Body,
+		article.AuthorID,
+		article.CreatedAt,
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := r.saveTags(tx, id, article.Tags); err != nil {
+		return 0, err
+	}
+
+	err = enqueuePostgres(tx, r.tenantID, models.NewOutboxEntry(models.OutboxArticlesChanged, strconv.Itoa(id)))
+	if err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+func (r *PostgresArticleRepository) FindByTag(tag *models.Tag) ([]*models.Article, error) {
+	query := `
+		SELECT
+			a.id,
+			a.title,
+			a.body,
+			a.author_id,
+			au.name,
+			a.created_at
+		FROM articles a
+		JOIN authors au ON au.tenant_id = a.tenant_id AND au.id = a.author_id
+		WHERE a.tenant_id = $1 AND a.id IN (
+			SELECT at.article_id
+			FROM article_tags at
+			WHERE at.tag_id = $2
+		)
+		ORDER BY a.id
+	`
+
+	return r.query(query, r.tenantID, tag.ID)
+}
+
+func (r *PostgresArticleRepository) Update(article *models.Article) error {
+	query := `
+		UPDATE articles
+		SET title = $1, body = $2, author_id = $3
+		WHERE id = $4 AND tenant_id = $5
+	`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query,
+		article.Title,
+		article.Body,
+		article.AuthorID,
+		article.ID,
+		r.tenantID,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	_, err = tx.Exec(`DELETE FROM article_tags WHERE article_id = $1`, article.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := r.saveTags(tx, article.ID, article.Tags); err != nil {
+		return err
+	}
+
+	err = enqueuePostgres(tx, r.tenantID, models.NewOutboxEntry(models.OutboxArticlesChanged, strconv.Itoa(article.ID)))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Delete leaves removing the tags of the article to the cascading foreign
+// key of article_tags.
+func (r *PostgresArticleRepository) Delete(id int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM articles WHERE id = $1 AND tenant_id = $2`, id, r.tenantID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	err = enqueuePostgres(tx, r.tenantID, models.NewOutboxEntry(models.OutboxArticlesDeleted, strconv.Itoa(id)))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *PostgresArticleRepository) FindById(id int) (*models.Article, error) {
+	query := `
+		SELECT
+			a.id,
+			a.title,
+			a.body,
+			a.author_id,
+			au.name,
+			a.created_at
+		FROM articles a
+		JOIN authors au ON au.tenant_id = a.tenant_id AND au.id = a.author_id
+		WHERE a.id = $1 AND a.tenant_id = $2
+	`
+
+	articles, err := r.query(query, id, r.tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if len(articles) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return articles[0], nil
+}
+
+func (r *PostgresArticleRepository) FindAll(limit, offset int) ([]*models.Article, error) {
+	query := `
+		SELECT
+			a.id,
+			a.title,
+			a.body,
+			a.author_id,
+			au.name,
+			a.created_at
+		FROM articles a
+		JOIN authors au ON au.tenant_id = a.tenant_id AND au.id = a.author_id
+		WHERE a.tenant_id = $1
+		ORDER BY a.id
+		LIMIT $2 OFFSET $3
+	`
+
+	return r.query(query, r.tenantID, limit, offset)
+}
+
+// FindAfter pages through the articles by id: it returns the first articles
+// with an id greater than afterID. Unlike an offset, the id skips no article
+// when earlier ones are deleted in the meantime.
+func (r *PostgresArticleRepository) FindAfter(afterID, limit int) ([]*models.Article, error) {
+	query := `
+		SELECT
+			a.id,
+			a.title,
+			a.body,
+			a.author_id,
+			au.name,
+			a.created_at
+		FROM articles a
+		JOIN authors au ON au.tenant_id = a.tenant_id AND au.id = a.author_id
+		WHERE a.tenant_id = $1 AND a.id > $2
+		ORDER BY a.id
+		LIMIT $3
+	`
+
+	return r.query(query, r.tenantID, afterID, limit)
+}
+
+func (r *PostgresArticleRepository) Count() (int, error) {
+	var total int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM articles WHERE tenant_id = $1`, r.tenantID).Scan(&total)
+	return total, err
+}
+
+func (r *PostgresArticleRepository) saveTags(tx *sql.Tx, articleID int, tags []*models.Tag) error {
+	query := `
+		INSERT INTO article_tags (article_id, tag_id)
+		VALUES ($1, $2)
+	`
+
+	for _, tag := range tags {
+		if _, err := tx.Exec(query, articleID, tag.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// query loads the articles of the rows and their tags.
+func (r *PostgresArticleRepository) query(query string, args ...interface{}) ([]*models.Article, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	articles := []*models.Article{}
+	for rows.Next() {
+		var article models.Article
+		err := rows.Scan(
+			&article.ID, &article.Title, &article.Body,
+			&article.AuthorID, &article.Author, &article.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		articles = append(articles, &article)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.attachTags(articles); err != nil {
+		return nil, err
+	}
+
+	return articles, nil
+}
+
+// attachTags loads the tags of all given articles with a single query.
+func (r *PostgresArticleRepository) attachTags(articles []*models.Article) error {
+	if len(articles) == 0 {
+		return nil
+	}
+
+	byID := make(map[int]*models.Article, len(articles))
+	args := make([]interface{}, len(articles))
+	for i, article := range articles {
+		article.Tags = []*models.Tag{}
+		byID[article.ID] = article
+		args[i] = article.ID
+	}
+
+	query := fmt.Sprintf(`
+		SELECT at.article_id, t.id, t.label, t.created_at, t.updated_at
+		FROM article_tags at
+		JOIN tags t ON at.tag_id = t.id
+		WHERE at.article_id IN (%s)
+		ORDER BY t.id
+	`, numberedPlaceholders(1, len(articles)))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			articleID int
+			tag       models.Tag
+		)
+		err := rows.Scan(&articleID, &tag.ID, &tag.Label, &tag.CreatedAt, &tag.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		byID[articleID].Tags = append(byID[articleID].Tags, &tag)
+	}
+
+	return rows.Err()
+}
+
+type PostgresTagsRepository struct {
+	db       *sql.DB
+	tenantID int
+}
+
+func NewPostgresTagsRepository(db *sql.DB) *PostgresTagsRepository {
+	return &PostgresTagsRepository{db: db}
+}
+
+func (r *PostgresTagsRepository) ForTenant(tenantID int) models.TagsRepository {
+	return &PostgresTagsRepository{db: r.db, tenantID: tenantID}
+}
+
+func (r *PostgresTagsRepository) Save(tag *models.Tag) (int, error) {
+	query := `
+		INSERT INTO tags (tenant_id, label, updated_at, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (tenant_id, label) DO UPDATE SET
+			label = excluded.label,
+			updated_at = excluded.updated_at
+		RETURNING id
+	`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(query,
+		r.tenantID,
+		tag.Label,
+		tag.UpdatedAt,
+		tag.CreatedAt,
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	err = enqueuePostgres(tx, r.tenantID, models.NewOutboxEntry(models.OutboxTagChanged, strconv.Itoa(id)))
+	if err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+func (r *PostgresTagsRepository) FindByLabel(label string) (*models.Tag, error) {
+	query := `
+		SELECT id, label, created_at, updated_at
+		FROM tags
+		WHERE label = $1 AND tenant_id = $2
+	`
+	row := r.db.QueryRow(query, label, r.tenantID)
+
+	var tag models.Tag
+	err := row.Scan(&tag.ID, &tag.Label, &tag.CreatedAt, &tag.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tag, nil
+}
+
+func (r *PostgresTagsRepository) FindByLabels(labels []string) ([]*models.Tag, error) {
+	if len(labels) == 0 {
+		return nil, nil
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, label, created_at, updated_at
+		FROM tags
+		WHERE tenant_id = $1 AND label IN (%s)
+	`, numberedPlaceholders(2, len(labels)))
+
+	args := make([]interface{}, 0, len(labels)+1)
+	args = append(args, r.tenantID)
+	for _, label := range labels {
+		args = append(args, label)
+	}
+
+	return r.query(query, args...)
+}
+
+func (r *PostgresTagsRepository) FindById(id int) (*models.Tag, error) {
+	query := `
+		SELECT id, label, created_at, updated_at
+		FROM tags
+		WHERE id = $1 AND tenant_id = $2
+	`
+	row := r.db.QueryRow(query, id, r.tenantID)
+
+	var tag models.Tag
+	err := row.Scan(&tag.ID, &tag.Label, &tag.CreatedAt, &tag.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tag, nil
+}
+
+func (r *PostgresTagsRepository) FindAll() ([]*models.Tag, error) {
+	query := `
+		SELECT id, label, created_at, updated_at
+		FROM tags
+		WHERE tenant_id = $1
+		ORDER BY id
+	`
+
+	return r.query(query, r.tenantID)
+}
+
+func (r *PostgresTagsRepository) FindPopularByPrefix(prefix string, limit int) ([]*models.Suggestion, error) {
+	query := `
+		SELECT t.label, COUNT(at.article_id) AS articles
+		FROM tags t
+		LEFT JOIN article_tags at ON at.tag_id = t.id
+		WHERE t.tenant_id = $1 AND (LOWER(t.label) LIKE $2 ESCAPE '\' OR LOWER(t.label) LIKE $3 ESCAPE '\')
+		GROUP BY t.id
+		ORDER BY articles DESC, t.label
+		LIMIT $4
+	`
+	start, word := prefixPatterns(prefix)
+
+	return querySuggestions(r.db, query, r.tenantID, start, word, limit)
+}
+
+func (r *PostgresTagsRepository) query(query string, args ...interface{}) ([]*models.Tag, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []*models.Tag
+	for rows.Next() {
+		var tag models.Tag
+		err := rows.Scan(&tag.ID, &tag.Label, &tag.CreatedAt, &tag.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, &tag)
+	}
+
+	return tags, rows.Err()
+}
+
+// numberedPlaceholders returns n Postgres placeholders starting at $from,
+// e.g. "$2,$3,$4" for IN lists.
+func numberedPlaceholders(from, n int) string {
+	placeholders := make([]string, n)
+	for i := range placeholders {
+		placeholders[i] = "$" + strconv.Itoa(from+i)
+	}
+
+	return strings.Join(placeholders, ",")
+}
@@ -0,0 +1,265 @@
+package adapters
+
+import (
+	"database/sql"
+	"fmt"
+	"mini-search-platform/internal/models"
+)
+
+type PostgresCataloguesRepository struct {
+	db       *sql.DB
+	tenantID int
+}
+
+func NewPostgresCataloguesRepository(db *sql.DB) *PostgresCataloguesRepository {
+	return &PostgresCataloguesRepository{db: db}
+}
+
+func (r *PostgresCataloguesRepository) ForTenant(tenantID int) models.CataloguesRepository {
+	return &PostgresCataloguesRepository{db: r.db, tenantID: tenantID}
+}
+
+func (r *PostgresCataloguesRepository) Save(catalogue *models.Catalogue) (int, error) {
+	query := `
+		INSERT INTO catalogues (tenant_id, code, language, name, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(query,
+		r.tenantID,
+		catalogue.Code,
+		catalogue.Language,
+		catalogue.Name,
+		catalogue.CreatedAt,
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	entry := models.NewOutboxEntry(models.OutboxCatalogueCreated)
+	entry.CatalogueID = id
+	if err := enqueuePostgres(tx, r.tenantID, entry); err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+func (r *PostgresCataloguesRepository) FindById(id int) (*models.Catalogue, error) {
+	query := `
+		SELECT id, code, language, name, created_at
+		FROM catalogues
+		WHERE id = $1 AND tenant_id = $2
+	`
+	row := r.db.QueryRow(query, id, r.tenantID)
+
+	var catalogue models.Catalogue
+	err := row.Scan(&catalogue.ID, &catalogue.Code, &catalogue.Language, &catalogue.Name, &catalogue.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &catalogue, nil
+}
+
+func (r *PostgresCataloguesRepository) FindByCode(code string) (*models.Catalogue, error) {
+	query := `
+		SELECT id, code, language, name, created_at
+		FROM catalogues
+		WHERE code = $1 AND tenant_id = $2
+	`
+	row := r.db.QueryRow(query, code, r.tenantID)
+
+	var catalogue models.Catalogue
+	err := row.Scan(&catalogue.ID, &catalogue.Code, &catalogue.Language, &catalogue.Name, &catalogue.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &catalogue, nil
+}
+
+func (r *PostgresCataloguesRepository) FindAll() ([]*models.Catalogue, error) {
+	query := `
+		SELECT id, code, language, name, created_at
+		FROM catalogues
+		WHERE tenant_id = $1
+		ORDER BY code COLLATE "C"
+	`
+	rows, err := r.db.Query(query, r.tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	catalogues := []*models.Catalogue{}
+	for rows.Next() {
+		var catalogue models.Catalogue
+		err := rows.Scan(&catalogue.ID, &catalogue.Code, &catalogue.Language, &catalogue.Name, &catalogue.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		catalogues = append(catalogues, &catalogue)
+	}
+
+	return catalogues, rows.Err()
+}
+
+type PostgresTranslationsRepository struct {
+	db       *sql.DB
+	tenantID int
+}
+
+func NewPostgresTranslationsRepository(db *sql.DB) *PostgresTranslationsRepository {
+	return &PostgresTranslationsRepository{db: db}
+}
+
+func (r *PostgresTranslationsRepository) ForTenant(tenantID int) models.TranslationsRepository {
+	return &PostgresTranslationsRepository{db: r.db, tenantID: tenantID}
+}
+
+func (r *PostgresTranslationsRepository) Save(translation *models.Translation) error {
+	query := `
+		INSERT INTO translations (
+			tenant_id,
+			catalogue_id,
+			entity_type,
+			entity_id,
+			title,
+			body,
+			source,
+			status,
+			attempts,
+			last_error,
+			updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (catalogue_id, entity_type, entity_id) DO UPDATE SET
+			title = excluded.title,
+			body = excluded.body,
+			source = excluded.source,
+			status = excluded.status,
+			attempts = excluded.attempts,
+			last_error = excluded.last_error,
+			updated_at = excluded.updated_at
+		WHERE translations.tenant_id = excluded.tenant_id
+			AND (translations.source = 'machine' OR excluded.source = 'manual')
+	`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(query,
+		r.tenantID,
+		translation.CatalogueID,
+		translation.EntityType,
+		translation.EntityID,
+		translation.Title,
+		translation.Body,
+		translation.Source,
+		translation.Status,
+		translation.Attempts,
+		translation.LastError,
+		translation.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if translation.IsTranslated() {
+		operation := models.OutboxArticleTranslationChanged
+		if translation.EntityType == models.EntityProduct {
+			operation = models.OutboxProductTranslationChanged
+		}
+		entry := models.NewOutboxEntry(operation, translation.EntityID)
+		entry.CatalogueID = translation.CatalogueID
+		if err := enqueuePostgres(tx, r.tenantID, entry); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *PostgresTranslationsRepository) Find(catalogueID int, entityType, entityID string) (*models.Translation, error) {
+	query := `
+		SELECT catalogue_id, entity_type, entity_id, title, body, source, status, attempts, last_error, updated_at
+		FROM translations
+		WHERE tenant_id = $1 AND catalogue_id = $2 AND entity_type = $3 AND entity_id = $4
+	`
+	row := r.db.QueryRow(query, r.tenantID, catalogueID, entityType, entityID)
+
+	return scanTranslation(row)
+}
+
+// FindByEntities loads the translations of several entities at once, keyed
+// by entity id. Entities without a translation are missing from the map.
+func (r *PostgresTranslationsRepository) FindByEntities(catalogueID int, entityType string, entityIDs []string) (map[string]*models.Translation, error) {
+	translations := make(map[string]*models.Translation)
+	if len(entityIDs) == 0 {
+		return translations, nil
+	}
+
+	query := fmt.Sprintf(`
+		SELECT catalogue_id, entity_type, entity_id, title, body, source, status, attempts, last_error, updated_at
+		FROM translations
+		WHERE tenant_id = $1 AND catalogue_id = $2 AND entity_type = $3 AND entity_id IN (%s)
+	`, numberedPlaceholders(4, len(entityIDs)))
+
+	args := make([]interface{}, 0, len(entityIDs)+3)
+	args = append(args, r.tenantID, catalogueID, entityType)
+	for _, entityID := range entityIDs {
+		args = append(args, entityID)
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		translation, err := scanTranslation(rows)
+		if err != nil {
+			return nil, err
+		}
+		translations[translation.EntityID] = translation
+	}
+
+	return translations, rows.Err()
+}
+
+func (r *PostgresTranslationsRepository) FindByStatus(catalogueID int, status string) ([]*models.Translation, error) {
+	query := `
+		SELECT catalogue_id, entity_type, entity_id, title, body, source, status, attempts, last_error, updated_at
+		FROM translations
+		WHERE tenant_id = $1 AND catalogue_id = $2 AND status = $3
+		ORDER BY entity_type COLLATE "C", entity_id COLLATE "C"
+	`
+	rows, err := r.db.Query(query, r.tenantID, catalogueID, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	translations := []*models.Translation{}
+	for rows.Next() {
+		translation, err := scanTranslation(rows)
+		if err != nil {
+			return nil, err
+		}
+		translations = append(translations, translation)
+	}
+
+	return translations, rows.Err()
+}
@@ -0,0 +1,172 @@
+package adapters
+
+import (
+	"database/sql"
+	"mini-search-platform/internal/models"
+	"time"
+)
+
+type PostgresMerchandisingRulesRepository struct {
+	db       *sql.DB
+	tenantID int
+}
+
+func NewPostgresMerchandisingRulesRepository(db *sql.DB) *PostgresMerchandisingRulesRepository {
+	return &PostgresMerchandisingRulesRepository{db: db}
+}
+
+func (r *PostgresMerchandisingRulesRepository) ForTenant(tenantID int) models.MerchandisingRulesRepository {
+	return &PostgresMerchandisingRulesRepository{db: r.db, tenantID: tenantID}
+}
+
+func (r *PostgresMerchandisingRulesRepository) Save(rule *models.MerchandisingRule) (int, error) {
+	pinned, buried, err := encodeMerchandising(rule)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+		INSERT INTO merchandising_rules (
+			tenant_id,
+			index_name,
+			match,
+			query,
+			pinned,
+			buried,
+			starts_at,
+			ends_at,
+			created_at,
+			updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id
+	`
+
+	var id int
+	err = r.db.QueryRow(query,
+		r.tenantID,
+		rule.Index,
+		rule.Match,
+		rule.Query,
+		pinned,
+		buried,
+		rule.StartsAt,
+		rule.EndsAt,
+		rule.CreatedAt,
+		rule.UpdatedAt,
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *PostgresMerchandisingRulesRepository) Update(rule *models.MerchandisingRule) error {
+	pinned, buried, err := encodeMerchandising(rule)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE merchandising_rules
+		SET match = $1, query = $2, pinned = $3, buried = $4, starts_at = $5, ends_at = $6, updated_at = $7
+		WHERE id = $8 AND tenant_id = $9
+	`
+	result, err := r.db.Exec(query,
+		rule.Match,
+		rule.Query,
+		pinned,
+		buried,
+		rule.StartsAt,
+		rule.EndsAt,
+		rule.UpdatedAt,
+		rule.ID,
+		r.tenantID,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *PostgresMerchandisingRulesRepository) Delete(id int) error {
+	result, err := r.db.Exec(`DELETE FROM merchandising_rules WHERE id = $1 AND tenant_id = $2`, id, r.tenantID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *PostgresMerchandisingRulesRepository) FindById(id int) (*models.MerchandisingRule, error) {
+	query := `
+		SELECT id, index_name, match, query, pinned, buried, starts_at, ends_at, created_at, updated_at
+		FROM merchandising_rules
+		WHERE id = $1 AND tenant_id = $2
+	`
+
+	return scanMerchandisingRule(r.db.QueryRow(query, id, r.tenantID))
+}
+
+func (r *PostgresMerchandisingRulesRepository) FindByIndex(index string) ([]*models.MerchandisingRule, error) {
+	query := `
+		SELECT id, index_name, match, query, pinned, buried, starts_at, ends_at, created_at, updated_at
+		FROM merchandising_rules
+		WHERE tenant_id = $1 AND index_name = $2
+		ORDER BY id
+	`
+
+	return r.query(query, r.tenantID, index)
+}
+
+// FindActive compares the campaign bounds as text like the SQLite
+// repository; the columns use the "C" collation for that.
+func (r *PostgresMerchandisingRulesRepository) FindActive(index string, at time.Time) ([]*models.MerchandisingRule, error) {
+	query := `
+		SELECT id, index_name, match, query, pinned, buried, starts_at, ends_at, created_at, updated_at
+		FROM merchandising_rules
+		WHERE tenant_id = $1 AND index_name = $2
+			AND (starts_at = '' OR starts_at <= $3)
+			AND (ends_at = '' OR ends_at > $4)
+		ORDER BY id
+	`
+	now := at.UTC().Format(time.RFC3339)
+
+	return r.query(query, r.tenantID, index, now, now)
+}
+
+func (r *PostgresMerchandisingRulesRepository) query(query string, args ...interface{}) ([]*models.MerchandisingRule, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []*models.MerchandisingRule{}
+	for rows.Next() {
+		rule, err := scanMerchandisingRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
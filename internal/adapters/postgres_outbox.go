@@ -0,0 +1,158 @@
+package adapters
+
+import (
+	"database/sql"
+	"encoding/json"
+	"mini-search-platform/internal/models"
+	"time"
+)
+
+// enqueuePostgres writes an index outbox entry like enqueue does for
+// SQLite.
+func enqueuePostgres(tx execer, tenantID int, entry *models.OutboxEntry) error {
+	entityIDs, err := json.Marshal(entry.EntityIDs)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO index_outbox (
+			tenant_id,
+			operation,
+			catalogue_id,
+			entity_ids,
+			status,
+			attempts,
+			available_at,
+			created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err = tx.Exec(query,
+		tenantID,
+		entry.Operation,
+		entry.CatalogueID,
+		string(entityIDs),
+		entry.Status,
+		entry.Attempts,
+		entry.AvailableAt,
+		entry.CreatedAt,
+	)
+
+	return err
+}
+
+type PostgresOutboxRepository struct {
+	db       *sql.DB
+	tenantID int
+}
+
+func NewPostgresOutboxRepository(db *sql.DB) *PostgresOutboxRepository {
+	return &PostgresOutboxRepository{db: db}
+}
+
+func (r *PostgresOutboxRepository) ForTenant(tenantID int) models.OutboxRepository {
+	return &PostgresOutboxRepository{db: r.db, tenantID: tenantID}
+}
+
+func (r *PostgresOutboxRepository) FindDue(now time.Time, limit int) ([]*models.OutboxEntry, error) {
+	query := `
+		SELECT id, tenant_id, operation, catalogue_id, entity_ids, status, attempts, last_error, available_at, created_at
+		FROM index_outbox
+		WHERE status = $1 AND available_at <= $2
+		ORDER BY id
+		LIMIT $3
+	`
+
+	return r.query(query, models.OutboxPending, now.UTC().Format(time.RFC3339), limit)
+}
+
+func (r *PostgresOutboxRepository) FindByStatus(status string) ([]*models.OutboxEntry, error) {
+	query := `
+		SELECT id, tenant_id, operation, catalogue_id, entity_ids, status, attempts, last_error, available_at, created_at
+		FROM index_outbox
+		WHERE tenant_id = $1 AND status = $2
+		ORDER BY id
+	`
+
+	return r.query(query, r.tenantID, status)
+}
+
+func (r *PostgresOutboxRepository) Complete(id int) error {
+	_, err := r.db.Exec(`DELETE FROM index_outbox WHERE id = $1`, id)
+	return err
+}
+
+func (r *PostgresOutboxRepository) Fail(entry *models.OutboxEntry, lastError string, retryAt time.Time, dead bool) error {
+	entry.Attempts++
+	entry.LastError = lastError
+	entry.AvailableAt = retryAt.UTC().Format(time.RFC3339)
+	if dead {
+		entry.Status = models.OutboxDead
+	}
+
+	query := `
+		UPDATE index_outbox
+		SET status = $1, attempts = $2, last_error = $3, available_at = $4
+		WHERE id = $5
+	`
+
+	_, err := r.db.Exec(query, entry.Status, entry.Attempts, entry.LastError, entry.AvailableAt, entry.ID)
+	return err
+}
+
+func (r *PostgresOutboxRepository) Requeue(id int) error {
+	query := `
+		UPDATE index_outbox
+		SET status = $1, attempts = 0, available_at = $2
+		WHERE id = $3 AND tenant_id = $4 AND status = $5
+	`
+
+	result, err := r.db.Exec(query,
+		models.OutboxPending,
+		time.Now().UTC().Format(time.RFC3339),
+		id,
+		r.tenantID,
+		models.OutboxDead,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *PostgresOutboxRepository) query(query string, args ...interface{}) ([]*models.OutboxEntry, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*models.OutboxEntry{}
+	for rows.Next() {
+		var entry models.OutboxEntry
+		var entityIDs string
+		err := rows.Scan(
+			&entry.ID, &entry.TenantID, &entry.Operation, &entry.CatalogueID, &entityIDs,
+			&entry.Status, &entry.Attempts, &entry.LastError, &entry.AvailableAt, &entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(entityIDs), &entry.EntityIDs); err != nil {
+			return nil, err
+		}
+		entries = append(entries, &entry)
+	}
+
+	return entries, rows.Err()
+}
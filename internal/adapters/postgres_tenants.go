@@ -0,0 +1,181 @@
+package adapters
+
+import (
+	"database/sql"
+	"mini-search-platform/internal/models"
+)
+
+type PostgresTenantsRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresTenantsRepository(db *sql.DB) *PostgresTenantsRepository {
+	return &PostgresTenantsRepository{db: db}
+}
+
+func (r *PostgresTenantsRepository) Save(tenant *models.Tenant) (int, error) {
+	query := `
+		INSERT INTO tenants (name, created_at)
+		VALUES ($1, $2)
+		RETURNING id
+	`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var id int
+	if err := tx.QueryRow(query, tenant.Name, tenant.CreatedAt).Scan(&id); err != nil {
+		return 0, err
+	}
+
+	err = enqueuePostgres(tx, id, models.NewOutboxEntry(models.OutboxTenantCreated))
+	if err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+func (r *PostgresTenantsRepository) FindById(id int) (*models.Tenant, error) {
+	query := `
+		SELECT id, name, created_at
+		FROM tenants
+		WHERE id = $1
+	`
+	row := r.db.QueryRow(query, id)
+
+	var tenant models.Tenant
+	err := row.Scan(&tenant.ID, &tenant.Name, &tenant.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tenant, nil
+}
+
+func (r *PostgresTenantsRepository) FindAll() ([]*models.Tenant, error) {
+	query := `
+		SELECT id, name, created_at
+		FROM tenants
+		ORDER BY id
+	`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tenants []*models.Tenant
+	for rows.Next() {
+		var tenant models.Tenant
+		err := rows.Scan(&tenant.ID, &tenant.Name, &tenant.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		tenants = append(tenants, &tenant)
+	}
+
+	return tenants, rows.Err()
+}
+
+type PostgresAPITokensRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresAPITokensRepository(db *sql.DB) *PostgresAPITokensRepository {
+	return &PostgresAPITokensRepository{db: db}
+}
+
+func (r *PostgresAPITokensRepository) Save(token *models.APIToken) (int, error) {
+	query := `
+		INSERT INTO api_tokens (tenant_id, name, token_hash, scopes, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	var id int
+	err := r.db.QueryRow(query,
+		token.TenantID,
+		token.Name,
+		token.TokenHash,
+		joinScopes(token.Scopes),
+		token.CreatedAt,
+		token.ExpiresAt,
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *PostgresAPITokensRepository) FindByHash(hash string) (*models.APIToken, error) {
+	query := `
+		SELECT id, tenant_id, name, token_hash, scopes, created_at, expires_at
+		FROM api_tokens
+		WHERE token_hash = $1
+	`
+	row := r.db.QueryRow(query, hash)
+
+	var (
+		token  models.APIToken
+		scopes string
+	)
+	err := row.Scan(&token.ID, &token.TenantID, &token.Name, &token.TokenHash, &scopes, &token.CreatedAt, &token.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	token.Scopes = splitScopes(scopes)
+
+	return &token, nil
+}
+
+func (r *PostgresAPITokensRepository) FindByTenant(tenantID int) ([]*models.APIToken, error) {
+	query := `
+		SELECT id, tenant_id, name, token_hash, scopes, created_at, expires_at
+		FROM api_tokens
+		WHERE tenant_id = $1
+		ORDER BY id
+	`
+	rows, err := r.db.Query(query, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []*models.APIToken{}
+	for rows.Next() {
+		var (
+			token  models.APIToken
+			scopes string
+		)
+		err := rows.Scan(&token.ID, &token.TenantID, &token.Name, &token.TokenHash, &scopes, &token.CreatedAt, &token.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+		token.Scopes = splitScopes(scopes)
+		tokens = append(tokens, &token)
+	}
+
+	return tokens, rows.Err()
+}
+
+func (r *PostgresAPITokensRepository) Delete(tenantID, id int) error {
+	result, err := r.db.Exec(`DELETE FROM api_tokens WHERE id = $1 AND tenant_id = $2`, id, tenantID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package adapters
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"mini-search-platform/config"
+	"mini-search-platform/internal/models"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mattn/go-sqlite3"
+)
+
+// Repositories are the repositories of a relational store.
+type Repositories struct {
+	Articles           models.ArticleRepository
+	Authors            models.AuthorsRepository
+	Tags               models.TagsRepository
+	Products           models.ProductRepository
+	Variants           models.VariantRepository
+	Tenants            models.TenantsRepository
+	Tokens             models.APITokensRepository
+	Catalogues         models.CataloguesRepository
+	Translations       models.TranslationsRepository
+	Outbox             models.OutboxRepository
+	SearchSettings     models.SearchSettingsRepository
+	MerchandisingRules models.MerchandisingRulesRepository
+}
+
+// NewRepositories returns the repositories of the database driver the
+// configuration selects, keeping their data in db.
+func NewRepositories(cfg *config.AppConfig, db *sql.DB) (*Repositories, error) {
+	switch cfg.Database.Driver {
+	case config.DatabaseSQLite:
+		return NewSQLliteRepositories(db), nil
+	case config.DatabasePostgres:
+		return NewPostgresRepositories(db), nil
+	}
+
+	return nil, fmt.Errorf("unknown database driver '%s'", cfg.Database.Driver)
+}
+
+func NewSQLliteRepositories(db *sql.DB) *Repositories {
+	return &Repositories{
+		Articles:           NewSQLliteArticleRepository(db),
+		Authors:            NewSQLliteAuthorsRepository(db),
+		Tags:               NewSQLliteTagsRepository(db),
+		Products:           NewSQLliteProductsRepository(db),
+		Variants:           NewSQLliteVariantsRepository(db),
+		Tenants:            NewSQLliteTenantsRepository(db),
+		Tokens:             NewSQLliteAPITokensRepository(db),
+		Catalogues:         NewSQLliteCataloguesRepository(db),
+		Translations:       NewSQLliteTranslationsRepository(db),
+		Outbox:             NewSQLliteOutboxRepository(db),
+		SearchSettings:     NewSQLliteSearchSettingsRepository(db),
+		MerchandisingRules: NewSQLliteMerchandisingRulesRepository(db),
+	}
+}
+
+func NewPostgresRepositories(db *sql.DB) *Repositories {
+	return &Repositories{
+		Articles:           NewPostgresArticleRepository(db),
+		Authors:            NewPostgresAuthorsRepository(db),
+		Tags:               NewPostgresTagsRepository(db),
+		Products:           NewPostgresProductsRepository(db),
+		Variants:           NewPostgresVariantsRepository(db),
+		Tenants:            NewPostgresTenantsRepository(db),
+		Tokens:             NewPostgresAPITokensRepository(db),
+		Catalogues:         NewPostgresCataloguesRepository(db),
+		Translations:       NewPostgresTranslationsRepository(db),
+		Outbox:             NewPostgresOutboxRepository(db),
+		SearchSettings:     NewPostgresSearchSettingsRepository(db),
+		MerchandisingRules: NewPostgresMerchandisingRulesRepository(db),
+	}
+}
+
+// isUniqueViolation reports whether the insert failed because the primary
+// key or a unique constraint is taken, in SQLite or PostgreSQL.
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey ||
+			sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+	}
+
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
@@ -0,0 +1,541 @@
+package adapters
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"mini-search-platform/config"
+	"mini-search-platform/internal/database"
+	"mini-search-platform/internal/models"
+	"mini-search-platform/pkg/postgres"
+	"mini-search-platform/pkg/sqlite"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSQLliteRepositories_Contract(t *testing.T) {
+	testRepositoriesContract(t, func(t *testing.T) *Repositories {
+		db, err := sqlite.Open(sqlite.MemoryDSN)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { sqlite.Close(db) })
+
+		if err := database.Create(db); err != nil {
+			t.Fatal(err)
+		}
+
+		return NewSQLliteRepositories(db)
+	})
+}
+
+// TestPostgresRepositories_Contract runs against the server of
+// POSTGRES_TEST_DSN, or else against a throwaway cluster if initdb and
+// pg_ctl are on the PATH. Every subtest gets a schema of its own.
+func TestPostgresRepositories_Contract(t *testing.T) {
+	dsn := postgresTestDSN(t)
+
+	admin, err := postgres.Open(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer postgres.Close(admin)
+	if err := admin.Ping(); err != nil {
+		t.Skipf("postgres is not reachable: %v", err)
+	}
+
+	n := 0
+	testRepositoriesContract(t, func(t *testing.T) *Repositories {
+		n++
+		schema := fmt.Sprintf("contract_%d_%d", time.Now().UnixNano(), n)
+		if _, err := admin.Exec(`CREATE SCHEMA ` + schema); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`) })
+
+		db, err := postgres.Open(withSearchPath(dsn, schema))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { postgres.Close(db) })
+
+		migrator, err := database.NewMigrator(db, config.DatabasePostgres)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := migrator.Migrate(migrator.LatestVersion()); err != nil {
+			t.Fatal(err)
+		}
+
+		return NewPostgresRepositories(db)
+	})
+}
+
+// testRepositoriesContract checks the behaviour every relational store has
+// to implement. newRepositories returns the repositories of an empty,
+// migrated database.
+func testRepositoriesContract(t *testing.T, newRepositories func(t *testing.T) *Repositories) {
+	t.Run("articles, authors and tags", func(t *testing.T) {
+		repositories := newRepositories(t)
+		tenantID := saveTenant(t, repositories, "articles")
+		authors := repositories.Authors.ForTenant(tenantID)
+		tags := repositories.Tags.ForTenant(tenantID)
+		articles := repositories.Articles.ForTenant(tenantID)
+
+		janeID, err := authors.Save(models.NewAuthor(0, "Jane Doe"))
+		if err != nil || janeID == 0 {
+			t.Fatalf("expected a generated author id, got %d, %v", janeID, err)
+		}
+		// ids are scoped to the tenant: given ones are kept unless taken
+		if _, err := authors.Save(models.NewAuthor(janeID, "John Roe")); !errors.Is(err, models.ErrAlreadyExists) {
+			t.Errorf("expected saving a taken author id to fail with ErrAlreadyExists, got %v", err)
+		}
+		if givenID, err := authors.Save(models.NewAuthor(janeID+10, "John Roe")); err != nil || givenID != janeID+10 {
+			t.Fatalf("expected the given author id %d, got %d, %v", janeID+10, givenID, err)
+		}
+		if nextID, err := authors.Save(models.NewAuthor(0, "Max Roe")); err != nil || nextID != janeID+11 {
+			t.Errorf("expected the next generated author id %d, got %d, %v", janeID+11, nextID, err)
+		}
+		otherTenantID := saveTenant(t, repositories, "articles-other")
+		if otherID, err := repositories.Authors.ForTenant(otherTenantID).Save(models.NewAuthor(janeID, "Jane Roe")); err != nil || otherID != janeID {
+			t.Errorf("expected another tenant to take the same author id %d, got %d, %v", janeID, otherID, err)
+		}
+		jane, err := authors.FindAuthorById(janeID)
+		if err != nil || jane.Name != "Jane Doe" {
+			t.Fatalf("expected Jane Doe, got %v, %v", jane, err)
+		}
+
+		denimID, err := tags.Save(models.NewTag("denim"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if again, err := tags.Save(models.NewTag("denim")); err != nil || again != denimID {
+			t.Errorf("expected saving a label again to keep id %d, got %d, %v", denimID, again, err)
+		}
+		blueID, err := tags.Save(models.NewTag("blue"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		found, err := tags.FindByLabels([]string{"denim", "blue", "missing"})
+		if err != nil || len(found) != 2 {
+			t.Errorf("expected 2 tags by label, got %d, %v", len(found), err)
+		}
+
+		jeansID, err := articles.Save(models.NewArticle("Jeans", "Blue jeans", jane, []*models.Tag{{ID: denimID}, {ID: blueID}}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := articles.Save(models.NewArticle("Jacket", "Denim jacket", jane, []*models.Tag{{ID: denimID}})); err != nil {
+			t.Fatal(err)
+		}
+
+		jeans, err := articles.FindById(jeansID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if jeans.Title != "Jeans" || jeans.Author != "Jane Doe" || !reflect.DeepEqual(tagLabels(jeans), []string{"blue", "denim"}) {
+			t.Errorf("unexpected article %+v with tags %v", jeans, tagLabels(jeans))
+		}
+		if all, err := articles.FindAll(10, 0); err != nil || len(all) != 2 || all[0].ID != jeansID {
+			t.Errorf("expected 2 articles in id order, got %d, %v", len(all), err)
+		}
+		if after, err := articles.FindAfter(jeansID, 10); err != nil || len(after) != 1 || after[0].Title != "Jacket" {
+			t.Errorf("expected the jacket after the jeans, got %v, %v", after, err)
+		}
+		if total, err := articles.Count(); err != nil || total != 2 {
+			t.Errorf("expected 2 articles, got %d, %v", total, err)
+		}
+		if tagged, err := articles.FindByTag(&models.Tag{ID: blueID}); err != nil || len(tagged) != 1 || tagged[0].ID != jeansID {
+			t.Errorf("expected the jeans tagged blue, got %v, %v", tagged, err)
+		}
+		suggestions, err := tags.FindPopularByPrefix("de", 5)
+		if err != nil || len(suggestions) != 1 || suggestions[0].Text != "denim" || suggestions[0].Count != 2 {
+			t.Errorf("expected denim with 2 articles, got %v, %v", suggestions, err)
+		}
+
+		jeans.Title, jeans.Tags = "Slim jeans", []*models.Tag{{ID: denimID}}
+		if err := articles.Update(jeans); err != nil {
+			t.Fatal(err)
+		}
+		if jeans, err = articles.FindById(jeansID); err != nil || jeans.Title != "Slim jeans" || !reflect.DeepEqual(tagLabels(jeans), []string{"denim"}) {
+			t.Errorf("expected the update to replace title and tags, got %+v, %v", jeans, err)
+		}
+
+		if err := articles.Delete(jeansID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := articles.FindById(jeansID); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("expected the deleted article to be gone, got %v", err)
+		}
+		if err := articles.Delete(jeansID); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("expected deleting twice to fail with no rows, got %v", err)
+		}
+		if err := articles.Update(jeans); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("expected updating a deleted article to fail with no rows, got %v", err)
+		}
+
+		operations := outboxOperations(t, repositories)
+		for _, operation := range []string{models.OutboxTenantCreated, models.OutboxTagChanged, models.OutboxArticlesChanged, models.OutboxArticlesDeleted} {
+			if operations[operation] == 0 {
+				t.Errorf("expected %s to be queued, got %v", operation, operations)
+			}
+		}
+	})
+
+	t.Run("products and variants", func(t *testing.T) {
+		repositories := newRepositories(t)
+		tenantID := saveTenant(t, repositories, "products")
+		products := repositories.Products.ForTenant(tenantID)
+		variants := repositories.Variants.ForTenant(tenantID)
+
+		for _, id := range []string{"sku-b", "sku-a"} {
+			if err := products.Save(models.NewProduct(id, "Jeans "+id, "Levis", "pants")); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := products.Save(models.NewProduct("sku-a", "Jeans", "Levis", "pants")); !errors.Is(err, models.ErrAlreadyExists) {
+			t.Errorf("expected saving a taken article id to fail with ErrAlreadyExists, got %v", err)
+		}
+		if all, err := products.FindAll(10, 0); err != nil || len(all) != 2 || all[0].ArticleID != "sku-a" {
+			t.Errorf("expected 2 products in article id order, got %v, %v", all, err)
+		}
+		if first, err := products.FindAfter("", 1); err != nil || len(first) != 1 || first[0].ArticleID != "sku-a" {
+			t.Errorf("expected sku-a first, got %v, %v", first, err)
+		}
+		if after, err := products.FindAfter("sku-a", 10); err != nil || len(after) != 1 || after[0].ArticleID != "sku-b" {
+			t.Errorf("expected sku-b after sku-a, got %v, %v", after, err)
+		}
+
+		product, err := products.FindById("sku-a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		product.Brand = "Wrangler"
+		if err := products.Update(product); err != nil {
+			t.Fatal(err)
+		}
+		if product, err = products.FindById("sku-a"); err != nil || product.Brand != "Wrangler" {
+			t.Errorf("expected the updated brand, got %+v, %v", product, err)
+		}
+
+		if facets, version, err := products.FindIndexedFacets("sku-a"); err != nil || facets != nil || version != product.IndexedFacetVersion {
+			t.Errorf("expected no facets before indexing, got %v, %d, %v", facets, version, err)
+		}
+		indexed := models.FacetData{AvailableSizes: []string{"M"}, AvailableColors: []string{"blue"}, IsInStock: true}
+		if saved, err := products.SaveIndexedFacets("sku-a", indexed, product.IndexedFacetVersion); err != nil || !saved {
+			t.Fatalf("expected the facets to be saved, got %t, %v", saved, err)
+		}
+		facets, version, err := products.FindIndexedFacets("sku-a")
+		if err != nil || !reflect.DeepEqual(*facets, indexed) || version != product.IndexedFacetVersion+1 {
+			t.Errorf("expected the indexed facets back with a new version, got %v, %d, %v", facets, version, err)
+		}
+		// a sync that read the facets before they were saved must not overwrite them
+		stale := models.FacetData{AvailableSizes: []string{}, AvailableColors: []string{}}
+		if saved, err := products.SaveIndexedFacets("sku-a", stale, product.IndexedFacetVersion); err != nil || saved {
+			t.Errorf("expected a save with a stale version to be refused, got %t, %v", saved, err)
+		}
+		if facets, _, err := products.FindIndexedFacets("sku-a"); err != nil || !reflect.DeepEqual(*facets, indexed) {
+			t.Errorf("expected the indexed facets to be kept, got %v, %v", facets, err)
+		}
+		if product, err = products.FindById("sku-a"); err != nil || product.IndexedFacetVersion != version {
+			t.Errorf("expected the product to carry version %d, got %+v, %v", version, product, err)
+		}
+		if err := products.ClearIndexedFacets(); err != nil {
+			t.Fatal(err)
+		}
+		if facets, cleared, err := products.FindIndexedFacets("sku-a"); err != nil || facets != nil || cleared != version+1 {
+			t.Errorf("expected the facets to be cleared with a new version, got %v, %d, %v", facets, cleared, err)
+		}
+
+		if err := variants.Save(models.NewVariant("v-2", "sku-a", "L", "black", 59.5, false)); err != nil {
+			t.Fatal(err)
+		}
+		if err := variants.Save(models.NewVariant("v-1", "sku-a", "M", "blue", 49.9, true)); err != nil {
+			t.Fatal(err)
+		}
+		if err := variants.Save(models.NewVariant("v-3", "sku-b", "S", "white", 39, true)); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := variants.Save(models.NewVariant("v-1", "sku-b", "S", "blue", 10, true)); !errors.Is(err, models.ErrAlreadyExists) {
+			t.Errorf("expected saving a taken variant id to fail with ErrAlreadyExists, got %v", err)
+		}
+
+		variant, err := variants.FindById("v-2")
+		if err != nil || variant.Price != 59.5 || variant.Availability {
+			t.Fatalf("unexpected variant %+v, %v", variant, err)
+		}
+		variant.Availability = true
+		if err := variants.Update(variant); err != nil {
+			t.Fatal(err)
+		}
+		if variant, err = variants.FindById("v-2"); err != nil || !variant.Availability {
+			t.Errorf("expected the variant to be available, got %+v, %v", variant, err)
+		}
+
+		if found, err := variants.FindByArticleId("sku-a"); err != nil || len(found) != 2 || found[0].VariantID != "v-1" {
+			t.Errorf("expected 2 variants in id order, got %v, %v", found, err)
+		}
+		if found, err := variants.FindByArticleIds([]string{"sku-a", "sku-b"}); err != nil || len(found) != 3 {
+			t.Errorf("expected 3 variants, got %v, %v", found, err)
+		}
+
+		if err := variants.Delete("v-3"); err != nil {
+			t.Fatal(err)
+		}
+		if err := variants.Delete("v-3"); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("expected deleting twice to fail with no rows, got %v", err)
+		}
+		if err := products.Delete("sku-a"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := variants.FindById("v-1"); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("expected the variants to be deleted with the product, got %v", err)
+		}
+		if total, err := products.Count(); err != nil || total != 1 {
+			t.Errorf("expected 1 product left, got %d, %v", total, err)
+		}
+	})
+
+	t.Run("tokens, catalogues and translations", func(t *testing.T) {
+		repositories := newRepositories(t)
+		tenantID := saveTenant(t, repositories, "catalogues")
+
+		token, _, err := models.NewAPIToken(tenantID, "ci", []models.Scope{models.ScopeSearch, models.ScopeCatalogRead})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokenID, err := repositories.Tokens.Save(token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found, err := repositories.Tokens.FindByHash(token.TokenHash); err != nil || found.ID != tokenID || !reflect.DeepEqual(found.Scopes, token.Scopes) {
+			t.Errorf("expected the token with its scopes, got %+v, %v", found, err)
+		}
+		if err := repositories.Tokens.Delete(tenantID, tokenID); err != nil {
+			t.Fatal(err)
+		}
+		if found, err := repositories.Tokens.FindByTenant(tenantID); err != nil || len(found) != 0 {
+			t.Errorf("expected no tokens left, got %v, %v", found, err)
+		}
+
+		catalogues := repositories.Catalogues.ForTenant(tenantID)
+		translations := repositories.Translations.ForTenant(tenantID)
+
+		catalogueID, err := catalogues.Save(models.NewCatalogue("de-de", "de", "Germany"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if catalogue, err := catalogues.FindByCode("de-de"); err != nil || catalogue.ID != catalogueID {
+			t.Errorf("expected catalogue %d, got %v, %v", catalogueID, catalogue, err)
+		}
+
+		if err := translations.Save(models.NewTranslation(catalogueID, models.EntityArticle, "1", "Jeans (manuell)", "")); err != nil {
+			t.Fatal(err)
+		}
+		machine := models.NewPendingTranslation(catalogueID, models.EntityArticle, "1")
+		machine.Title, machine.Status = "Jeans (maschinell)", models.TranslationTranslated
+		if err := translations.Save(machine); err != nil {
+			t.Fatal(err)
+		}
+		if err := translations.Save(models.NewPendingTranslation(catalogueID, models.EntityArticle, "2")); err != nil {
+			t.Fatal(err)
+		}
+
+		if found, err := translations.Find(catalogueID, models.EntityArticle, "1"); err != nil || found.Title != "Jeans (manuell)" {
+			t.Errorf("expected the manual translation to be kept, got %+v, %v", found, err)
+		}
+		if found, err := translations.FindByEntities(catalogueID, models.EntityArticle, []string{"1", "2", "3"}); err != nil || len(found) != 2 {
+			t.Errorf("expected 2 translations, got %v, %v", found, err)
+		}
+		if pending, err := translations.FindByStatus(catalogueID, models.TranslationPending); err != nil || len(pending) != 1 || pending[0].EntityID != "2" {
+			t.Errorf("expected the pending translation, got %v, %v", pending, err)
+		}
+	})
+
+	t.Run("search settings and merchandising rules", func(t *testing.T) {
+		repositories := newRepositories(t)
+		tenantID := saveTenant(t, repositories, "settings")
+		settings := repositories.SearchSettings.ForTenant(tenantID)
+		rules := repositories.MerchandisingRules.ForTenant(tenantID)
+
+		if _, err := settings.FindByIndex("articles"); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("expected no settings yet, got %v", err)
+		}
+		saved := models.NewSearchSettings("articles")
+		saved.Synonyms = map[string][]string{"jeans": {"denim"}}
+		saved.StopWords = []string{"the"}
+		for i := 0; i < 2; i++ {
+			if err := settings.Save(saved); err != nil {
+				t.Fatal(err)
+			}
+		}
+		found, err := settings.FindByIndex("articles")
+		if err != nil || !reflect.DeepEqual(found.Synonyms, saved.Synonyms) || !reflect.DeepEqual(found.StopWords, saved.StopWords) {
+			t.Errorf("expected the saved settings back, got %+v, %v", found, err)
+		}
+
+		now := time.Date(2026, 11, 27, 12, 0, 0, 0, time.UTC)
+		rule := models.NewMerchandisingRule("articles", models.MatchExact, "jeans")
+		rule.Pinned = []models.Pin{{ID: "7", Position: 1}}
+		rule.StartsAt = now.Add(-time.Hour).Format(time.RFC3339)
+		ruleID, err := rules.Save(rule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ended := models.NewMerchandisingRule("articles", models.MatchExact, "jeans")
+		ended.Buried = []string{"9"}
+		ended.EndsAt = now.Format(time.RFC3339)
+		if _, err := rules.Save(ended); err != nil {
+			t.Fatal(err)
+		}
+
+		active, err := rules.FindActive("articles", now)
+		if err != nil || len(active) != 1 || active[0].ID != ruleID || !reflect.DeepEqual(active[0].Pinned, rule.Pinned) {
+			t.Errorf("expected only the running rule, got %v, %v", active, err)
+		}
+
+		rule.ID, rule.Query = ruleID, "slim jeans"
+		if err := rules.Update(rule); err != nil {
+			t.Fatal(err)
+		}
+		if found, err := rules.FindById(ruleID); err != nil || found.Query != "slim jeans" {
+			t.Errorf("expected the updated query, got %+v, %v", found, err)
+		}
+		if err := rules.Delete(ruleID); err != nil {
+			t.Fatal(err)
+		}
+		if all, err := rules.FindByIndex("articles"); err != nil || len(all) != 1 {
+			t.Errorf("expected 1 rule left, got %v, %v", all, err)
+		}
+	})
+
+	t.Run("outbox", func(t *testing.T) {
+		repositories := newRepositories(t)
+		tenantID := saveTenant(t, repositories, "outbox")
+		outbox := repositories.Outbox.ForTenant(tenantID)
+
+		due, err := outbox.FindDue(time.Now().Add(time.Minute), 10)
+		if err != nil || len(due) != 1 || due[0].Operation != models.OutboxTenantCreated || due[0].TenantID != tenantID {
+			t.Fatalf("expected the tenant creation to be due, got %v, %v", due, err)
+		}
+
+		if err := outbox.Fail(due[0], "unavailable", time.Now().Add(time.Hour), true); err != nil {
+			t.Fatal(err)
+		}
+		if due, err := outbox.FindDue(time.Now().Add(time.Minute), 10); err != nil || len(due) != 0 {
+			t.Errorf("expected no due entries after the failure, got %v, %v", due, err)
+		}
+		dead, err := outbox.FindByStatus(models.OutboxDead)
+		if err != nil || len(dead) != 1 || dead[0].LastError != "unavailable" || dead[0].Attempts != 1 {
+			t.Fatalf("expected the dead entry, got %v, %v", dead, err)
+		}
+
+		if err := outbox.Requeue(dead[0].ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := outbox.Requeue(dead[0].ID); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("expected requeueing a pending entry to fail with no rows, got %v", err)
+		}
+		if err := outbox.Complete(dead[0].ID); err != nil {
+			t.Fatal(err)
+		}
+		if pending, err := outbox.FindByStatus(models.OutboxPending); err != nil || len(pending) != 0 {
+			t.Errorf("expected the completed entry to be gone, got %v, %v", pending, err)
+		}
+	})
+}
+
+func saveTenant(t *testing.T, repositories *Repositories, name string) int {
+	t.Helper()
+
+	tenantID, err := repositories.Tenants.Save(models.NewTenant(name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tenant, err := repositories.Tenants.FindById(tenantID); err != nil || tenant.Name != name {
+		t.Fatalf("expected tenant %s, got %v, %v", name, tenant, err)
+	}
+
+	return tenantID
+}
+
+func tagLabels(article *models.Article) []string {
+	labels := []string{}
+	for _, tag := range article.Tags {
+		labels = append(labels, tag.Label)
+	}
+	sort.Strings(labels)
+
+	return labels
+}
+
+// outboxOperations counts the pending outbox entries by operation.
+func outboxOperations(t *testing.T, repositories *Repositories) map[string]int {
+	t.Helper()
+
+	due, err := repositories.Outbox.FindDue(time.Now().Add(time.Minute), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	operations := map[string]int{}
+	for _, entry := range due {
+		operations[entry.Operation]++
+	}
+
+	return operations
+}
+
+// postgresTestDSN returns POSTGRES_TEST_DSN, or starts a cluster listening
+// on a socket in a temporary directory until the test ends. It skips the
+// test if neither is possible.
+func postgresTestDSN(t *testing.T) string {
+	if dsn := os.Getenv("POSTGRES_TEST_DSN"); dsn != "" {
+		return dsn
+	}
+
+	initdb, err := exec.LookPath("initdb")
+	if err != nil {
+		t.Skip("set POSTGRES_TEST_DSN or put initdb and pg_ctl on the PATH to run against postgres")
+	}
+	pgCtl, err := exec.LookPath("pg_ctl")
+	if err != nil {
+		t.Skip("set POSTGRES_TEST_DSN or put initdb and pg_ctl on the PATH to run against postgres")
+	}
+
+	dir := t.TempDir()
+	data := filepath.Join(dir, "data")
+	if output, err := exec.Command(initdb, "-D", data, "-U", "postgres", "-A", "trust", "--no-sync").CombinedOutput(); err != nil {
+		t.Skipf("initdb failed: %v: %s", err, output)
+	}
+
+	options := fmt.Sprintf("-k %s -c listen_addresses='' -p 5432", dir)
+	start := exec.Command(pgCtl, "-D", data, "-o", options, "-l", filepath.Join(dir, "postgres.log"), "-w", "start")
+	if output, err := start.CombinedOutput(); err != nil {
+		t.Skipf("pg_ctl failed to start postgres: %v: %s", err, output)
+	}
+	t.Cleanup(func() {
+		exec.Command(pgCtl, "-D", data, "-m", "immediate", "stop").Run()
+	})
+
+	return fmt.Sprintf("host=%s port=5432 user=postgres dbname=postgres sslmode=disable", dir)
+}
+
+// withSearchPath adds the search_path parameter to a URL or key=value DSN.
+func withSearchPath(dsn, schema string) string {
+	if !strings.HasPrefix(dsn, "postgres://") && !strings.HasPrefix(dsn, "postgresql://") {
+		return dsn + " search_path=" + schema
+	}
+	if strings.Contains(dsn, "?") {
+		return dsn + "&search_path=" + schema
+	}
+
+	return dsn + "?search_path=" + schema
+}
@@ -4,48 +4,61 @@ import (
 	"database/sql"
 	"fmt"
 	"mini-search-platform/internal/models"
+	"strconv"
 	"strings"
 )
 
 type SQLliteAuthorsRepository struct {
-	db *sql.DB
+	db       *sql.DB
+	tenantID int
 }
 
 func NewSQLliteAuthorsRepository(db *sql.DB) *SQLliteAuthorsRepository {
 	return &SQLliteAuthorsRepository{db: db}
 }
+
+func (r *SQLliteAuthorsRepository) ForTenant(tenantID int) models.AuthorsRepository {
+	return &SQLliteAuthorsRepository{db: r.db, tenantID: tenantID}
+}
+
+// Save keeps the id of the author if it is given, and generates the next
+// one of the tenant otherwise. Author ids are scoped to the tenant.
 func (r *SQLliteAuthorsRepository) Save(author *models.Author) (int, error) {
 	query := `
 		INSERT INTO authors (
 			id,
-			name, 
+			tenant_id,
+			name,
 			created_at
-		) VALUES (?, ?, ?)
+		) VALUES (
+			COALESCE(?, (SELECT COALESCE(MAX(id), 0) + 1 FROM authors WHERE tenant_id = ?)),
+			?, ?, ?
+		)
+		RETURNING id
 	`
 
-	result, err := r.db.Exec(query,
-		author.ID,
+	var id int
+	err := r.db.QueryRow(query,
+		sql.NullInt64{Int64: int64(author.ID), Valid: author.ID != 0},
+		r.tenantID,
+		r.tenantID,
 		author.Name,
 		author.CreatedAt,
-	)
-	if err != nil {
-		return 0, err
+	).Scan(&id)
+	if isUniqueViolation(err) {
+		return 0, fmt.Errorf("author %d '%s': %w", author.ID, author.Name, models.ErrAlreadyExists)
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		return 0, err
-	}
-
-	return int(id), err
+	return id, err
 }
+
 func (r *SQLliteAuthorsRepository) FindAuthorById(id int) (*models.Author, error) {
 	query := `
 		SELECT id, name, created_at
 		FROM authors
-		WHERE id = ?
+		WHERE id = ? AND tenant_id = ?
 	`
-	row := r.db.QueryRow(query, id)
+	row := r.db.QueryRow(query, id, r.tenantID)
 
 	var author models.Author
 	err := row.Scan(&author.ID, &author.Name, &author.CreatedAt)
@@ -56,21 +69,43 @@ func (r *SQLliteAuthorsRepository) FindAuthorById(id int) (*models.Author, error
 	return &author, nil
 }
 
+func (r *SQLliteAuthorsRepository) FindPopularByPrefix(prefix string, limit int) ([]*models.Suggestion, error) {
+	query := `
+		SELECT au.name, COUNT(ar.id) AS articles
+		FROM authors au
+		LEFT JOIN articles ar ON ar.author_id = au.id AND ar.tenant_id = au.tenant_id
+		WHERE au.tenant_id = ? AND (LOWER(au.name) LIKE ? ESCAPE '\' OR LOWER(au.name) LIKE ? ESCAPE '\')
+		GROUP BY au.id
+		ORDER BY articles DESC, au.name
+		LIMIT ?
+	`
+	start, word := prefixPatterns(prefix)
+
+	return querySuggestions(r.db, query, r.tenantID, start, word, limit)
+}
+
 type SQLliteArticleRepository struct {
-	db *sql.DB
+	db       *sql.DB
+	tenantID int
 }
 
 func NewSQLliteArticleRepository(db *sql.DB) *SQLliteArticleRepository {
 	return &SQLliteArticleRepository{db: db}
 }
+
+func (r *SQLliteArticleRepository) ForTenant(tenantID int) models.ArticleRepository {
+	return &SQLliteArticleRepository{db: r.db, tenantID: tenantID}
+}
+
 func (r *SQLliteArticleRepository) Save(article *models.Article) (int, error) {
 	query := `
 		INSERT INTO articles (
+			tenant_id,
 			title, 
 			body, 
 			author_id,
 			created_at
-		) VALUES (?, ?, ?, ?);
+		) VALUES (?, ?, ?, ?, ?);
 	`
 
 	tx, err := r.db.Begin()
@@ -80,6 +115,7 @@ func (r *SQLliteArticleRepository) Save(article *models.Article) (int, error) {
 	defer tx.Rollback()
 
 	result, err := tx.Exec(query,
+		r.tenantID,
 		article.Title,
 		article.Body,
 		article.AuthorID,
@@ -106,6 +142,11 @@ func (r *SQLliteArticleRepository) Save(article *models.Article) (int, error) {
 		}
 	}
 
+	err = enqueue(tx, r.tenantID, models.NewOutboxEntry(models.OutboxArticlesChanged, strconv.FormatInt(lastInsertedId, 10)))
+	if err != nil {
+		return 0, err
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		return 0, err
@@ -128,17 +169,17 @@ func (r *SQLliteArticleRepository) FindByTag(tag *models.Tag) ([]*models.Article
 			t.created_at,
 			t.updated_at
 		FROM articles a
-		JOIN authors au ON a.author_id = au.id
+		JOIN authors au ON au.tenant_id = a.tenant_id AND au.id = a.author_id
 		JOIN tags t ON at.tag_id = t.id
 		JOIN article_tags at ON a.id = at.article_id
-		WHERE a.id IN (
+		WHERE a.tenant_id = ? AND a.id IN (
 			SELECT at.article_id
 			FROM article_tags at
 			WHERE at.tag_id = ?
 		)
 	`
 
-	rows, err := r.db.Query(query, tag.ID)
+	rows, err := r.db.Query(query, r.tenantID, tag.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -197,49 +238,306 @@ func (r *SQLliteArticleRepository) FindByTag(tag *models.Tag) ([]*models.Article
 
 }
 
+func (r *SQLliteArticleRepository) Update(article *models.Article) error {
+	query := `
+		UPDATE articles
+		SET title = ?, body = ?, author_id = ?
+		WHERE id = ? AND tenant_id = ?
+	`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query,
+		article.Title,
+		article.Body,
+		article.AuthorID,
+		article.ID,
+		r.tenantID,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	_, err = tx.Exec(`DELETE FROM article_tags WHERE article_id = ?`, article.ID)
+	if err != nil {
+		return err
+	}
+
+	query = `
+		INSERT INTO article_tags (article_id, tag_id)
+		VALUES (?, ?)
+	`
+
+	for _, tag := range article.Tags {
+		_, err := tx.Exec(query, article.ID, tag.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = enqueue(tx, r.tenantID, models.NewOutboxEntry(models.OutboxArticlesChanged, strconv.Itoa(article.ID)))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *SQLliteArticleRepository) Delete(id int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM articles WHERE id = ? AND tenant_id = ?`, id, r.tenantID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	_, err = tx.Exec(`DELETE FROM article_tags WHERE article_id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	err = enqueue(tx, r.tenantID, models.NewOutboxEntry(models.OutboxArticlesDeleted, strconv.Itoa(id)))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *SQLliteArticleRepository) FindById(id int) (*models.Article, error) {
+	query := `
+		SELECT
+			a.id,
+			a.title,
+			a.body,
+			a.author_id,
+			au.name,
+			a.created_at
+		FROM articles a
+		JOIN authors au ON au.tenant_id = a.tenant_id AND au.id = a.author_id
+		WHERE a.id = ? AND a.tenant_id = ?
+	`
+	row := r.db.QueryRow(query, id, r.tenantID)
+
+	var article models.Article
+	err := row.Scan(
+		&article.ID, &article.Title, &article.Body,
+		&article.AuthorID, &article.Author, &article.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	articles := []*models.Article{&article}
+	if err := r.attachTags(articles); err != nil {
+		return nil, err
+	}
+
+	return &article, nil
+}
+
+func (r *SQLliteArticleRepository) FindAll(limit, offset int) ([]*models.Article, error) {
+	query := `
+		SELECT
+			a.id,
+			a.title,
+			a.body,
+			a.author_id,
+			au.name,
+			a.created_at
+		FROM articles a
+		JOIN authors au ON au.tenant_id = a.tenant_id AND au.id = a.author_id
+		WHERE a.tenant_id = ?
+		ORDER BY a.id
+		LIMIT ? OFFSET ?
+	`
+
+	return r.query(query, r.tenantID, limit, offset)
+}
+
+// FindAfter pages through the articles by id: it returns the first articles
+// with an id greater than afterID. Unlike an offset, the id skips no article
+// when earlier ones are deleted in the meantime.
+func (r *SQLliteArticleRepository) FindAfter(afterID, limit int) ([]*models.Article, error) {
+	query := `
+		SELECT
+			a.id,
+			a.title,
+			a.body,
+			a.author_id,
+			au.name,
+			a.created_at
+		FROM articles a
+		JOIN authors au ON au.tenant_id = a.tenant_id AND au.id = a.author_id
+		WHERE a.tenant_id = ? AND a.id > ?
+		ORDER BY a.id
+		LIMIT ?
+	`
+
+	return r.query(query, r.tenantID, afterID, limit)
+}
+
+func (r *SQLliteArticleRepository) query(query string, args ...interface{}) ([]*models.Article, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	articles := []*models.Article{}
+	for rows.Next() {
+		var article models.Article
+		err := rows.Scan(
+			&article.ID, &article.Title, &article.Body,
+			&article.AuthorID, &article.Author, &article.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		articles = append(articles, &article)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.attachTags(articles); err != nil {
+		return nil, err
+	}
+
+	return articles, nil
+}
+
+func (r *SQLliteArticleRepository) Count() (int, error) {
+	var total int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM articles WHERE tenant_id = ?`, r.tenantID).Scan(&total)
+	return total, err
+}
+
+// attachTags loads the tags of all given articles with a single query.
+func (r *SQLliteArticleRepository) attachTags(articles []*models.Article) error {
+	if len(articles) == 0 {
+		return nil
+	}
+
+	byID := make(map[int]*models.Article, len(articles))
+	args := make([]interface{}, len(articles))
+	for i, article := range articles {
+		article.Tags = []*models.Tag{}
+		byID[article.ID] = article
+		args[i] = article.ID
+	}
+
+	placeholders := strings.Repeat("?,", len(articles)-1) + "?"
+
+	query := fmt.Sprintf(`
+		SELECT at.article_id, t.id, t.label, t.created_at, t.updated_at
+		FROM article_tags at
+		JOIN tags t ON at.tag_id = t.id
+		WHERE at.article_id IN (%s)
+	`, placeholders)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			articleID int
+			tag       models.Tag
+		)
+		err := rows.Scan(&articleID, &tag.ID, &tag.Label, &tag.CreatedAt, &tag.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		byID[articleID].Tags = append(byID[articleID].Tags, &tag)
+	}
+
+	return rows.Err()
+}
+
 type SQLliteTagsRepository struct {
-	db *sql.DB
+	db       *sql.DB
+	tenantID int
 }
 
 func NewSQLliteTagsRepository(db *sql.DB) *SQLliteTagsRepository {
 	return &SQLliteTagsRepository{db: db}
 }
 
+func (r *SQLliteTagsRepository) ForTenant(tenantID int) models.TagsRepository {
+	return &SQLliteTagsRepository{db: r.db, tenantID: tenantID}
+}
+
 func (r *SQLliteTagsRepository) Save(tag *models.Tag) (int, error) {
 	query := `
-		INSERT INTO tags (label, updated_at, created_at)
-		VALUES (?, ?, ?)
-		ON CONFLICT(label) DO UPDATE SET
+		INSERT INTO tags (tenant_id, label, updated_at, created_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(tenant_id, label) DO UPDATE SET
 			label = ?,
-			updated_at = ?;
+			updated_at = ?
+		RETURNING id;
 	`
 
-	result, err := r.db.Exec(query,
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(query,
+		r.tenantID,
 		tag.Label,
 		tag.UpdatedAt,
 		tag.CreatedAt,
 		tag.Label,
 		tag.UpdatedAt,
-	)
+	).Scan(&id)
 	if err != nil {
 		return 0, err
 	}
 
-	id, err := result.LastInsertId()
+	err = enqueue(tx, r.tenantID, models.NewOutboxEntry(models.OutboxTagChanged, strconv.Itoa(id)))
 	if err != nil {
 		return 0, err
 	}
 
-	return int(id), err
+	return id, tx.Commit()
 }
 
 func (r *SQLliteTagsRepository) FindByLabel(label string) (*models.Tag, error) {
 	query := `
 		SELECT id, label, created_at, updated_at
 		FROM tags
-		WHERE label = ?
+		WHERE label = ? AND tenant_id = ?
 	`
-	row := r.db.QueryRow(query, label)
+	row := r.db.QueryRow(query, label, r.tenantID)
 
 	var tag models.Tag
 	err := row.Scan(&tag.ID, &tag.Label, &tag.CreatedAt, &tag.UpdatedAt)
@@ -260,12 +558,13 @@ func (r *SQLliteTagsRepository) FindByLabels(labels []string) ([]*models.Tag, er
 	query := fmt.Sprintf(`
 		SELECT id, label, created_at, updated_at
 		FROM tags
-		WHERE label IN (%s)
+		WHERE tenant_id = ? AND label IN (%s)
 	`, placeholders)
 
-	args := make([]interface{}, len(labels))
-	for i, label := range labels {
-		args[i] = label
+	args := make([]interface{}, 0, len(labels)+1)
+	args = append(args, r.tenantID)
+	for _, label := range labels {
+		args = append(args, label)
 	}
 
 	rows, err := r.db.Query(query, args...)
@@ -291,9 +590,9 @@ func (r *SQLliteTagsRepository) FindById(id int) (*models.Tag, error) {
 	query := `
 		SELECT id, label, created_at, updated_at
 		FROM tags
-		WHERE id = ?
+		WHERE id = ? AND tenant_id = ?
 	`
-	row := r.db.QueryRow(query, id)
+	row := r.db.QueryRow(query, id, r.tenantID)
 
 	var tag models.Tag
 	err := row.Scan(&tag.ID, &tag.Label, &tag.CreatedAt, &tag.UpdatedAt)
@@ -308,8 +607,9 @@ func (r *SQLliteTagsRepository) FindAll() ([]*models.Tag, error) {
 	query := `
 		SELECT id, label, created_at, updated_at
 		FROM tags
+		WHERE tenant_id = ?
 	`
-	rows, err := r.db.Query(query)
+	rows, err := r.db.Query(query, r.tenantID)
 	if err != nil {
 		return nil, err
 	}
@@ -327,3 +627,46 @@ func (r *SQLliteTagsRepository) FindAll() ([]*models.Tag, error) {
 
 	return tags, nil
 }
+
+func (r *SQLliteTagsRepository) FindPopularByPrefix(prefix string, limit int) ([]*models.Suggestion, error) {
+	query := `
+		SELECT t.label, COUNT(at.article_id) AS articles
+		FROM tags t
+		LEFT JOIN article_tags at ON at.tag_id = t.id
+		WHERE t.tenant_id = ? AND (LOWER(t.label) LIKE ? ESCAPE '\' OR LOWER(t.label) LIKE ? ESCAPE '\')
+		GROUP BY t.id
+		ORDER BY articles DESC, t.label
+		LIMIT ?
+	`
+	start, word := prefixPatterns(prefix)
+
+	return querySuggestions(r.db, query, r.tenantID, start, word, limit)
+}
+
+// prefixPatterns returns the LIKE patterns matching text starting with the
+// prefix and text with a later word starting with it.
+func prefixPatterns(prefix string) (string, string) {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.ToLower(prefix))
+	return escaped + "%", "% " + escaped + "%"
+}
+
+// querySuggestions scans rows of suggested texts and their number of
+// articles.
+func querySuggestions(db *sql.DB, query string, args ...interface{}) ([]*models.Suggestion, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suggestions := []*models.Suggestion{}
+	for rows.Next() {
+		var suggestion models.Suggestion
+		if err := rows.Scan(&suggestion.Text, &suggestion.Count); err != nil {
+			return nil, err
+		}
+		suggestions = append(suggestions, &suggestion)
+	}
+
+	return suggestions, rows.Err()
+}
@@ -0,0 +1,285 @@
+package adapters
+
+import (
+	"database/sql"
+	"fmt"
+	"mini-search-platform/internal/models"
+	"strings"
+)
+
+type SQLliteCataloguesRepository struct {
+	db       *sql.DB
+	tenantID int
+}
+
+func NewSQLliteCataloguesRepository(db *sql.DB) *SQLliteCataloguesRepository {
+	return &SQLliteCataloguesRepository{db: db}
+}
+
+func (r *SQLliteCataloguesRepository) ForTenant(tenantID int) models.CataloguesRepository {
+	return &SQLliteCataloguesRepository{db: r.db, tenantID: tenantID}
+}
+
+func (r *SQLliteCataloguesRepository) Save(catalogue *models.Catalogue) (int, error) {
+	query := `
+		INSERT INTO catalogues (tenant_id, code, language, name, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query,
+		r.tenantID,
+		catalogue.Code,
+		catalogue.Language,
+		catalogue.Name,
+		catalogue.CreatedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	entry := models.NewOutboxEntry(models.OutboxCatalogueCreated)
+	entry.CatalogueID = int(id)
+	if err := enqueue(tx, r.tenantID, entry); err != nil {
+		return 0, err
+	}
+
+	return int(id), tx.Commit()
+}
+
+func (r *SQLliteCataloguesRepository) FindById(id int) (*models.Catalogue, error) {
+	query := `
+		SELECT id, code, language, name, created_at
+		FROM catalogues
+		WHERE id = ? AND tenant_id = ?
+	`
+	row := r.db.QueryRow(query, id, r.tenantID)
+
+	var catalogue models.Catalogue
+	err := row.Scan(&catalogue.ID, &catalogue.Code, &catalogue.Language, &catalogue.Name, &catalogue.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &catalogue, nil
+}
+
+func (r *SQLliteCataloguesRepository) FindByCode(code string) (*models.Catalogue, error) {
+	query := `
+		SELECT id, code, language, name, created_at
+		FROM catalogues
+		WHERE code = ? AND tenant_id = ?
+	`
+	row := r.db.QueryRow(query, code, r.tenantID)
+
+	var catalogue models.Catalogue
+	err := row.Scan(&catalogue.ID, &catalogue.Code, &catalogue.Language, &catalogue.Name, &catalogue.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &catalogue, nil
+}
+
+func (r *SQLliteCataloguesRepository) FindAll() ([]*models.Catalogue, error) {
+	query := `
+		SELECT id, code, language, name, created_at
+		FROM catalogues
+		WHERE tenant_id = ?
+		ORDER BY code
+	`
+	rows, err := r.db.Query(query, r.tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	catalogues := []*models.Catalogue{}
+	for rows.Next() {
+		var catalogue models.Catalogue
+		err := rows.Scan(&catalogue.ID, &catalogue.Code, &catalogue.Language, &catalogue.Name, &catalogue.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		catalogues = append(catalogues, &catalogue)
+	}
+
+	return catalogues, rows.Err()
+}
+
+type SQLliteTranslationsRepository struct {
+	db       *sql.DB
+	tenantID int
+}
+
+func NewSQLliteTranslationsRepository(db *sql.DB) *SQLliteTranslationsRepository {
+	return &SQLliteTranslationsRepository{db: db}
+}
+
+func (r *SQLliteTranslationsRepository) ForTenant(tenantID int) models.TranslationsRepository {
+	return &SQLliteTranslationsRepository{db: r.db, tenantID: tenantID}
+}
+
+func (r *SQLliteTranslationsRepository) Save(translation *models.Translation) error {
+	query := `
+		INSERT INTO translations (
+			tenant_id,
+			catalogue_id,
+			entity_type,
+			entity_id,
+			title,
+			body,
+			source,
+			status,
+			attempts,
+			last_error,
+			updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(catalogue_id, entity_type, entity_id) DO UPDATE SET
+			title = excluded.title,
+			body = excluded.body,
+			source = excluded.source,
+			status = excluded.status,
+			attempts = excluded.attempts,
+			last_error = excluded.last_error,
+			updated_at = excluded.updated_at
+		WHERE translations.tenant_id = excluded.tenant_id
+			AND (translations.source = 'machine' OR excluded.source = 'manual')
+	`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(query,
+		r.tenantID,
+		translation.CatalogueID,
+		translation.EntityType,
+		translation.EntityID,
+		translation.Title,
+		translation.Body,
+		translation.Source,
+		translation.Status,
+		translation.Attempts,
+		translation.LastError,
+		translation.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if translation.IsTranslated() {
+		operation := models.OutboxArticleTranslationChanged
+		if translation.EntityType == models.EntityProduct {
+			operation = models.OutboxProductTranslationChanged
+		}
+		entry := models.NewOutboxEntry(operation, translation.EntityID)
+		entry.CatalogueID = translation.CatalogueID
+		if err := enqueue(tx, r.tenantID, entry); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *SQLliteTranslationsRepository) Find(catalogueID int, entityType, entityID string) (*models.Translation, error) {
+	query := `
+		SELECT catalogue_id, entity_type, entity_id, title, body, source, status, attempts, last_error, updated_at
+		FROM translations
+		WHERE tenant_id = ? AND catalogue_id = ? AND entity_type = ? AND entity_id = ?
+	`
+	row := r.db.QueryRow(query, r.tenantID, catalogueID, entityType, entityID)
+
+	return scanTranslation(row)
+}
+
+// FindByEntities loads the translations of several entities at once, keyed
+// by entity id. Entities without a translation are missing from the map.
+func (r *SQLliteTranslationsRepository) FindByEntities(catalogueID int, entityType string, entityIDs []string) (map[string]*models.Translation, error) {
+	translations := make(map[string]*models.Translation)
+	if len(entityIDs) == 0 {
+		return translations, nil
+	}
+
+	placeholders := strings.Repeat("?,", len(entityIDs)-1) + "?"
+
+	query := fmt.Sprintf(`
+		SELECT catalogue_id, entity_type, entity_id, title, body, source, status, attempts, last_error, updated_at
+		FROM translations
+		WHERE tenant_id = ? AND catalogue_id = ? AND entity_type = ? AND entity_id IN (%s)
+	`, placeholders)
+
+	args := make([]interface{}, 0, len(entityIDs)+3)
+	args = append(args, r.tenantID, catalogueID, entityType)
+	for _, entityID := range entityIDs {
+		args = append(args, entityID)
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		translation, err := scanTranslation(rows)
+		if err != nil {
+			return nil, err
+		}
+		translations[translation.EntityID] = translation
+	}
+
+	return translations, rows.Err()
+}
+
+func (r *SQLliteTranslationsRepository) FindByStatus(catalogueID int, status string) ([]*models.Translation, error) {
+	query := `
+		SELECT catalogue_id, entity_type, entity_id, title, body, source, status, attempts, last_error, updated_at
+		FROM translations
+		WHERE tenant_id = ? AND catalogue_id = ? AND status = ?
+		ORDER BY entity_type, entity_id
+	`
+	rows, err := r.db.Query(query, r.tenantID, catalogueID, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	translations := []*models.Translation{}
+	for rows.Next() {
+		translation, err := scanTranslation(rows)
+		if err != nil {
+			return nil, err
+		}
+		translations = append(translations, translation)
+	}
+
+	return translations, rows.Err()
+}
+
+func scanTranslation(row interface{ Scan(...interface{}) error }) (*models.Translation, error) {
+	var translation models.Translation
+	err := row.Scan(
+		&translation.CatalogueID, &translation.EntityType, &translation.EntityID,
+		&translation.Title, &translation.Body, &translation.Source,
+		&translation.Status, &translation.Attempts, &translation.LastError, &translation.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &translation, nil
+}
@@ -0,0 +1,209 @@
+package adapters
+
+import (
+	"database/sql"
+	"encoding/json"
+	"mini-search-platform/internal/models"
+	"time"
+)
+
+type SQLliteMerchandisingRulesRepository struct {
+	db       *sql.DB
+	tenantID int
+}
+
+func NewSQLliteMerchandisingRulesRepository(db *sql.DB) *SQLliteMerchandisingRulesRepository {
+	return &SQLliteMerchandisingRulesRepository{db: db}
+}
+
+func (r *SQLliteMerchandisingRulesRepository) ForTenant(tenantID int) models.MerchandisingRulesRepository {
+	return &SQLliteMerchandisingRulesRepository{db: r.db, tenantID: tenantID}
+}
+
+func (r *SQLliteMerchandisingRulesRepository) Save(rule *models.MerchandisingRule) (int, error) {
+	pinned, buried, err := encodeMerchandising(rule)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+		INSERT INTO merchandising_rules (
+			tenant_id,
+			index_name,
+			match,
+			query,
+			pinned,
+			buried,
+			starts_at,
+			ends_at,
+			created_at,
+			updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := r.db.Exec(query,
+		r.tenantID,
+		rule.Index,
+		rule.Match,
+		rule.Query,
+		pinned,
+		buried,
+		rule.StartsAt,
+		rule.EndsAt,
+		rule.CreatedAt,
+		rule.UpdatedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+func (r *SQLliteMerchandisingRulesRepository) Update(rule *models.MerchandisingRule) error {
+	pinned, buried, err := encodeMerchandising(rule)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE merchandising_rules
+		SET match = ?, query = ?, pinned = ?, buried = ?, starts_at = ?, ends_at = ?, updated_at = ?
+		WHERE id = ? AND tenant_id = ?
+	`
+	result, err := r.db.Exec(query,
+		rule.Match,
+		rule.Query,
+		pinned,
+		buried,
+		rule.StartsAt,
+		rule.EndsAt,
+		rule.UpdatedAt,
+		rule.ID,
+		r.tenantID,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *SQLliteMerchandisingRulesRepository) Delete(id int) error {
+	result, err := r.db.Exec(`DELETE FROM merchandising_rules WHERE id = ? AND tenant_id = ?`, id, r.tenantID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *SQLliteMerchandisingRulesRepository) FindById(id int) (*models.MerchandisingRule, error) {
+	query := `
+		SELECT id, index_name, match, query, pinned, buried, starts_at, ends_at, created_at, updated_at
+		FROM merchandising_rules
+		WHERE id = ? AND tenant_id = ?
+	`
+
+	return scanMerchandisingRule(r.db.QueryRow(query, id, r.tenantID))
+}
+
+func (r *SQLliteMerchandisingRulesRepository) FindByIndex(index string) ([]*models.MerchandisingRule, error) {
+	query := `
+		SELECT id, index_name, match, query, pinned, buried, starts_at, ends_at, created_at, updated_at
+		FROM merchandising_rules
+		WHERE tenant_id = ? AND index_name = ?
+		ORDER BY id
+	`
+
+	return r.query(query, r.tenantID, index)
+}
+
+// FindActive compares the campaign bounds as text, which works because
+// they are stored as RFC 3339 UTC times.
+func (r *SQLliteMerchandisingRulesRepository) FindActive(index string, at time.Time) ([]*models.MerchandisingRule, error) {
+	query := `
+		SELECT id, index_name, match, query, pinned, buried, starts_at, ends_at, created_at, updated_at
+		FROM merchandising_rules
+		WHERE tenant_id = ? AND index_name = ?
+			AND (starts_at = '' OR starts_at <= ?)
+			AND (ends_at = '' OR ends_at > ?)
+		ORDER BY id
+	`
+	now := at.UTC().Format(time.RFC3339)
+
+	return r.query(query, r.tenantID, index, now, now)
+}
+
+func (r *SQLliteMerchandisingRulesRepository) query(query string, args ...interface{}) ([]*models.MerchandisingRule, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []*models.MerchandisingRule{}
+	for rows.Next() {
+		rule, err := scanMerchandisingRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+func encodeMerchandising(rule *models.MerchandisingRule) (string, string, error) {
+	pinned, err := json.Marshal(rule.Pinned)
+	if err != nil {
+		return "", "", err
+	}
+	buried, err := json.Marshal(rule.Buried)
+	if err != nil {
+		return "", "", err
+	}
+
+	return string(pinned), string(buried), nil
+}
+
+func scanMerchandisingRule(row interface{ Scan(...interface{}) error }) (*models.MerchandisingRule, error) {
+	var rule models.MerchandisingRule
+	var pinned, buried string
+	err := row.Scan(
+		&rule.ID, &rule.Index, &rule.Match, &rule.Query, &pinned, &buried,
+		&rule.StartsAt, &rule.EndsAt, &rule.CreatedAt, &rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(pinned), &rule.Pinned); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(buried), &rule.Buried); err != nil {
+		return nil, err
+	}
+
+	return &rule, nil
+}
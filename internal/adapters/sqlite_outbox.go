@@ -0,0 +1,163 @@
+package adapters
+
+import (
+	"database/sql"
+	"encoding/json"
+	"mini-search-platform/internal/models"
+	"time"
+)
+
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// enqueue writes an index outbox entry. Repositories call it with the
+// transaction of the change so that both are committed together.
+func enqueue(tx execer, tenantID int, entry *models.OutboxEntry) error {
+	entityIDs, err := json.Marshal(entry.EntityIDs)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO index_outbox (
+			tenant_id,
+			operation,
+			catalogue_id,
+			entity_ids,
+			status,
+			attempts,
+			available_at,
+			created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err = tx.Exec(query,
+		tenantID,
+		entry.Operation,
+		entry.CatalogueID,
+		string(entityIDs),
+		entry.Status,
+		entry.Attempts,
+		entry.AvailableAt,
+		entry.CreatedAt,
+	)
+
+	return err
+}
+
+type SQLliteOutboxRepository struct {
+	db       *sql.DB
+	tenantID int
+}
+
+func NewSQLliteOutboxRepository(db *sql.DB) *SQLliteOutboxRepository {
+	return &SQLliteOutboxRepository{db: db}
+}
+
+func (r *SQLliteOutboxRepository) ForTenant(tenantID int) models.OutboxRepository {
+	return &SQLliteOutboxRepository{db: r.db, tenantID: tenantID}
+}
+
+func (r *SQLliteOutboxRepository) FindDue(now time.Time, limit int) ([]*models.OutboxEntry, error) {
+	query := `
+		SELECT id, tenant_id, operation, catalogue_id, entity_ids, status, attempts, last_error, available_at, created_at
+		FROM index_outbox
+		WHERE status = ? AND available_at <= ?
+		ORDER BY id
+		LIMIT ?
+	`
+
+	return r.query(query, models.OutboxPending, now.UTC().Format(time.RFC3339), limit)
+}
+
+func (r *SQLliteOutboxRepository) FindByStatus(status string) ([]*models.OutboxEntry, error) {
+	query := `
+		SELECT id, tenant_id, operation, catalogue_id, entity_ids, status, attempts, last_error, available_at, created_at
+		FROM index_outbox
+		WHERE tenant_id = ? AND status = ?
+		ORDER BY id
+	`
+
+	return r.query(query, r.tenantID, status)
+}
+
+func (r *SQLliteOutboxRepository) Complete(id int) error {
+	_, err := r.db.Exec(`DELETE FROM index_outbox WHERE id = ?`, id)
+	return err
+}
+
+func (r *SQLliteOutboxRepository) Fail(entry *models.OutboxEntry, lastError string, retryAt time.Time, dead bool) error {
+	entry.Attempts++
+	entry.LastError = lastError
+	entry.AvailableAt = retryAt.UTC().Format(time.RFC3339)
+	if dead {
+		entry.Status = models.OutboxDead
+	}
+
+	query := `
+		UPDATE index_outbox
+		SET status = ?, attempts = ?, last_error = ?, available_at = ?
+		WHERE id = ?
+	`
+
+	_, err := r.db.Exec(query, entry.Status, entry.Attempts, entry.LastError, entry.AvailableAt, entry.ID)
+	return err
+}
+
+func (r *SQLliteOutboxRepository) Requeue(id int) error {
+	query := `
+		UPDATE index_outbox
+		SET status = ?, attempts = 0, available_at = ?
+		WHERE id = ? AND tenant_id = ? AND status = ?
+	`
+
+	result, err := r.db.Exec(query,
+		models.OutboxPending,
+		time.Now().UTC().Format(time.RFC3339),
+		id,
+		r.tenantID,
+		models.OutboxDead,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *SQLliteOutboxRepository) query(query string, args ...interface{}) ([]*models.OutboxEntry, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*models.OutboxEntry{}
+	for rows.Next() {
+		var entry models.OutboxEntry
+		var entityIDs string
+		err := rows.Scan(
+			&entry.ID, &entry.TenantID, &entry.Operation, &entry.CatalogueID, &entityIDs,
+			&entry.Status, &entry.Attempts, &entry.LastError, &entry.AvailableAt, &entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(entityIDs), &entry.EntityIDs); err != nil {
+			return nil, err
+		}
+		entries = append(entries, &entry)
+	}
+
+	return entries, rows.Err()
+}
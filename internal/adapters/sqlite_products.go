@@ -0,0 +1,489 @@
+package adapters
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"mini-search-platform/internal/models"
+	"strings"
+)
+
+type SQLliteProductsRepository struct {
+	db       *sql.DB
+	tenantID int
+}
+
+func NewSQLliteProductsRepository(db *sql.DB) *SQLliteProductsRepository {
+	return &SQLliteProductsRepository{db: db}
+}
+
+func (r *SQLliteProductsRepository) ForTenant(tenantID int) models.ProductRepository {
+	return &SQLliteProductsRepository{db: r.db, tenantID: tenantID}
+}
+
+func (r *SQLliteProductsRepository) Save(product *models.Product) error {
+	query := `
+		INSERT INTO products (
+			tenant_id,
+			article_id,
+			title,
+			brand,
+			category,
+			created_at,
+			updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(query,
+		r.tenantID,
+		product.ArticleID,
+		product.Title,
+		product.Brand,
+		product.Category,
+		product.CreatedAt,
+		product.UpdatedAt,
+	)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("product '%s': %w", product.ArticleID, models.ErrAlreadyExists)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = enqueue(tx, r.tenantID, models.NewOutboxEntry(models.OutboxProductsChanged, product.ArticleID))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *SQLliteProductsRepository) Update(product *models.Product) error {
+	query := `
+		UPDATE products
+		SET title = ?, brand = ?, category = ?, updated_at = ?
+		WHERE article_id = ? AND tenant_id = ?
+	`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query,
+		product.Title,
+		product.Brand,
+		product.Category,
+		product.UpdatedAt,
+		product.ArticleID,
+		r.tenantID,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	err = enqueue(tx, r.tenantID, models.NewOutboxEntry(models.OutboxProductsChanged, product.ArticleID))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *SQLliteProductsRepository) Delete(articleID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`DELETE FROM variants WHERE article_id = ? AND tenant_id = ?`, articleID, r.tenantID)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`DELETE FROM products WHERE article_id = ? AND tenant_id = ?`, articleID, r.tenantID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	err = enqueue(tx, r.tenantID, models.NewOutboxEntry(models.OutboxProductsDeleted, articleID))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *SQLliteProductsRepository) FindById(articleID string) (*models.Product, error) {
+	query := `
+		SELECT article_id, title, brand, category, created_at, updated_at, indexed_facet_version
+		FROM products
+		WHERE article_id = ? AND tenant_id = ?
+	`
+	row := r.db.QueryRow(query, articleID, r.tenantID)
+
+	var product models.Product
+	err := row.Scan(
+		&product.ArticleID, &product.Title, &product.Brand,
+		&product.Category, &product.CreatedAt, &product.UpdatedAt,
+		&product.IndexedFacetVersion,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &product, nil
+}
+
+func (r *SQLliteProductsRepository) FindAll(limit, offset int) ([]*models.Product, error) {
+	query := `
+		SELECT article_id, title, brand, category, created_at, updated_at, indexed_facet_version
+		FROM products
+		WHERE tenant_id = ?
+		ORDER BY article_id
+		LIMIT ? OFFSET ?
+	`
+
+	return r.query(query, r.tenantID, limit, offset)
+}
+
+// FindAfter pages through the products by article id: it returns the first
+// products with an article id greater than afterArticleID. Unlike an offset,
+// the id skips no product when earlier ones are deleted in the meantime.
+func (r *SQLliteProductsRepository) FindAfter(afterArticleID string, limit int) ([]*models.Product, error) {
+	query := `
+		SELECT article_id, title, brand, category, created_at, updated_at, indexed_facet_version
+		FROM products
+		WHERE tenant_id = ? AND article_id > ?
+		ORDER BY article_id
+		LIMIT ?
+	`
+
+	return r.query(query, r.tenantID, afterArticleID, limit)
+}
+
+func (r *SQLliteProductsRepository) query(query string, args ...interface{}) ([]*models.Product, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []*models.Product{}
+	for rows.Next() {
+		var product models.Product
+		err := rows.Scan(
+			&product.ArticleID, &product.Title, &product.Brand,
+			&product.Category, &product.CreatedAt, &product.UpdatedAt,
+			&product.IndexedFacetVersion,
+		)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, &product)
+	}
+
+	return products, rows.Err()
+}
+
+func (r *SQLliteProductsRepository) Count() (int, error) {
+	var total int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM products WHERE tenant_id = ?`, r.tenantID).Scan(&total)
+	return total, err
+}
+
+// FindIndexedFacets returns the facet data the product was last indexed
+// with, or nil if the product was never indexed, and its version.
+func (r *SQLliteProductsRepository) FindIndexedFacets(articleID string) (*models.FacetData, int, error) {
+	query := `
+		SELECT indexed_facet_data, indexed_facet_version
+		FROM products
+		WHERE article_id = ? AND tenant_id = ?
+	`
+
+	var raw sql.NullString
+	var version int
+	if err := r.db.QueryRow(query, articleID, r.tenantID).Scan(&raw, &version); err != nil {
+		return nil, 0, err
+	}
+	if !raw.Valid {
+		return nil, version, nil
+	}
+
+	var facets models.FacetData
+	if err := json.Unmarshal([]byte(raw.String), &facets); err != nil {
+		return nil, 0, err
+	}
+
+	return &facets, version, nil
+}
+
+// SaveIndexedFacets is a compare-and-set on the version, which it bumps, so
+// that concurrent syncs cannot record a stale aggregate unnoticed.
+func (r *SQLliteProductsRepository) SaveIndexedFacets(articleID string, facets models.FacetData, version int) (bool, error) {
+	raw, err := json.Marshal(facets)
+	if err != nil {
+		return false, err
+	}
+
+	query := `
+		UPDATE products
+		SET indexed_facet_data = ?, indexed_facet_version = indexed_facet_version + 1
+		WHERE article_id = ? AND tenant_id = ? AND indexed_facet_version = ?
+	`
+
+	result, err := r.db.Exec(query, string(raw), articleID, r.tenantID, version)
+	if err != nil {
+		return false, err
+	}
+	saved, err := result.RowsAffected()
+
+	return saved == 1, err
+}
+
+// ClearIndexedFacets bumps the versions too, so that syncs running at the
+// same time record their facet data again.
+func (r *SQLliteProductsRepository) ClearIndexedFacets() error {
+	query := `
+		UPDATE products
+		SET indexed_facet_data = NULL, indexed_facet_version = indexed_facet_version + 1
+		WHERE tenant_id = ?
+	`
+
+	_, err := r.db.Exec(query, r.tenantID)
+	return err
+}
+
+type SQLliteVariantsRepository struct {
+	db       *sql.DB
+	tenantID int
+}
+
+func NewSQLliteVariantsRepository(db *sql.DB) *SQLliteVariantsRepository {
+	return &SQLliteVariantsRepository{db: db}
+}
+
+func (r *SQLliteVariantsRepository) ForTenant(tenantID int) models.VariantRepository {
+	return &SQLliteVariantsRepository{db: r.db, tenantID: tenantID}
+}
+
+func (r *SQLliteVariantsRepository) Save(variant *models.Variant) error {
+	query := `
+		INSERT INTO variants (
+			tenant_id,
+			variant_id,
+			article_id,
+			size,
+			color,
+			price,
+			availability,
+			updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(query,
+		r.tenantID,
+		variant.VariantID,
+		variant.ArticleID,
+		variant.Size,
+		variant.Color,
+		variant.Price,
+		variant.Availability,
+		variant.UpdatedAt,
+	)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("variant '%s': %w", variant.VariantID, models.ErrAlreadyExists)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = enqueue(tx, r.tenantID, models.NewOutboxEntry(models.OutboxProductFacetsChanged, variant.ArticleID))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *SQLliteVariantsRepository) Update(variant *models.Variant) error {
+	query := `
+		UPDATE variants
+		SET size = ?, color = ?, price = ?, availability = ?, updated_at = ?
+		WHERE variant_id = ? AND tenant_id = ?
+		RETURNING article_id
+	`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var articleID string
+	err = tx.QueryRow(query,
+		variant.Size,
+		variant.Color,
+		variant.Price,
+		variant.Availability,
+		variant.UpdatedAt,
+		variant.VariantID,
+		r.tenantID,
+	).Scan(&articleID)
+	if err != nil {
+		return err
+	}
+
+	err = enqueue(tx, r.tenantID, models.NewOutboxEntry(models.OutboxProductFacetsChanged, articleID))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *SQLliteVariantsRepository) Delete(variantID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var articleID string
+	err = tx.QueryRow(
+		`DELETE FROM variants WHERE variant_id = ? AND tenant_id = ? RETURNING article_id`,
+		variantID, r.tenantID,
+	).Scan(&articleID)
+	if err != nil {
+		return err
+	}
+
+	err = enqueue(tx, r.tenantID, models.NewOutboxEntry(models.OutboxProductFacetsChanged, articleID))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *SQLliteVariantsRepository) FindById(variantID string) (*models.Variant, error) {
+	query := `
+		SELECT variant_id, article_id, size, color, price, availability, updated_at
+		FROM variants
+		WHERE variant_id = ? AND tenant_id = ?
+	`
+	row := r.db.QueryRow(query, variantID, r.tenantID)
+
+	var variant models.Variant
+	err := row.Scan(
+		&variant.VariantID, &variant.ArticleID, &variant.Size, &variant.Color,
+		&variant.Price, &variant.Availability, &variant.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &variant, nil
+}
+
+func (r *SQLliteVariantsRepository) FindByArticleId(articleID string) ([]*models.Variant, error) {
+	query := `
+		SELECT variant_id, article_id, size, color, price, availability, updated_at
+		FROM variants
+		WHERE article_id = ? AND tenant_id = ?
+		ORDER BY variant_id
+	`
+	rows, err := r.db.Query(query, articleID, r.tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	variants := []*models.Variant{}
+	for rows.Next() {
+		var variant models.Variant
+		err := rows.Scan(
+			&variant.VariantID, &variant.ArticleID, &variant.Size, &variant.Color,
+			&variant.Price, &variant.Availability, &variant.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, &variant)
+	}
+
+	return variants, rows.Err()
+}
+
+// FindByArticleIds loads the variants of several products with a single query.
+func (r *SQLliteVariantsRepository) FindByArticleIds(articleIDs []string) ([]*models.Variant, error) {
+	if len(articleIDs) == 0 {
+		return []*models.Variant{}, nil
+	}
+
+	placeholders := strings.Repeat("?,", len(articleIDs)-1) + "?"
+
+	query := fmt.Sprintf(`
+		SELECT variant_id, article_id, size, color, price, availability, updated_at
+		FROM variants
+		WHERE tenant_id = ? AND article_id IN (%s)
+		ORDER BY article_id, variant_id
+	`, placeholders)
+
+	args := make([]interface{}, 0, len(articleIDs)+1)
+	args = append(args, r.tenantID)
+	for _, articleID := range articleIDs {
+		args = append(args, articleID)
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	variants := []*models.Variant{}
+	for rows.Next() {
+		var variant models.Variant
+		err := rows.Scan(
+			&variant.VariantID, &variant.ArticleID, &variant.Size, &variant.Color,
+			&variant.Price, &variant.Availability, &variant.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, &variant)
+	}
+
+	return variants, rows.Err()
+}
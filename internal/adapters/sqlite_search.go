@@ -0,0 +1,654 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"mini-search-platform/internal/models"
+	"mini-search-platform/internal/search"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SQLliteSearchEngine answers searches from FTS5 tables kept in the same
+// SQLite database as the relational tables, so that small tenants need no
+// search service at all. Hits are ranked with bm25(). Filters are applied in
+// SQL, and so is paging unless hits are sorted or facets are counted, which
+// happens in the engine. Filter values are compared case-insensitively for
+// ASCII letters only, and range filters compare numbers with numbers and
+// text with text.
+//
+// FTS5 is only compiled into go-sqlite3 with the sqlite_fts5 build tag.
+type SQLliteSearchEngine struct {
+	db          *sql.DB
+	tenantID    int
+	catalogue   *models.Catalogue
+	articlesUID string
+	productsUID string
+}
+
+// NewSQLliteSearchEngine checks that the search tables were created by the
+// migrations of the database. It fails if they are missing, which is the
+// case if the SQLite driver was built without FTS5.
+func NewSQLliteSearchEngine(db *sql.DB) (*SQLliteSearchEngine, error) {
+	for _, table := range []string{"search_documents", ftsTable(search.ARTICLES_INDEX_NAME), ftsTable(search.PRODUCTS_INDEX_NAME)} {
+		if _, err := db.Exec(fmt.Sprintf(`SELECT 1 FROM %s LIMIT 0`, table)); err != nil {
+			return nil, fmt.Errorf("missing search table %s (is go-sqlite3 built with -tags sqlite_fts5?): %w", table, err)
+		}
+	}
+
+	return newSQLliteSearchEngine(db, 0, nil), nil
+}
+
+func newSQLliteSearchEngine(db *sql.DB, tenantID int, catalogue *models.Catalogue) *SQLliteSearchEngine {
+	return &SQLliteSearchEngine{
+		db:          db,
+		tenantID:    tenantID,
+		catalogue:   catalogue,
+		articlesUID: search.IndexNameFor(tenantID, search.ARTICLES_INDEX_NAME, catalogue),
+		productsUID: search.IndexNameFor(tenantID, search.PRODUCTS_INDEX_NAME, catalogue),
+	}
+}
+
+func ftsTable(base string) string {
+	return "search_" + base + "_fts"
+}
+
+func (e *SQLliteSearchEngine) ForTenant(tenantID int) search.SearchEngine {
+	return newSQLliteSearchEngine(e.db, tenantID, nil)
+}
+
+func (e *SQLliteSearchEngine) ForCatalogue(catalogue *models.Catalogue) search.SearchEngine {
+	return newSQLliteSearchEngine(e.db, e.tenantID, catalogue)
+}
+
+// CreateIndexes does nothing: the indexes of all tenants share the search
+// tables created by the migrations.
+func (e *SQLliteSearchEngine) CreateIndexes() error {
+	return nil
+}
+
+func (e *SQLliteSearchEngine) IndexArticles(articles []*models.Article) error {
+	documents := []interface{}{}
+	for _, document := range search.NewArticleDocuments(articles) {
+		documents = append(documents, document)
+	}
+
+	return e.index(e.articlesUID, search.ARTICLES_INDEX_NAME, documents)
+}
+
+func (e *SQLliteSearchEngine) DeleteArticles(ids []int) error {
+	identifiers := make([]string, len(ids))
+	for i, id := range ids {
+		identifiers[i] = strconv.Itoa(id)
+	}
+
+	return e.delete(e.articlesUID, search.ARTICLES_INDEX_NAME, identifiers)
+}
+
+func (e *SQLliteSearchEngine) IndexProducts(products []*search.ProductDocument) error {
+	documents := make([]interface{}, len(products))
+	for i, product := range products {
+		documents[i] = product
+	}
+
+	return e.index(e.productsUID, search.PRODUCTS_INDEX_NAME, documents)
+}
+
+func (e *SQLliteSearchEngine) DeleteProducts(articleIDs []string) error {
+	return e.delete(e.productsUID, search.PRODUCTS_INDEX_NAME, articleIDs)
+}
+
+// targets returns the index itself plus its staging index while a rebuild
+// of it is running.
+func (e *SQLliteSearchEngine) targets(tx *sql.Tx, uid string) ([]string, error) {
+	var rebuilding int
+	err := tx.QueryRow(`SELECT COUNT(*) FROM search_rebuilds WHERE index_name = ?`, uid+stagingSuffix).Scan(&rebuilding)
+	if err != nil {
+		return nil, err
+	}
+	if rebuilding > 0 {
+		return []string{uid, uid + stagingSuffix}, nil
+	}
+
+	return []string{uid}, nil
+}
+
+// UpdateSettings stores the search settings, which are applied to queries.
+// Documents are indexed without stop words, so they need no reindexing.
+func (e *SQLliteSearchEngine) UpdateSettings(index string, settings *models.SearchSettings) error {
+	uid, err := e.uid(index)
+	if err != nil {
+		return err
+	}
+
+	source, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+
+	tx, err := e.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	targets, err := e.targets(tx, uid)
+	if err != nil {
+		return err
+	}
+
+	for _, target := range targets {
+		_, err := tx.Exec(
+			`INSERT INTO search_index_settings (index_name, settings) VALUES (?, ?)
+			ON CONFLICT(index_name) DO UPDATE SET settings = excluded.settings`,
+			target, string(source),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// indexSettings returns the settings of the index tuned with its search
+// settings.
+func (e *SQLliteSearchEngine) indexSettings(uid, base string) (search.IndexSettings, error) {
+	settings := search.IndexSettingsFor(base, e.catalogue)
+
+	var source string
+	err := e.db.QueryRow(`SELECT settings FROM search_index_settings WHERE index_name = ?`, uid).Scan(&source)
+	if err == sql.ErrNoRows {
+		return settings, nil
+	}
+	if err != nil {
+		return settings, err
+	}
+
+	var custom models.SearchSettings
+	if err := json.Unmarshal([]byte(source), &custom); err != nil {
+		return settings, err
+	}
+
+	return settings.WithSearchSettings(&custom), nil
+}
+
+func (e *SQLliteSearchEngine) index(uid, base string, documents []interface{}) error {
+	settings := search.IndexSettingsFor(base, e.catalogue)
+
+	tx, err := e.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	targets, err := e.targets(tx, uid)
+	if err != nil {
+		return err
+	}
+
+	columns := strings.Join(settings.Searchable, ", ")
+	placeholders := strings.Repeat(", ?", len(settings.Searchable))
+	insert := fmt.Sprintf(`INSERT INTO %s (rowid, index_name, %s) VALUES (?, ?%s)`, ftsTable(base), columns, placeholders)
+
+	for _, document := range documents {
+		source, fields, text, err := decodeDocument(document)
+		if err != nil {
+			return err
+		}
+
+		ids := fields[settings.PrimaryKey]
+		if len(ids) != 1 {
+			return fmt.Errorf("document has no primary key '%s'", settings.PrimaryKey)
+		}
+
+		values := []interface{}{}
+		for _, attribute := range settings.Searchable {
+			values = append(values, strings.Join(attributeText(text, attribute), " "))
+		}
+
+		for _, target := range targets {
+			if err := deleteSearchDocuments(tx, base, target, ids); err != nil {
+				return err
+			}
+
+			var rowID int64
+			err := tx.QueryRow(
+				`INSERT INTO search_documents (index_name, document_id, source) VALUES (?, ?, ?) RETURNING id`,
+				target, ids[0], string(source),
+			).Scan(&rowID)
+			if err != nil {
+				return err
+			}
+
+			if _, err := tx.Exec(insert, append([]interface{}{rowID, target}, values...)...); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (e *SQLliteSearchEngine) delete(uid, base string, ids []string) error {
+	tx, err := e.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	targets, err := e.targets(tx, uid)
+	if err != nil {
+		return err
+	}
+
+	for _, target := range targets {
+		if err := deleteSearchDocuments(tx, base, target, ids); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func deleteSearchDocuments(tx *sql.Tx, base, uid string, ids []string) error {
+	for _, id := range ids {
+		var rowID int64
+		err := tx.QueryRow(
+			`DELETE FROM search_documents WHERE index_name = ? AND document_id = ? RETURNING id`,
+			uid, id,
+		).Scan(&rowID)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(fmt.Sprintf(`DELETE FROM %s WHERE rowid = ?`, ftsTable(base)), rowID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// clearSearchIndex deletes every document of the index.
+func clearSearchIndex(tx *sql.Tx, base, uid string) error {
+	_, err := tx.Exec(
+		fmt.Sprintf(`DELETE FROM %s WHERE rowid IN (SELECT id FROM search_documents WHERE index_name = ?)`, ftsTable(base)),
+		uid,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM search_documents WHERE index_name = ?`, uid)
+	return err
+}
+
+type sqliteMatch struct {
+	source  []byte
+	snippet string
+	fields  map[string][]string
+}
+
+// search returns the requested page of matching documents. Every query term
+// has to match, the last one also as a prefix.
+func (e *SQLliteSearchEngine) search(ctx context.Context, uid, base, query string, options search.SearchOptions) (documentPage, error) {
+	settings, err := e.indexSettings(uid, base)
+	if err != nil {
+		return documentPage{}, err
+	}
+
+	keys, err := parseSortKeys(options.Sort, settings.Sortable)
+	if err != nil {
+		return documentPage{}, err
+	}
+
+	filter, filterArgs, err := sqliteFilter(options.Filter, settings.Filterable)
+	if err != nil {
+		return documentPage{}, err
+	}
+
+	facets, err := settings.FacetAttributes(options.Facets)
+	if err != nil {
+		return documentPage{}, err
+	}
+
+	stopWords := stopWordSet(settings.StopWords)
+	terms := tokenize(query, stopWords)
+	prefix := len(terms) > 0 && !strings.HasSuffix(query, " ")
+	expanded := expandQuery(terms, synonymSet(settings.Synonyms, stopWords))
+
+	page := documentPage{terms: highlightTerms(expanded)}
+	if prefix {
+		page.prefix = terms[len(terms)-1]
+	}
+
+	var from, order string
+	var args []interface{}
+	if len(terms) == 0 {
+		from = `FROM search_documents d WHERE d.index_name = ?`
+		order = `ORDER BY d.id`
+		args = []interface{}{uid}
+	} else {
+		// every term has to match one of its alternatives, phrases as a
+		// whole; synonyms are not matched as a prefix
+		expressions := make([]string, len(expanded))
+		for i, term := range expanded {
+			alternatives := make([]string, len(term.alternatives))
+			for j, alternative := range term.alternatives {
+				alternatives[j] = `"` + strings.Join(alternative, " ") + `"`
+				if prefix && i == len(expanded)-1 && j == 0 {
+					alternatives[j] += "*"
+				}
+			}
+			expressions[i] = "(" + strings.Join(alternatives, " OR ") + ")"
+		}
+
+		// earlier searchable attributes weigh more, like in Meilisearch
+		weights := make([]string, len(settings.Searchable))
+		for i := range settings.Searchable {
+			weights[i] = strconv.Itoa(len(settings.Searchable) - i)
+		}
+
+		table := ftsTable(base)
+		from = fmt.Sprintf(`FROM %s f JOIN search_documents d ON d.id = f.rowid WHERE %s MATCH ? AND f.index_name = ?`, table, table)
+		order = fmt.Sprintf(`ORDER BY bm25(%s, %s)`, table, strings.Join(weights, ", "))
+		args = []interface{}{strings.Join(expressions, " "), uid}
+	}
+	from += filter
+	args = append(args, filterArgs...)
+
+	snippet := `''`
+	if len(terms) > 0 {
+		snippet = fmt.Sprintf(`snippet(%s, -1, '<mark>', '</mark>', '…', 16)`, ftsTable(base))
+	}
+	selection := fmt.Sprintf(`SELECT d.source, %s %s %s`, snippet, from, order)
+
+	// without sorting and facets only the page is fetched
+	offset, limit := max(options.Offset, 0), searchLimit(options.Limit)
+	paged := len(keys) == 0 && len(facets) == 0
+	if paged {
+		if err := e.db.QueryRowContext(ctx, `SELECT COUNT(*) `+from, args...).Scan(&page.total); err != nil {
+			return documentPage{}, err
+		}
+		selection += ` LIMIT ? OFFSET ?`
+		args = append(args, limit, offset)
+	}
+
+	rows, err := e.db.QueryContext(ctx, selection, args...)
+	if err != nil {
+		return documentPage{}, err
+	}
+	defer rows.Close()
+
+	results := []sqliteMatch{}
+	for rows.Next() {
+		var match sqliteMatch
+		if err := rows.Scan(&match.source, &match.snippet); err != nil {
+			return documentPage{}, err
+		}
+		results = append(results, match)
+	}
+	if err := rows.Err(); err != nil {
+		return documentPage{}, err
+	}
+
+	if !paged {
+		for i := range results {
+			var value interface{}
+			if err := json.Unmarshal(results[i].source, &value); err != nil {
+				return documentPage{}, err
+			}
+			results[i].fields = map[string][]string{}
+			flattenDocument("", value, results[i].fields, map[string][]string{})
+		}
+
+		// the stable sort keeps the bm25 order between equal sort values
+		sort.SliceStable(results, func(a, b int) bool {
+			for _, key := range keys {
+				if c := compareDocumentValues(results[a].fields[key.attribute], results[b].fields[key.attribute]); c != 0 {
+					return (c < 0) != key.descending
+				}
+			}
+			return false
+		})
+
+		page.total = len(results)
+		fields := make([]map[string][]string, len(results))
+		for i, result := range results {
+			fields[i] = result.fields
+		}
+		page.facets = countFacets(fields, options.Facets, facets)
+
+		offset = min(offset, page.total)
+		results = results[offset:min(offset+limit, page.total)]
+	}
+
+	for _, result := range results {
+		page.sources = append(page.sources, result.source)
+		page.snippets = append(page.snippets, result.snippet)
+	}
+
+	return page, nil
+}
+
+// sqliteFilter compiles the filter into conditions on the documents d,
+// each matching any value of the attribute in the source of the document,
+// rejecting attributes that are not filterable themselves or nested in a
+// filterable attribute.
+func sqliteFilter(filter search.Filter, filterable []string) (string, []interface{}, error) {
+	var where strings.Builder
+	var args []interface{}
+	for _, condition := range filter {
+		if !isFilterable(condition.Attribute, filterable) {
+			return "", nil, fmt.Errorf("attribute '%s' is not filterable", condition.Attribute)
+		}
+
+		// the values of an attribute are the leaves at its path, with any
+		// array along the path
+		paths := []string{"$"}
+		for _, segment := range strings.Split(condition.Attribute, ".") {
+			segment = globEscape(segment)
+			next := make([]string, 0, 2*len(paths))
+			for _, path := range paths {
+				next = append(next, path+"."+segment, path+"."+segment+"[[]*[]]")
+			}
+			paths = next
+		}
+		matches := strings.TrimSuffix(strings.Repeat("t.fullkey GLOB ? OR ", len(paths)), " OR ")
+		for _, path := range paths {
+			args = append(args, path)
+		}
+
+		value := `lower(CASE t.type WHEN 'true' THEN 'true' WHEN 'false' THEN 'false' ELSE CAST(t.atom AS TEXT) END)`
+		var compare string
+		switch condition.Operator {
+		case search.FilterIn:
+			compare = value + ` IN (` + strings.TrimSuffix(strings.Repeat("lower(?), ", len(condition.Values)), ", ") + `)`
+			for _, v := range condition.Values {
+				args = append(args, v)
+			}
+		case search.FilterAtLeast, search.FilterAtMost:
+			if len(condition.Values) != 1 {
+				return "", nil, fmt.Errorf("expected a single value for '%s'", condition.Attribute)
+			}
+			operator := condition.Operator
+			if number, err := strconv.ParseFloat(condition.Values[0], 64); err == nil {
+				compare = `t.type IN ('integer', 'real') AND t.atom ` + operator + ` ?`
+				args = append(args, number)
+			} else {
+				compare = `t.type NOT IN ('integer', 'real') AND ` + value + ` ` + operator + ` lower(?)`
+				args = append(args, condition.Values[0])
+			}
+		default:
+			return "", nil, fmt.Errorf("unsupported filter operator '%s'", condition.Operator)
+		}
+
+		fmt.Fprintf(&where, ` AND EXISTS (SELECT 1 FROM json_tree(d.source) t WHERE t.atom IS NOT NULL AND (%s) AND %s)`, matches, compare)
+	}
+
+	return where.String(), args, nil
+}
+
+// globEscape escapes the characters GLOB treats as wildcards.
+func globEscape(s string) string {
+	return strings.NewReplacer("[", "[[]", "*", "[*]", "?", "[?]").Replace(s)
+}
+
+func (e *SQLliteSearchEngine) Search(query string, options search.SearchOptions) (search.SearchResponse, error) {
+	return e.SearchContext(context.Background(), query, options)
+}
+
+func (e *SQLliteSearchEngine) SearchContext(ctx context.Context, query string, options search.SearchOptions) (search.SearchResponse, error) {
+	page, err := e.search(ctx, e.articlesUID, search.ARTICLES_INDEX_NAME, query, options)
+	if err != nil {
+		return search.SearchResponse{Query: query}, err
+	}
+
+	return page.articles(query, options)
+}
+
+func (e *SQLliteSearchEngine) SearchProducts(query string, options search.SearchOptions) (search.ProductSearchResponse, error) {
+	return e.SearchProductsContext(context.Background(), query, options)
+}
+
+func (e *SQLliteSearchEngine) SearchProductsContext(ctx context.Context, query string, options search.SearchOptions) (search.ProductSearchResponse, error) {
+	page, err := e.search(ctx, e.productsUID, search.PRODUCTS_INDEX_NAME, query, options)
+	if err != nil {
+		return search.ProductSearchResponse{Query: query}, err
+	}
+
+	return page.products(query, options)
+}
+
+func (e *SQLliteSearchEngine) uid(index string) (string, error) {
+	switch index {
+	case search.ARTICLES_INDEX_NAME:
+		return e.articlesUID, nil
+	case search.PRODUCTS_INDEX_NAME:
+		return e.productsUID, nil
+	}
+
+	return "", fmt.Errorf("unknown index '%s'", index)
+}
+
+// BeginRebuild registers the staging index in the search_rebuilds table, so
+// that regular writes of every process reach it too.
+func (e *SQLliteSearchEngine) BeginRebuild(index string) (search.SearchEngine, error) {
+	live, err := e.uid(index)
+	if err != nil {
+		return nil, err
+	}
+	staging := live + stagingSuffix
+
+	tx, err := e.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := clearSearchIndex(tx, index, staging); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`DELETE FROM search_index_settings WHERE index_name = ?`, staging); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`INSERT OR IGNORE INTO search_rebuilds (index_name) VALUES (?)`, staging); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	engine := newSQLliteSearchEngine(e.db, e.tenantID, e.catalogue)
+	if index == search.ARTICLES_INDEX_NAME {
+		engine.articlesUID = staging
+	} else {
+		engine.productsUID = staging
+	}
+
+	return engine, nil
+}
+
+// CommitRebuild replaces the documents of the live index with the staging
+// ones in a single transaction.
+func (e *SQLliteSearchEngine) CommitRebuild(index string) error {
+	live, err := e.uid(index)
+	if err != nil {
+		return err
+	}
+	staging := live + stagingSuffix
+
+	tx, err := e.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM search_rebuilds WHERE index_name = ?`, staging)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return fmt.Errorf("index '%s' is not being rebuilt", live)
+	}
+
+	if err := clearSearchIndex(tx, index, live); err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		fmt.Sprintf(`UPDATE %s SET index_name = ? WHERE rowid IN (SELECT id FROM search_documents WHERE index_name = ?)`, ftsTable(index)),
+		live, staging,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`UPDATE search_documents SET index_name = ? WHERE index_name = ?`, live, staging)
+	if err != nil {
+		return err
+	}
+
+	// the live index takes over the settings of the staging index
+	if _, err := tx.Exec(`DELETE FROM search_index_settings WHERE index_name = ?`, live); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`UPDATE search_index_settings SET index_name = ? WHERE index_name = ?`, live, staging)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (e *SQLliteSearchEngine) AbortRebuild(index string) error {
+	live, err := e.uid(index)
+	if err != nil {
+		return err
+	}
+	staging := live + stagingSuffix
+
+	tx, err := e.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM search_rebuilds WHERE index_name = ?`, staging); err != nil {
+		return err
+	}
+	if err := clearSearchIndex(tx, index, staging); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM search_index_settings WHERE index_name = ?`, staging); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,98 @@
+package adapters
+
+import (
+	"database/sql"
+	"encoding/json"
+	"mini-search-platform/internal/models"
+)
+
+type SQLliteSearchSettingsRepository struct {
+	db       *sql.DB
+	tenantID int
+}
+
+func NewSQLliteSearchSettingsRepository(db *sql.DB) *SQLliteSearchSettingsRepository {
+	return &SQLliteSearchSettingsRepository{db: db}
+}
+
+func (r *SQLliteSearchSettingsRepository) ForTenant(tenantID int) models.SearchSettingsRepository {
+	return &SQLliteSearchSettingsRepository{db: r.db, tenantID: tenantID}
+}
+
+func (r *SQLliteSearchSettingsRepository) FindByIndex(index string) (*models.SearchSettings, error) {
+	query := `
+		SELECT synonyms, stop_words, ranking_rules, updated_at
+		FROM search_settings
+		WHERE tenant_id = ? AND index_name = ?
+	`
+	row := r.db.QueryRow(query, r.tenantID, index)
+
+	var synonyms, stopWords, rankingRules string
+	settings := models.NewSearchSettings(index)
+	err := row.Scan(&synonyms, &stopWords, &rankingRules, &settings.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(synonyms), &settings.Synonyms); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(stopWords), &settings.StopWords); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(rankingRules), &settings.RankingRules); err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+func (r *SQLliteSearchSettingsRepository) Save(settings *models.SearchSettings) error {
+	synonyms, err := json.Marshal(settings.Synonyms)
+	if err != nil {
+		return err
+	}
+	stopWords, err := json.Marshal(settings.StopWords)
+	if err != nil {
+		return err
+	}
+	rankingRules, err := json.Marshal(settings.RankingRules)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO search_settings (tenant_id, index_name, synonyms, stop_words, ranking_rules, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(tenant_id, index_name) DO UPDATE SET
+			synonyms = excluded.synonyms,
+			stop_words = excluded.stop_words,
+			ranking_rules = excluded.ranking_rules,
+			updated_at = excluded.updated_at
+	`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(query,
+		r.tenantID,
+		settings.Index,
+		string(synonyms),
+		string(stopWords),
+		string(rankingRules),
+		settings.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	err = enqueue(tx, r.tenantID, models.NewOutboxEntry(models.OutboxSearchSettingsChanged, settings.Index))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,109 @@
+package adapters
+
+import (
+	"mini-search-platform/internal/database"
+	"mini-search-platform/internal/models"
+	"mini-search-platform/internal/search"
+	"mini-search-platform/pkg/sqlite"
+	"strings"
+	"testing"
+)
+
+// Run with -tags sqlite_fts5, the test is skipped otherwise.
+func TestSQLliteSearchEngine_RanksFiltersAndRebuilds(t *testing.T) {
+	db, err := sqlite.Open(sqlite.MemoryDSN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlite.Close(db)
+
+	if err := database.Create(db); err != nil {
+		t.Fatal(err)
+	}
+
+	base, err := NewSQLliteSearchEngine(db)
+	if err != nil {
+		t.Skip(err)
+	}
+	engine := base.ForTenant(12012)
+
+	err = engine.IndexArticles([]*models.Article{
+		memoryArticle(1, "Summer outfits", "Light denim jackets for the summer", "Jane", "denim", "summer"),
+		memoryArticle(2, "Denim care", "How to wash denim", "John", "denim"),
+		memoryArticle(3, "Winter coats", "Warm coats and a denim scarf", "Jane", "winter"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := engine.Search("denim", search.SearchOptions{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := hitIDs(response); len(ids) != 3 || ids[0] != 2 {
+		t.Errorf("expected the title match 2 to rank first, got %v", ids)
+	}
+	if !strings.Contains(response.Hits[0].Snippet, "<mark>") {
+		t.Errorf("expected a highlighted snippet, got %q", response.Hits[0].Snippet)
+	}
+
+	response, err = engine.Search("jack", search.SearchOptions{Limit: 10, Filter: search.Filter{
+		{Attribute: "author", Operator: search.FilterIn, Values: []string{"Jane"}},
+		{Attribute: "tags.label", Operator: search.FilterIn, Values: []string{"summer"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := hitIDs(response); len(ids) != 1 || ids[0] != 1 {
+		t.Errorf("expected the prefix query with filter to match article 1, got %v", ids)
+	}
+
+	// pages are fetched in SQL, counting every match of the index only
+	response, err = base.ForTenant(12013).Search("denim", search.SearchOptions{Limit: 10})
+	if err != nil || len(response.Hits) != 0 {
+		t.Errorf("expected other tenants to find nothing, got %v, %v", response, err)
+	}
+	response, err = engine.Search("denim", search.SearchOptions{Limit: 1, Offset: 1, Filter: search.Filter{
+		{Attribute: "author", Operator: search.FilterIn, Values: []string{"jane"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := hitIDs(response); len(ids) != 1 || response.Total != 2 {
+		t.Errorf("expected the second of 2 matches by Jane, got %v of %d", ids, response.Total)
+	}
+
+	staging, err := engine.BeginRebuild(search.ARTICLES_INDEX_NAME)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := staging.IndexArticles([]*models.Article{memoryArticle(4, "Denim guide", "", "Jane")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.DeleteArticles([]int{4}); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.IndexArticles([]*models.Article{memoryArticle(5, "Denim trends", "", "Jane")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.CommitRebuild(search.ARTICLES_INDEX_NAME); err != nil {
+		t.Fatal(err)
+	}
+
+	response, err = engine.Search("denim", search.SearchOptions{Limit: 10, Sort: []string{"title:asc"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := hitIDs(response); len(ids) != 1 || ids[0] != 5 {
+		t.Errorf("expected the rebuilt index to hold only the concurrent write 5, got %v", ids)
+	}
+
+	// a negative offset starts at the first hit
+	response, err = engine.Search("denim", search.SearchOptions{Limit: 10, Offset: -3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := hitIDs(response); len(ids) != 1 || ids[0] != 5 {
+		t.Errorf("expected a negative offset to return the first page, got %v", ids)
+	}
+}
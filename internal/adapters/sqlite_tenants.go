@@ -0,0 +1,204 @@
+package adapters
+
+import (
+	"database/sql"
+	"mini-search-platform/internal/models"
+	"strings"
+)
+
+type SQLliteTenantsRepository struct {
+	db *sql.DB
+}
+
+func NewSQLliteTenantsRepository(db *sql.DB) *SQLliteTenantsRepository {
+	return &SQLliteTenantsRepository{db: db}
+}
+
+func (r *SQLliteTenantsRepository) Save(tenant *models.Tenant) (int, error) {
+	query := `
+		INSERT INTO tenants (name, created_at)
+		VALUES (?, ?)
+	`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query, tenant.Name, tenant.CreatedAt)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	err = enqueue(tx, int(id), models.NewOutboxEntry(models.OutboxTenantCreated))
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), tx.Commit()
+}
+
+func (r *SQLliteTenantsRepository) FindById(id int) (*models.Tenant, error) {
+	query := `
+		SELECT id, name, created_at
+		FROM tenants
+		WHERE id = ?
+	`
+	row := r.db.QueryRow(query, id)
+
+	var tenant models.Tenant
+	err := row.Scan(&tenant.ID, &tenant.Name, &tenant.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tenant, nil
+}
+
+func (r *SQLliteTenantsRepository) FindAll() ([]*models.Tenant, error) {
+	query := `
+		SELECT id, name, created_at
+		FROM tenants
+	`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tenants []*models.Tenant
+	for rows.Next() {
+		var tenant models.Tenant
+		err := rows.Scan(&tenant.ID, &tenant.Name, &tenant.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		tenants = append(tenants, &tenant)
+	}
+
+	return tenants, rows.Err()
+}
+
+type SQLliteAPITokensRepository struct {
+	db *sql.DB
+}
+
+func NewSQLliteAPITokensRepository(db *sql.DB) *SQLliteAPITokensRepository {
+	return &SQLliteAPITokensRepository{db: db}
+}
+
+func (r *SQLliteAPITokensRepository) Save(token *models.APIToken) (int, error) {
+	query := `
+		INSERT INTO api_tokens (tenant_id, name, token_hash, scopes, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.Exec(query,
+		token.TenantID,
+		token.Name,
+		token.TokenHash,
+		joinScopes(token.Scopes),
+		token.CreatedAt,
+		token.ExpiresAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), err
+}
+
+func (r *SQLliteAPITokensRepository) FindByHash(hash string) (*models.APIToken, error) {
+	query := `
+		SELECT id, tenant_id, name, token_hash, scopes, created_at, expires_at
+		FROM api_tokens
+		WHERE token_hash = ?
+	`
+	row := r.db.QueryRow(query, hash)
+
+	var (
+		token  models.APIToken
+		scopes string
+	)
+	err := row.Scan(&token.ID, &token.TenantID, &token.Name, &token.TokenHash, &scopes, &token.CreatedAt, &token.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	token.Scopes = splitScopes(scopes)
+
+	return &token, nil
+}
+
+func (r *SQLliteAPITokensRepository) FindByTenant(tenantID int) ([]*models.APIToken, error) {
+	query := `
+		SELECT id, tenant_id, name, token_hash, scopes, created_at, expires_at
+		FROM api_tokens
+		WHERE tenant_id = ?
+		ORDER BY id
+	`
+	rows, err := r.db.Query(query, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []*models.APIToken{}
+	for rows.Next() {
+		var (
+			token  models.APIToken
+			scopes string
+		)
+		err := rows.Scan(&token.ID, &token.TenantID, &token.Name, &token.TokenHash, &scopes, &token.CreatedAt, &token.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+		token.Scopes = splitScopes(scopes)
+		tokens = append(tokens, &token)
+	}
+
+	return tokens, rows.Err()
+}
+
+func (r *SQLliteAPITokensRepository) Delete(tenantID, id int) error {
+	result, err := r.db.Exec(`DELETE FROM api_tokens WHERE id = ? AND tenant_id = ?`, id, tenantID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func joinScopes(scopes []models.Scope) string {
+	values := make([]string, len(scopes))
+	for i, scope := range scopes {
+		values[i] = string(scope)
+	}
+	return strings.Join(values, " ")
+}
+
+func splitScopes(value string) []models.Scope {
+	scopes := []models.Scope{}
+	for _, field := range strings.Fields(value) {
+		scopes = append(scopes, models.Scope(field))
+	}
+	return scopes
+}
@@ -0,0 +1,366 @@
+package adapters
+
+import (
+	"database/sql"
+	"errors"
+	"mini-search-platform/internal/database"
+	"mini-search-platform/internal/models"
+	"mini-search-platform/pkg/sqlite"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRepositories_IsolateTenants(t *testing.T) {
+	db, err := sqlite.Open(sqlite.MemoryDSN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlite.Close(db)
+
+	if err := database.Create(db); err != nil {
+		t.Fatal(err)
+	}
+
+	tenants := NewSQLliteTenantsRepository(db)
+	tenantA, err := tenants.Save(models.NewTenant("isolation-a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tenantB, err := tenants.Save(models.NewTenant("isolation-b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authorsA := NewSQLliteAuthorsRepository(db).ForTenant(tenantA)
+	tagsA := NewSQLliteTagsRepository(db).ForTenant(tenantA)
+	articlesA := NewSQLliteArticleRepository(db).ForTenant(tenantA)
+	productsA := NewSQLliteProductsRepository(db).ForTenant(tenantA)
+
+	authorsB := NewSQLliteAuthorsRepository(db).ForTenant(tenantB)
+	tagsB := NewSQLliteTagsRepository(db).ForTenant(tenantB)
+	articlesB := NewSQLliteArticleRepository(db).ForTenant(tenantB)
+	productsB := NewSQLliteProductsRepository(db).ForTenant(tenantB)
+
+	authorID, err := authorsA.Save(models.NewAuthor(0, "Jane"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	author, err := authorsA.FindAuthorById(authorID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tagID, err := tagsA.Save(models.NewTag("denim"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	articleID, err := articlesA.Save(models.NewArticle("Jeans", "Blue", author, []*models.Tag{{ID: tagID}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := productsA.Save(models.NewProduct("sku-1", "Jeans", "Levis", "pants")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := authorsB.FindAuthorById(authorID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Tenant B could read author of tenant A: %v", err)
+	}
+	if _, err := tagsB.FindByLabel("denim"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Tenant B could read tag of tenant A: %v", err)
+	}
+	if _, err := articlesB.FindById(articleID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Tenant B could read article of tenant A: %v", err)
+	}
+	if found, _ := articlesB.FindAll(100, 0); len(found) != 0 {
+		t.Errorf("Tenant B listed %d articles of tenant A", len(found))
+	}
+	if _, err := productsB.FindById("sku-1"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Tenant B could read product of tenant A: %v", err)
+	}
+	if err := articlesB.Delete(articleID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Tenant B could delete article of tenant A: %v", err)
+	}
+	if err := productsB.Update(models.NewProduct("sku-1", "Hijacked", "x", "y")); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Tenant B could update product of tenant A: %v", err)
+	}
+
+	// Both tenants may use the same natural keys without clashing.
+	if _, err := tagsB.Save(models.NewTag("denim")); err != nil {
+		t.Errorf("Tenant B could not create its own 'denim' tag: %v", err)
+	}
+	if err := productsB.Save(models.NewProduct("sku-1", "Other jeans", "Lee", "pants")); err != nil {
+		t.Errorf("Tenant B could not create its own 'sku-1' product: %v", err)
+	}
+
+	article, err := articlesA.FindById(articleID)
+	if err != nil {
+		t.Fatalf("Tenant A lost access to its article: %v", err)
+	}
+	if len(article.Tags) != 1 || article.Tags[0].Label != "denim" {
+		t.Errorf("Tenant A article tags changed: %v", article.Tags)
+	}
+}
+
+func TestArticleRepository_RewritesArticleTags(t *testing.T) {
+	db, err := sqlite.Open(sqlite.MemoryDSN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlite.Close(db)
+
+	if err := database.Create(db); err != nil {
+		t.Fatal(err)
+	}
+
+	tenantID, err := NewSQLliteTenantsRepository(db).Save(models.NewTenant("article-tags"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	authors := NewSQLliteAuthorsRepository(db).ForTenant(tenantID)
+	tags := NewSQLliteTagsRepository(db).ForTenant(tenantID)
+	articles := NewSQLliteArticleRepository(db).ForTenant(tenantID)
+
+	authorID, err := authors.Save(models.NewAuthor(0, "Jane"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	author, err := authors.FindAuthorById(authorID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := map[string]int{}
+	for _, label := range []string{"denim", "blue", "summer"} {
+		if ids[label], err = tags.Save(models.NewTag(label)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	articleTags := func(articleID int) []int {
+		rows, err := db.Query(`SELECT tag_id FROM article_tags WHERE article_id = ? ORDER BY tag_id`, articleID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+
+		tagIDs := []int{}
+		for rows.Next() {
+			var tagID int
+			if err := rows.Scan(&tagID); err != nil {
+				t.Fatal(err)
+			}
+			tagIDs = append(tagIDs, tagID)
+		}
+		return tagIDs
+	}
+
+	jeansID, err := articles.Save(models.NewArticle("Jeans", "Blue", author, []*models.Tag{{ID: ids["denim"]}, {ID: ids["blue"]}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	jacketID, err := articles.Save(models.NewArticle("Jacket", "Denim", author, []*models.Tag{{ID: ids["denim"]}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jeans, err := articles.FindById(jeansID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jeans.Update("Jeans", "Blue", author, []*models.Tag{{ID: ids["summer"]}, {ID: ids["denim"]}})
+	if err := articles.Update(jeans); err != nil {
+		t.Fatal(err)
+	}
+	if tagIDs := articleTags(jeansID); !reflect.DeepEqual(tagIDs, []int{ids["denim"], ids["summer"]}) {
+		t.Errorf("expected the update to replace the tags with denim and summer, got %v", tagIDs)
+	}
+
+	if err := articles.Delete(jeansID); err != nil {
+		t.Fatal(err)
+	}
+	if tagIDs := articleTags(jeansID); len(tagIDs) != 0 {
+		t.Errorf("expected the tags of the deleted article to be removed, got %v", tagIDs)
+	}
+	if tagIDs := articleTags(jacketID); !reflect.DeepEqual(tagIDs, []int{ids["denim"]}) {
+		t.Errorf("expected the tags of other articles to be kept, got %v", tagIDs)
+	}
+}
+
+func TestProductsRepository_ScopesIdsToTenant(t *testing.T) {
+	db, err := sqlite.Open(sqlite.MemoryDSN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlite.Close(db)
+
+	if err := database.Create(db); err != nil {
+		t.Fatal(err)
+	}
+
+	tenants := NewSQLliteTenantsRepository(db)
+	tenantA, err := tenants.Save(models.NewTenant("products-a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tenantB, err := tenants.Save(models.NewTenant("products-b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tenantID := range []int{tenantA, tenantB} {
+		products := NewSQLliteProductsRepository(db).ForTenant(tenantID)
+		variants := NewSQLliteVariantsRepository(db).ForTenant(tenantID)
+
+		// the same ids are free in every tenant
+		if err := products.Save(models.NewProduct("sku-1", "Jeans", "Levis", "pants")); err != nil {
+			t.Fatalf("tenant %d: %v", tenantID, err)
+		}
+		if err := variants.Save(models.NewVariant("sku-1-m", "sku-1", "M", "blue", 49.9, true)); err != nil {
+			t.Fatalf("tenant %d: %v", tenantID, err)
+		}
+
+		if err := products.Save(models.NewProduct("sku-1", "Jeans", "Levis", "pants")); !errors.Is(err, models.ErrAlreadyExists) {
+			t.Errorf("tenant %d: expected a taken article id to fail with ErrAlreadyExists, got %v", tenantID, err)
+		}
+		if err := variants.Save(models.NewVariant("sku-1-m", "sku-1", "L", "black", 59.9, false)); !errors.Is(err, models.ErrAlreadyExists) {
+			t.Errorf("tenant %d: expected a taken variant id to fail with ErrAlreadyExists, got %v", tenantID, err)
+		}
+	}
+
+	variant, err := NewSQLliteVariantsRepository(db).ForTenant(tenantA).FindById("sku-1-m")
+	if err != nil || variant.Size != "M" || !variant.Availability {
+		t.Errorf("expected the first variant to be kept, got %+v, %v", variant, err)
+	}
+	if found, err := NewSQLliteProductsRepository(db).ForTenant(tenantB).FindAll(10, 0); err != nil || len(found) != 1 {
+		t.Errorf("expected 1 product of tenant B, got %v, %v", found, err)
+	}
+}
+
+func TestTagsRepository_SuggestsPopularLabels(t *testing.T) {
+	db, err := sqlite.Open(sqlite.MemoryDSN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlite.Close(db)
+
+	if err := database.Create(db); err != nil {
+		t.Fatal(err)
+	}
+
+	tenantID, err := NewSQLliteTenantsRepository(db).Save(models.NewTenant("suggestions"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	authors := NewSQLliteAuthorsRepository(db).ForTenant(tenantID)
+	tags := NewSQLliteTagsRepository(db).ForTenant(tenantID)
+	articles := NewSQLliteArticleRepository(db).ForTenant(tenantID)
+
+	authorID, err := authors.Save(models.NewAuthor(0, "Jane Dean"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	author, err := authors.FindAuthorById(authorID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := map[string]int{}
+	for _, label := range []string{"denim", "raw denim", "dense", "den_x", "summer"} {
+		if ids[label], err = tags.Save(models.NewTag(label)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, labels := range [][]string{{"raw denim"}, {"raw denim", "dense"}, {"summer"}} {
+		articleTags := []*models.Tag{}
+		for _, label := range labels {
+			articleTags = append(articleTags, &models.Tag{ID: ids[label]})
+		}
+		if _, err := articles.Save(models.NewArticle("Jeans", "Blue", author, articleTags)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	suggestions, err := tags.FindPopularByPrefix("Den", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	labels := []string{}
+	for _, suggestion := range suggestions {
+		labels = append(labels, suggestion.Text)
+	}
+	// the most used labels come first
+	if strings.Join(labels, ",") != "raw denim,dense,den_x,denim" || suggestions[0].Count != 2 {
+		t.Errorf("unexpected suggestions %v", labels)
+	}
+
+	suggestions, err = tags.FindPopularByPrefix("den_", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(suggestions) != 1 || suggestions[0].Text != "den_x" {
+		t.Errorf("expected _ to match itself only, got %+v", suggestions)
+	}
+
+	suggestions, err = authors.FindPopularByPrefix("dea", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(suggestions) != 1 || suggestions[0].Text != "Jane Dean" || suggestions[0].Count != 3 {
+		t.Errorf("unexpected author suggestions %+v", suggestions)
+	}
+}
+
+func TestMerchandisingRulesRepository_FindsActiveCampaigns(t *testing.T) {
+	db, err := sqlite.Open(sqlite.MemoryDSN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlite.Close(db)
+
+	if err := database.Create(db); err != nil {
+		t.Fatal(err)
+	}
+
+	tenantID, err := NewSQLliteTenantsRepository(db).Save(models.NewTenant("merchandising"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := NewSQLliteMerchandisingRulesRepository(db).ForTenant(tenantID)
+
+	for _, bounds := range [][2]string{
+		{"", ""},
+		{"2026-06-01T00:00:00Z", "2026-09-01T00:00:00Z"},
+		{"2026-09-01T00:00:00Z", ""},
+	} {
+		rule := models.NewMerchandisingRule("articles", models.MatchExact, "summer sale")
+		rule.Pinned = []models.Pin{{ID: "1", Position: 1}}
+		rule.StartsAt, rule.EndsAt = bounds[0], bounds[1]
+		if _, err := rules.Save(rule); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for at, expected := range map[string][]int{
+		"2026-05-31T23:59:59Z": {1},
+		"2026-06-01T00:00:00Z": {1, 2},
+		"2026-09-01T00:00:00Z": {1, 3},
+	} {
+		now, _ := time.Parse(time.RFC3339, at)
+		active, err := rules.FindActive("articles", now)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ids := []int{}
+		for _, rule := range active {
+			ids = append(ids, rule.ID)
+		}
+		if !reflect.DeepEqual(ids, expected) {
+			t.Errorf("%s: expected rules %v to be active, got %v", at, expected, ids)
+		}
+	}
+
+	if active, err := NewSQLliteMerchandisingRulesRepository(db).ForTenant(tenantID+1).FindActive("articles", time.Now()); err != nil || len(active) != 0 {
+		t.Errorf("expected no rules of another tenant, got %v, %v", active, err)
+	}
+}
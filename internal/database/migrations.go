@@ -0,0 +1,357 @@
+package database
+
+import "database/sql"
+
+// sqliteMigrations are applied in order of their versions, which must never
+// change once released. Add new migrations at the end.
+var sqliteMigrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create initial schema",
+		// the tables may exist in databases created before migrations
+		Up: `
+			CREATE TABLE IF NOT EXISTS tenants (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL UNIQUE,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			);
+
+			CREATE TABLE IF NOT EXISTS api_tokens (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				tenant_id INTEGER NOT NULL,
+				name TEXT NOT NULL,
+				token_hash TEXT NOT NULL UNIQUE,
+				scopes TEXT NOT NULL DEFAULT '',
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (tenant_id) REFERENCES tenants (id)
+			);
+
+			CREATE TABLE IF NOT EXISTS authors (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				tenant_id INTEGER NOT NULL,
+				name TEXT NOT NULL,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				UNIQUE (tenant_id, name),
+				FOREIGN KEY (tenant_id) REFERENCES tenants (id)
+			);
+
+			CREATE TABLE IF NOT EXISTS articles (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				tenant_id INTEGER NOT NULL,
+				title TEXT NOT NULL,
+				body TEXT NOT NULL,
+				author_id INTEGER NOT NULL,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (tenant_id) REFERENCES tenants (id),
+				FOREIGN KEY (author_id) REFERENCES author (id)
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_articles_tenant
+				ON articles (tenant_id);
+
+			CREATE TABLE IF NOT EXISTS tags (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				tenant_id INTEGER NOT NULL,
+				label TEXT NOT NULL,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				updated_at TIMESTAMP,
+				UNIQUE (tenant_id, label),
+				FOREIGN KEY (tenant_id) REFERENCES tenants (id)
+			);
+
+			CREATE TABLE IF NOT EXISTS article_tags (
+				article_id INTEGER NOT NULL,
+				tag_id INTEGER NOT NULL,
+				PRIMARY KEY (article_id, tag_id),
+				FOREIGN KEY (article_id) REFERENCES articles (id),
+				FOREIGN KEY (tag_id) REFERENCES tags (id)
+			);
+
+			CREATE TABLE IF NOT EXISTS products (
+				tenant_id INTEGER NOT NULL,
+				article_id TEXT NOT NULL,
+				title TEXT NOT NULL,
+				brand TEXT NOT NULL,
+				category TEXT NOT NULL,
+				indexed_facet_data TEXT,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				PRIMARY KEY (tenant_id, article_id),
+				FOREIGN KEY (tenant_id) REFERENCES tenants (id)
+			);
+
+			CREATE TABLE IF NOT EXISTS variants (
+				tenant_id INTEGER NOT NULL,
+				variant_id TEXT NOT NULL,
+				article_id TEXT NOT NULL,
+				size TEXT NOT NULL,
+				color TEXT NOT NULL,
+				price FLOAT NOT NULL,
+				availability BOOLEAN NOT NULL,
+				updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				PRIMARY KEY (tenant_id, variant_id),
+				FOREIGN KEY (tenant_id, article_id) REFERENCES products (tenant_id, article_id)
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_variants_article_size_color
+				ON variants (tenant_id, article_id, size, color);
+
+			CREATE TABLE IF NOT EXISTS catalogues (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				tenant_id INTEGER NOT NULL,
+				code TEXT NOT NULL,
+				language TEXT NOT NULL,
+				name TEXT NOT NULL,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				UNIQUE (tenant_id, code),
+				FOREIGN KEY (tenant_id) REFERENCES tenants (id)
+			);
+
+			CREATE TABLE IF NOT EXISTS translations (
+				tenant_id INTEGER NOT NULL,
+				catalogue_id INTEGER NOT NULL,
+				entity_type TEXT NOT NULL,
+				entity_id TEXT NOT NULL,
+				title TEXT NOT NULL,
+				body TEXT NOT NULL DEFAULT '',
+				source TEXT NOT NULL DEFAULT 'manual',
+				status TEXT NOT NULL DEFAULT 'translated',
+				attempts INTEGER NOT NULL DEFAULT 0,
+				last_error TEXT NOT NULL DEFAULT '',
+				updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				PRIMARY KEY (catalogue_id, entity_type, entity_id),
+				FOREIGN KEY (tenant_id) REFERENCES tenants (id),
+				FOREIGN KEY (catalogue_id) REFERENCES catalogues (id)
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_translations_status
+				ON translations (tenant_id, catalogue_id, status);
+
+			CREATE TABLE IF NOT EXISTS index_outbox (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				tenant_id INTEGER NOT NULL,
+				operation TEXT NOT NULL,
+				catalogue_id INTEGER NOT NULL DEFAULT 0,
+				entity_ids TEXT NOT NULL,
+				status TEXT NOT NULL,
+				attempts INTEGER NOT NULL DEFAULT 0,
+				last_error TEXT NOT NULL DEFAULT '',
+				available_at TEXT NOT NULL,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (tenant_id) REFERENCES tenants (id)
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_index_outbox_due
+				ON index_outbox (status, available_at);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS index_outbox;
+			DROP TABLE IF EXISTS translations;
+			DROP TABLE IF EXISTS catalogues;
+			DROP TABLE IF EXISTS variants;
+			DROP TABLE IF EXISTS products;
+			DROP TABLE IF EXISTS article_tags;
+			DROP TABLE IF EXISTS tags;
+			DROP TABLE IF EXISTS articles;
+			DROP TABLE IF EXISTS authors;
+			DROP TABLE IF EXISTS api_tokens;
+			DROP TABLE IF EXISTS tenants;
+		`,
+	},
+	{
+		Version: 2,
+		Name:    "create search_settings",
+		Up: `
+			CREATE TABLE IF NOT EXISTS search_settings (
+				tenant_id INTEGER NOT NULL,
+				index_name TEXT NOT NULL,
+				synonyms TEXT NOT NULL DEFAULT '{}',
+				stop_words TEXT NOT NULL DEFAULT '[]',
+				ranking_rules TEXT NOT NULL DEFAULT '[]',
+				updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				PRIMARY KEY (tenant_id, index_name),
+				FOREIGN KEY (tenant_id) REFERENCES tenants (id)
+			);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS search_settings;
+		`,
+	},
+	{
+		Version: 3,
+		Name:    "create merchandising_rules",
+		Up: `
+			CREATE TABLE IF NOT EXISTS merchandising_rules (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				tenant_id INTEGER NOT NULL,
+				index_name TEXT NOT NULL,
+				match TEXT NOT NULL,
+				query TEXT NOT NULL,
+				pinned TEXT NOT NULL DEFAULT '[]',
+				buried TEXT NOT NULL DEFAULT '[]',
+				starts_at TEXT NOT NULL DEFAULT '',
+				ends_at TEXT NOT NULL DEFAULT '',
+				created_at TEXT NOT NULL,
+				updated_at TEXT NOT NULL,
+				FOREIGN KEY (tenant_id) REFERENCES tenants (id)
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_merchandising_rules_index
+				ON merchandising_rules (tenant_id, index_name);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS merchandising_rules;
+		`,
+	},
+	{
+		Version: 4,
+		Name:    "version indexed facets of products",
+		Up: `
+			ALTER TABLE products ADD COLUMN indexed_facet_version INTEGER NOT NULL DEFAULT 0;
+		`,
+		Down: `
+			ALTER TABLE products DROP COLUMN indexed_facet_version;
+		`,
+	},
+	{
+		Version: 5,
+		Name:    "create search engine tables",
+		// the SQLite search engine created them before this migration
+		Up: `
+			CREATE TABLE IF NOT EXISTS search_documents (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				index_name TEXT NOT NULL,
+				document_id TEXT NOT NULL,
+				source TEXT NOT NULL,
+				UNIQUE(index_name, document_id)
+			);
+
+			CREATE TABLE IF NOT EXISTS search_rebuilds (
+				index_name TEXT PRIMARY KEY
+			);
+
+			CREATE TABLE IF NOT EXISTS search_index_settings (
+				index_name TEXT PRIMARY KEY,
+				settings TEXT NOT NULL
+			);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS search_index_settings;
+			DROP TABLE IF EXISTS search_rebuilds;
+			DROP TABLE IF EXISTS search_documents;
+		`,
+	},
+	{
+		Version: 6,
+		Name:    "create search engine full-text tables",
+		// FTS5 is only compiled into go-sqlite3 with the sqlite_fts5 build tag
+		Applies: hasFTS5,
+		Up: `
+			CREATE VIRTUAL TABLE IF NOT EXISTS search_articles_fts
+				USING fts5(title, body, author, tags, tokenize = 'unicode61 remove_diacritics 2');
+
+			CREATE VIRTUAL TABLE IF NOT EXISTS search_products_fts
+				USING fts5(title, brand, category, tokenize = 'unicode61 remove_diacritics 2');
+		`,
+		Down: `
+			DROP TABLE IF EXISTS search_products_fts;
+			DROP TABLE IF EXISTS search_articles_fts;
+		`,
+	},
+	{
+		Version: 7,
+		Name:    "key authors by tenant",
+		// SQLite cannot change a primary key, the table is copied instead;
+		// reverting fails once tenants share author ids
+		Up: `
+			CREATE TABLE authors_by_tenant (
+				id INTEGER NOT NULL,
+				tenant_id INTEGER NOT NULL,
+				name TEXT NOT NULL,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				PRIMARY KEY (tenant_id, id),
+				UNIQUE (tenant_id, name),
+				FOREIGN KEY (tenant_id) REFERENCES tenants (id)
+			);
+
+			INSERT INTO authors_by_tenant (id, tenant_id, name, created_at)
+				SELECT id, tenant_id, name, created_at FROM authors;
+
+			DROP TABLE authors;
+			ALTER TABLE authors_by_tenant RENAME TO authors;
+		`,
+		Down: `
+			CREATE TABLE authors_by_id (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				tenant_id INTEGER NOT NULL,
+				name TEXT NOT NULL,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				UNIQUE (tenant_id, name),
+				FOREIGN KEY (tenant_id) REFERENCES tenants (id)
+			);
+
+			INSERT INTO authors_by_id (id, tenant_id, name, created_at)
+				SELECT id, tenant_id, name, created_at FROM authors;
+
+			DROP TABLE authors;
+			ALTER TABLE authors_by_id RENAME TO authors;
+		`,
+	},
+	{
+		Version: 8,
+		Name:    "expire api tokens",
+		Up: `
+			ALTER TABLE api_tokens ADD COLUMN expires_at TEXT NOT NULL DEFAULT '';
+		`,
+		Down: `
+			ALTER TABLE api_tokens DROP COLUMN expires_at;
+		`,
+	},
+	{
+		Version: 9,
+		Name:    "scope search engine full-text tables by index",
+		// FTS5 tables cannot be altered, they are copied instead
+		Applies: hasFTS5,
+		Up: `
+			CREATE VIRTUAL TABLE search_articles_fts_by_index
+				USING fts5(title, body, author, tags, index_name UNINDEXED, tokenize = 'unicode61 remove_diacritics 2');
+			INSERT INTO search_articles_fts_by_index (rowid, title, body, author, tags, index_name)
+				SELECT f.rowid, f.title, f.body, f.author, f.tags, d.index_name
+				FROM search_articles_fts f
+				JOIN search_documents d ON d.id = f.rowid;
+			DROP TABLE search_articles_fts;
+			ALTER TABLE search_articles_fts_by_index RENAME TO search_articles_fts;
+
+			CREATE VIRTUAL TABLE search_products_fts_by_index
+				USING fts5(title, brand, category, index_name UNINDEXED, tokenize = 'unicode61 remove_diacritics 2');
+			INSERT INTO search_products_fts_by_index (rowid, title, brand, category, index_name)
+				SELECT f.rowid, f.title, f.brand, f.category, d.index_name
+				FROM search_products_fts f
+				JOIN search_documents d ON d.id = f.rowid;
+			DROP TABLE search_products_fts;
+			ALTER TABLE search_products_fts_by_index RENAME TO search_products_fts;
+		`,
+		Down: `
+			CREATE VIRTUAL TABLE search_articles_fts_shared
+				USING fts5(title, body, author, tags, tokenize = 'unicode61 remove_diacritics 2');
+			INSERT INTO search_articles_fts_shared (rowid, title, body, author, tags)
+				SELECT rowid, title, body, author, tags FROM search_articles_fts;
+			DROP TABLE search_articles_fts;
+			ALTER TABLE search_articles_fts_shared RENAME TO search_articles_fts;
+
+			CREATE VIRTUAL TABLE search_products_fts_shared
+				USING fts5(title, brand, category, tokenize = 'unicode61 remove_diacritics 2');
+			INSERT INTO search_products_fts_shared (rowid, title, brand, category)
+				SELECT rowid, title, brand, category FROM search_products_fts;
+			DROP TABLE search_products_fts;
+			ALTER TABLE search_products_fts_shared RENAME TO search_products_fts;
+		`,
+	},
+}
+
+// hasFTS5 reports whether SQLite was compiled with the FTS5 extension.
+func hasFTS5(tx *sql.Tx) (bool, error) {
+	var enabled bool
+	err := tx.QueryRow(`SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&enabled)
+	return enabled, err
+}
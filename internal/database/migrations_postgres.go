@@ -0,0 +1,258 @@
+package database
+
+// postgresMigrations mirror sqliteMigrations version by version. Times are
+// kept as RFC 3339 text like in SQLite, so that both drivers return the
+// same values, and the ones compared in queries use the "C" collation to
+// compare like SQLite does. JSON documents are kept as JSONB.
+var postgresMigrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create initial schema",
+		Up: `
+			CREATE TABLE tenants (
+				id SERIAL PRIMARY KEY,
+				name TEXT NOT NULL UNIQUE,
+				created_at TEXT NOT NULL
+			);
+
+			CREATE TABLE api_tokens (
+				id SERIAL PRIMARY KEY,
+				tenant_id INTEGER NOT NULL REFERENCES tenants (id),
+				name TEXT NOT NULL,
+				token_hash TEXT NOT NULL UNIQUE,
+				scopes TEXT NOT NULL DEFAULT '',
+				created_at TEXT NOT NULL
+			);
+
+			CREATE TABLE authors (
+				id SERIAL PRIMARY KEY,
+				tenant_id INTEGER NOT NULL REFERENCES tenants (id),
+				name TEXT NOT NULL,
+				created_at TEXT NOT NULL,
+				UNIQUE (tenant_id, name)
+			);
+
+			CREATE TABLE articles (
+				id SERIAL PRIMARY KEY,
+				tenant_id INTEGER NOT NULL REFERENCES tenants (id),
+				title TEXT NOT NULL,
+				body TEXT NOT NULL,
+				author_id INTEGER NOT NULL REFERENCES authors (id),
+				created_at TEXT NOT NULL
+			);
+
+			CREATE INDEX idx_articles_tenant
+				ON articles (tenant_id);
+
+			CREATE TABLE tags (
+				id SERIAL PRIMARY KEY,
+				tenant_id INTEGER NOT NULL REFERENCES tenants (id),
+				label TEXT NOT NULL,
+				created_at TEXT NOT NULL,
+				updated_at TEXT NOT NULL,
+				UNIQUE (tenant_id, label)
+			);
+
+			CREATE TABLE article_tags (
+				article_id INTEGER NOT NULL REFERENCES articles (id) ON DELETE CASCADE,
+				tag_id INTEGER NOT NULL REFERENCES tags (id),
+				PRIMARY KEY (article_id, tag_id)
+			);
+
+			CREATE INDEX idx_article_tags_tag
+				ON article_tags (tag_id);
+
+			CREATE TABLE products (
+				tenant_id INTEGER NOT NULL REFERENCES tenants (id),
+				article_id TEXT NOT NULL,
+				title TEXT NOT NULL,
+				brand TEXT NOT NULL,
+				category TEXT NOT NULL,
+				indexed_facet_data JSONB,
+				created_at TEXT NOT NULL,
+				updated_at TEXT NOT NULL,
+				PRIMARY KEY (tenant_id, article_id)
+			);
+
+			CREATE TABLE variants (
+				tenant_id INTEGER NOT NULL,
+				variant_id TEXT NOT NULL,
+				article_id TEXT NOT NULL,
+				size TEXT NOT NULL,
+				color TEXT NOT NULL,
+				price DOUBLE PRECISION NOT NULL,
+				availability BOOLEAN NOT NULL,
+				updated_at TEXT NOT NULL,
+				PRIMARY KEY (tenant_id, variant_id),
+				FOREIGN KEY (tenant_id, article_id) REFERENCES products (tenant_id, article_id)
+			);
+
+			CREATE INDEX idx_variants_article_size_color
+				ON variants (tenant_id, article_id, size, color);
+
+			CREATE TABLE catalogues (
+				id SERIAL PRIMARY KEY,
+				tenant_id INTEGER NOT NULL REFERENCES tenants (id),
+				code TEXT NOT NULL,
+				language TEXT NOT NULL,
+				name TEXT NOT NULL,
+				created_at TEXT NOT NULL,
+				UNIQUE (tenant_id, code)
+			);
+
+			CREATE TABLE translations (
+				tenant_id INTEGER NOT NULL REFERENCES tenants (id),
+				catalogue_id INTEGER NOT NULL REFERENCES catalogues (id),
+				entity_type TEXT NOT NULL,
+				entity_id TEXT NOT NULL,
+				title TEXT NOT NULL,
+				body TEXT NOT NULL DEFAULT '',
+				source TEXT NOT NULL DEFAULT 'manual',
+				status TEXT NOT NULL DEFAULT 'translated',
+				attempts INTEGER NOT NULL DEFAULT 0,
+				last_error TEXT NOT NULL DEFAULT '',
+				updated_at TEXT NOT NULL,
+				PRIMARY KEY (catalogue_id, entity_type, entity_id)
+			);
+
+			CREATE INDEX idx_translations_status
+				ON translations (tenant_id, catalogue_id, status);
+
+			CREATE TABLE index_outbox (
+				id SERIAL PRIMARY KEY,
+				tenant_id INTEGER NOT NULL REFERENCES tenants (id),
+				operation TEXT NOT NULL,
+				catalogue_id INTEGER NOT NULL DEFAULT 0,
+				entity_ids JSONB NOT NULL,
+				status TEXT NOT NULL,
+				attempts INTEGER NOT NULL DEFAULT 0,
+				last_error TEXT NOT NULL DEFAULT '',
+				available_at TEXT COLLATE "C" NOT NULL,
+				created_at TEXT NOT NULL
+			);
+
+			CREATE INDEX idx_index_outbox_due
+				ON index_outbox (status, available_at);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS index_outbox;
+			DROP TABLE IF EXISTS translations;
+			DROP TABLE IF EXISTS catalogues;
+			DROP TABLE IF EXISTS variants;
+			DROP TABLE IF EXISTS products;
+			DROP TABLE IF EXISTS article_tags;
+			DROP TABLE IF EXISTS tags;
+			DROP TABLE IF EXISTS articles;
+			DROP TABLE IF EXISTS authors;
+			DROP TABLE IF EXISTS api_tokens;
+			DROP TABLE IF EXISTS tenants;
+		`,
+	},
+	{
+		Version: 2,
+		Name:    "create search_settings",
+		Up: `
+			CREATE TABLE search_settings (
+				tenant_id INTEGER NOT NULL REFERENCES tenants (id),
+				index_name TEXT NOT NULL,
+				synonyms JSONB NOT NULL DEFAULT '{}',
+				stop_words JSONB NOT NULL DEFAULT '[]',
+				ranking_rules JSONB NOT NULL DEFAULT '[]',
+				updated_at TEXT NOT NULL,
+				PRIMARY KEY (tenant_id, index_name)
+			);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS search_settings;
+		`,
+	},
+	{
+		Version: 3,
+		Name:    "create merchandising_rules",
+		Up: `
+			CREATE TABLE merchandising_rules (
+				id SERIAL PRIMARY KEY,
+				tenant_id INTEGER NOT NULL REFERENCES tenants (id),
+				index_name TEXT NOT NULL,
+				match TEXT NOT NULL,
+				query TEXT NOT NULL,
+				pinned JSONB NOT NULL DEFAULT '[]',
+				buried JSONB NOT NULL DEFAULT '[]',
+				starts_at TEXT COLLATE "C" NOT NULL DEFAULT '',
+				ends_at TEXT COLLATE "C" NOT NULL DEFAULT '',
+				created_at TEXT NOT NULL,
+				updated_at TEXT NOT NULL
+			);
+
+			CREATE INDEX idx_merchandising_rules_index
+				ON merchandising_rules (tenant_id, index_name);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS merchandising_rules;
+		`,
+	},
+	{
+		Version: 4,
+		Name:    "version indexed facets of products",
+		Up: `
+			ALTER TABLE products ADD COLUMN indexed_facet_version INTEGER NOT NULL DEFAULT 0;
+		`,
+		Down: `
+			ALTER TABLE products DROP COLUMN indexed_facet_version;
+		`,
+	},
+	// the SQLite search engine requires the SQLite driver, its tables only
+	// keep the versions of both drivers in step
+	{
+		Version: 5,
+		Name:    "create search engine tables",
+		Up:      `SELECT 1;`,
+		Down:    `SELECT 1;`,
+	},
+	{
+		Version: 6,
+		Name:    "create search engine full-text tables",
+		Up:      `SELECT 1;`,
+		Down:    `SELECT 1;`,
+	},
+	{
+		Version: 7,
+		Name:    "key authors by tenant",
+		// reverting fails once tenants share author ids
+		Up: `
+			ALTER TABLE articles DROP CONSTRAINT articles_author_id_fkey;
+			ALTER TABLE authors DROP CONSTRAINT authors_pkey;
+			ALTER TABLE authors ALTER COLUMN id DROP DEFAULT;
+			DROP SEQUENCE authors_id_seq;
+			ALTER TABLE authors ADD PRIMARY KEY (tenant_id, id);
+			ALTER TABLE articles ADD CONSTRAINT articles_author_fkey
+				FOREIGN KEY (tenant_id, author_id) REFERENCES authors (tenant_id, id);
+		`,
+		Down: `
+			ALTER TABLE articles DROP CONSTRAINT articles_author_fkey;
+			ALTER TABLE authors DROP CONSTRAINT authors_pkey;
+			CREATE SEQUENCE authors_id_seq OWNED BY authors.id;
+			SELECT setval('authors_id_seq', COALESCE(MAX(id), 0) + 1, false) FROM authors;
+			ALTER TABLE authors ALTER COLUMN id SET DEFAULT nextval('authors_id_seq');
+			ALTER TABLE authors ADD PRIMARY KEY (id);
+			ALTER TABLE articles ADD CONSTRAINT articles_author_id_fkey
+				FOREIGN KEY (author_id) REFERENCES authors (id);
+		`,
+	},
+	{
+		Version: 8,
+		Name:    "expire api tokens",
+		Up: `
+			ALTER TABLE api_tokens ADD COLUMN expires_at TEXT NOT NULL DEFAULT '';
+		`,
+		Down: `
+			ALTER TABLE api_tokens DROP COLUMN expires_at;
+		`,
+	},
+	{
+		Version: 9,
+		Name:    "scope search engine full-text tables by index",
+		Up:      `SELECT 1;`,
+		Down:    `SELECT 1;`,
+	},
+}
@@ -1,39 +1,269 @@
 package database
 
-import "database/sql"
+import (
+	"database/sql"
+	"fmt"
+	"mini-search-platform/config"
+	"mini-search-platform/pkg/postgres"
+	"mini-search-platform/pkg/sqlite"
+	"strings"
+	"time"
+)
 
+// Migration is a versioned schema change. Up and Down are run in a
+// transaction together with recording the version in schema_migrations.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+	// Applies, if set, reports whether the database supports the migration.
+	// Migrations it does not support are left out until it does, even if
+	// later ones were applied in the meantime.
+	Applies func(tx *sql.Tx) (bool, error)
+}
+
+// SchemaTooNewError is returned when the database has migrations applied
+// that this binary does not know, i.e. it was migrated by a newer release.
+type SchemaTooNewError struct {
+	Version int
+	Latest  int
+}
+
+func (e *SchemaTooNewError) Error() string {
+	return fmt.Sprintf("database schema version %d is newer than the latest known version %d", e.Version, e.Latest)
+}
+
+// Create migrates the SQLite database to the latest schema version.
 func Create(db *sql.DB) error {
-	_, err := db.Exec(`
-		CREATE TABLE IF NOT EXISTS authors (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			name TEXT NOT NULL UNIQUE,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		);
-		
-		CREATE TABLE IF NOT EXISTS articles (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			title TEXT NOT NULL,
-			body TEXT NOT NULL,
-			author_id INTEGER NOT NULL,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			FOREIGN KEY (author_id) REFERENCES author (id)
-		);
-
-		CREATE TABLE IF NOT EXISTS tags (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			label TEXT NOT NULL UNIQUE,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP
-		);
-
-		CREATE TABLE IF NOT EXISTS article_tags (
-			article_id INTEGER NOT NULL,
-			tag_id INTEGER NOT NULL,
-			PRIMARY KEY (article_id, tag_id),
-			FOREIGN KEY (article_id) REFERENCES articles (id),
-			FOREIGN KEY (tag_id) REFERENCES tags (id)
-		);
+	migrator, err := NewMigrator(db, config.DatabaseSQLite)
+	if err != nil {
+		return err
+	}
+
+	_, err = migrator.Migrate(migrator.LatestVersion())
+	return err
+}
+
+// Migrator applies the migrations of a database driver, sqlite or postgres.
+type Migrator struct {
+	db         *sql.DB
+	driver     string
+	migrations []Migration
+}
+
+func NewMigrator(db *sql.DB, driver string) (*Migrator, error) {
+	migrator := &Migrator{db: db, driver: driver}
+	switch driver {
+	case config.DatabaseSQLite:
+		migrator.migrations = sqliteMigrations
+	case config.DatabasePostgres:
+		migrator.migrations = postgresMigrations
+	default:
+		return nil, fmt.Errorf("unknown database driver '%s'", driver)
+	}
+
+	return migrator, nil
+}
+
+// LatestVersion returns the version of the last migration.
+func (m *Migrator) LatestVersion() int {
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns the schema version of the database, 0 if no migration
+// was applied yet.
+func (m *Migrator) Version() (int, error) {
+	if err := m.createMigrationsTable(); err != nil {
+		return 0, err
+	}
+
+	var version int
+	err := m.db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	return version, err
+}
+
+// Migrate applies the up steps of the unapplied migrations up to the target
+// version, or the down steps of the applied ones above it, one transaction
+// per migration.
+// It returns the migrations run, and refuses to touch a database whose
+// schema is newer than the latest known version.
+func (m *Migrator) Migrate(target int) ([]Migration, error) {
+	latest := m.LatestVersion()
+	if target < 0 || target > latest {
+		return nil, fmt.Errorf("unknown schema version %d, expected 0 to %d", target, latest)
+	}
+
+	current, err := m.Version()
+	if err != nil {
+		return nil, err
+	}
+	if current > latest {
+		return nil, &SchemaTooNewError{Version: current, Latest: latest}
+	}
+
+	applied, err := m.appliedVersions()
+	if err != nil {
+		return nil, err
+	}
+
+	run := []Migration{}
+	for _, migration := range m.migrations {
+		if !applied[migration.Version] && migration.Version <= target {
+			ran, err := m.apply(migration, true)
+			if err != nil {
+				return run, err
+			}
+			if ran {
+				run = append(run, migration)
+			}
+		}
+	}
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if applied[migration.Version] && migration.Version > target {
+			ran, err := m.apply(migration, false)
+			if err != nil {
+				return run, err
+			}
+			if ran {
+				run = append(run, migration)
+			}
+		}
+	}
+
+	return run, nil
+}
+
+func (m *Migrator) appliedVersions() (map[int]bool, error) {
+	rows, err := m.db.Query(`SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]bool{}
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+
+	return applied, rows.Err()
+}
+
+// apply runs a step of the migration unless another process already did,
+// or the database does not support it. It reports whether it ran the step.
+func (m *Migrator) apply(migration Migration, up bool) (bool, error) {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// SQLite transactions take the write lock when they begin, Postgres
+	// ones have to wait for other migrating processes explicitly
+	if m.driver == config.DatabasePostgres {
+		if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext('schema_migrations'))`); err != nil {
+			return false, err
+		}
+	}
+
+	var applied bool
+	err = tx.QueryRow(m.bind(`SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = ?)`), migration.Version).Scan(&applied)
+	if err != nil {
+		return false, err
+	}
+	if applied == up {
+		return false, nil
+	}
+	if up && migration.Applies != nil {
+		supported, err := migration.Applies(tx)
+		if err != nil || !supported {
+			return false, err
+		}
+	}
+
+	statement, record := migration.Down, `DELETE FROM schema_migrations WHERE version = ?`
+	args := []interface{}{migration.Version}
+	if up {
+		statement, record = migration.Up, `INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`
+		args = append(args, migration.Name, time.Now().UTC().Format(time.RFC3339))
+	}
+
+	if _, err := tx.Exec(statement); err != nil {
+		return false, fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Name, err)
+	}
+	if _, err := tx.Exec(m.bind(record), args...); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// bind numbers the placeholders of the query for Postgres.
+func (m *Migrator) bind(query string) string {
+	if m.driver != config.DatabasePostgres {
+		return query
+	}
+
+	var bound strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			fmt.Fprintf(&bound, "$%d", n)
+			continue
+		}
+		bound.WriteRune(r)
+	}
+
+	return bound.String()
+}
+
+func (m *Migrator) createMigrationsTable() error {
+	_, err := m.db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TEXT NOT NULL
+		)
 	`)
 
 	return err
 }
+
+// Connect opens the database selected by the configuration.
+func Connect(cfg *config.AppConfig) (*sql.DB, error) {
+	switch cfg.Database.Driver {
+	case config.DatabaseSQLite:
+		return sqlite.Open(cfg.Database.SQLiteDSN)
+	case config.DatabasePostgres:
+		return postgres.Open(string(cfg.Database.PostgresDSN))
+	}
+
+	return nil, fmt.Errorf("unknown database driver '%s'", cfg.Database.Driver)
+}
+
+// Open connects to the database selected by the configuration and migrates
+// it to the latest schema version.
+func Open(cfg *config.AppConfig) (*sql.DB, error) {
+	db, err := Connect(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	migrator, err := NewMigrator(db, cfg.Database.Driver)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	if _, err := migrator.Migrate(migrator.LatestVersion()); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
@@ -0,0 +1,80 @@
+package database_test
+
+import (
+	"errors"
+	"mini-search-platform/config"
+	"mini-search-platform/internal/database"
+	"mini-search-platform/pkg/sqlite"
+	"testing"
+)
+
+func TestMigrate_UpDownAndNewerSchema(t *testing.T) {
+	db, err := sqlite.Open(sqlite.MemoryDSN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlite.Close(db)
+
+	migrator, err := database.NewMigrator(db, config.DatabaseSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the two full-text search migrations, the latest one included, are
+	// left out unless FTS5 is compiled in
+	var fts5 bool
+	if err := db.QueryRow(`SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&fts5); err != nil {
+		t.Fatal(err)
+	}
+	latest, applied, newest := migrator.LatestVersion(), migrator.LatestVersion(), migrator.LatestVersion()
+	if !fts5 {
+		applied -= 2
+		newest--
+	}
+
+	if err := database.Create(db); err != nil {
+		t.Fatal(err)
+	}
+	if version, err := migrator.Version(); err != nil || version != newest {
+		t.Fatalf("expected version %d, got %d, %v", newest, version, err)
+	}
+	if _, err := db.Exec(`SELECT 1 FROM search_documents`); err != nil {
+		t.Errorf("expected search_documents to be created, got %v", err)
+	}
+	if _, err := db.Exec(`SELECT 1 FROM search_articles_fts`); (err == nil) != fts5 {
+		t.Errorf("expected search_articles_fts to exist only with FTS5, got %v", err)
+	}
+
+	// migrating again is a no-op
+	if run, err := migrator.Migrate(latest); err != nil || len(run) != 0 {
+		t.Errorf("expected no migrations to run, got %v, %v", run, err)
+	}
+
+	run, err := migrator.Migrate(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(run) != applied-1 || run[0].Version != newest {
+		t.Errorf("expected the migrations above 1 to be reverted newest first, got %v", run)
+	}
+	for _, table := range []string{"search_settings", "search_documents"} {
+		if _, err := db.Exec(`SELECT 1 FROM ` + table); err == nil {
+			t.Errorf("expected %s to be dropped", table)
+		}
+	}
+
+	if err := database.Create(db); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`SELECT 1 FROM merchandising_rules`); err != nil {
+		t.Errorf("expected merchandising_rules to be recreated, got %v", err)
+	}
+
+	if _, err := db.Exec(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, 'from the future', '')`, latest+1); err != nil {
+		t.Fatal(err)
+	}
+	var tooNew *database.SchemaTooNewError
+	if err := database.Create(db); !errors.As(err, &tooNew) || tooNew.Version != latest+1 {
+		t.Errorf("expected a newer schema to be refused, got %v", err)
+	}
+}
@@ -1,18 +1,19 @@
 package handlers
 
 import (
-	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"mini-search-platform/internal/middleware"
 	"mini-search-platform/internal/models"
-	"mini-search-platform/internal/search"
-	"mini-search-platform/pkg/retry"
+	"mini-search-platform/internal/translation"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mcuadros/go-defaults"
 )
 
-type AuthorsFinder interface {
-	FindAuthorById(id int) (*models.Author, error)
-}
-
 type ArticleInput struct {
 	Title    string   `json:"title" binding:"required"`
 	Body     string   `json:"body" binding:"required"`
@@ -32,8 +33,14 @@ type AddArticlesResponse struct {
 	Failed   []map[string]ArticleInput `json:"failed"`
 }
 
-func AddArticles(repository models.ArticleRepository, finder AuthorsFinder, tagsRepository models.TagsRepository, sync *search.IndexSyncManager) gin.HandlerFunc {
+func AddArticles(repository models.ArticleRepository, finder models.AuthorsRepository, tagsRepository models.TagsRepository, enricher *translation.Enricher) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		repository := repository.ForTenant(tenantID)
+		finder := finder.ForTenant(tenantID)
+		tagsRepository := tagsRepository.ForTenant(tenantID)
+		enricher := enricher.ForTenant(tenantID)
+
 		var inputs []ArticleInput
 		if err := c.ShouldBindJSON(&inputs); err != nil {
 			c.JSON(400, gin.H{"error": err.Error()})
@@ -73,13 +80,7 @@ func AddArticles(repository models.ArticleRepository, finder AuthorsFinder, tags
 			inserted = append(inserted, article)
 		}
 
-		resync := func(articlesToSync []*models.Article) error {
-			operation := func() error {
-				return sync.SyncAfterArticlesChanged(articlesToSync)
-			}
-			return retry.WithBackoff(context.Background(), operation)
-		}
-		go resync(inserted)
+		enrichInBackground(func() error { return enricher.EnrichArticles(inserted) }, "tenant", tenantID, "articles", articleIDs(inserted))
 
 		c.JSON(201, AddArticlesResponse{
 			Summary: AddArticlesSummary{
@@ -92,8 +93,13 @@ func AddArticles(repository models.ArticleRepository, finder AuthorsFinder, tags
 	}
 }
 
-func AddArticle(repository models.ArticleRepository, finder AuthorsFinder, tagsRepository models.TagsRepository, sync *search.IndexSyncManager) gin.HandlerFunc {
+func AddArticle(repository models.ArticleRepository, finder models.AuthorsRepository, tagsRepository models.TagsRepository, enricher *translation.Enricher) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		repository := repository.ForTenant(tenantID)
+		finder := finder.ForTenant(tenantID)
+		tagsRepository := tagsRepository.ForTenant(tenantID)
+		enricher := enricher.ForTenant(tenantID)
 
 		var input ArticleInput
 		if err := c.ShouldBindJSON(&input); err != nil {
@@ -123,14 +129,245 @@ func AddArticle(repository models.ArticleRepository, finder AuthorsFinder, tagsR
 
 		article.ID = lastInsertedId
 
-		resync := func(articlesToSync []*models.Article) error {
-			operation := func() error {
-				return sync.SyncAfterArticlesChanged(articlesToSync)
+		enrichInBackground(func() error { return enricher.EnrichArticles([]*models.Article{article}) }, "tenant", tenantID, "article", article.ID)
+
+		c.JSON(201, article)
+	}
+}
+
+type ListArticlesQueryParams struct {
+	Limit  int `form:"limit" default:"10" binding:"min=1,max=100"`
+	Offset int `form:"offset" default:"0" binding:"min=0"`
+}
+
+type ListArticlesResponse struct {
+	Articles []*models.Article `json:"articles"`
+	Offset   int               `json:"offset"`
+	Limit    int               `json:"limit"`
+	Total    int               `json:"total"`
+}
+
+func ListArticles(repository models.ArticleRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		repository := repository.ForTenant(tenantID)
+
+		var params ListArticlesQueryParams
+
+		defaults.SetDefaults(&params)
+
+		if err := c.ShouldBindQuery(&params); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		articles, err := repository.FindAll(params.Limit, params.Offset)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to fetch articles"})
+			return
+		}
+
+		total, err := repository.Count()
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to count articles"})
+			return
+		}
+
+		c.JSON(200, ListArticlesResponse{
+			Articles: articles,
+			Offset:   params.Offset,
+			Limit:    params.Limit,
+			Total:    total,
+		})
+	}
+}
+
+func GetArticle(repository models.ArticleRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		repository := repository.ForTenant(tenantID)
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid article id"})
+			return
+		}
+
+		article, err := repository.FindById(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(404, gin.H{"error": fmt.Sprintf("Could not find article %d", id)})
+			return
+		}
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to fetch article"})
+			return
+		}
+
+		c.JSON(200, article)
+	}
+}
+
+func UpdateArticle(repository models.ArticleRepository, finder models.AuthorsRepository, tagsRepository models.TagsRepository, enricher *translation.Enricher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		repository := repository.ForTenant(tenantID)
+		finder := finder.ForTenant(tenantID)
+		tagsRepository := tagsRepository.ForTenant(tenantID)
+		enricher := enricher.ForTenant(tenantID)
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid article id"})
+			return
+		}
+
+		var input ArticleInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		article, err := repository.FindById(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(404, gin.H{"error": fmt.Sprintf("Could not find article %d", id)})
+			return
+		}
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to fetch article"})
+			return
+		}
+
+		author, err := finder.FindAuthorById(input.AuthorID)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Author not found"})
+			return
+		}
+
+		tags, err := tagsRepository.FindByLabels(input.Tags)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Could not find one (or more) tags"})
+			return
+		}
+
+		article.Update(input.Title, input.Body, author, tags)
+
+		if err := repository.Update(article); err != nil {
+			c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to update article %d", id)})
+			return
+		}
+
+		enrichInBackground(func() error { return enricher.EnrichArticles([]*models.Article{article}) }, "tenant", tenantID, "article", article.ID)
+
+		c.JSON(200, article)
+	}
+}
+
+type PatchArticleInput struct {
+	Title    *string   `json:"title"`
+	Body     *string   `json:"body"`
+	AuthorID *int      `json:"author_id"`
+	Tags     *[]string `json:"tags"`
+}
+
+func PatchArticle(repository models.ArticleRepository, finder models.AuthorsRepository, tagsRepository models.TagsRepository, enricher *translation.Enricher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		repository := repository.ForTenant(tenantID)
+		finder := finder.ForTenant(tenantID)
+		tagsRepository := tagsRepository.ForTenant(tenantID)
+		enricher := enricher.ForTenant(tenantID)
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid article id"})
+			return
+		}
+
+		var input PatchArticleInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		// title and body are required like for POST and PUT
+		if input.Title != nil && strings.TrimSpace(*input.Title) == "" {
+			c.JSON(400, gin.H{"error": "Title must not be blank"})
+			return
+		}
+		if input.Body != nil && strings.TrimSpace(*input.Body) == "" {
+			c.JSON(400, gin.H{"error": "Body must not be blank"})
+			return
+		}
+
+		article, err := repository.FindById(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(404, gin.H{"error": fmt.Sprintf("Could not find article %d", id)})
+			return
+		}
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to fetch article"})
+			return
+		}
+
+		title, body := article.Title, article.Body
+		if input.Title != nil {
+			title = *input.Title
+		}
+		if input.Body != nil {
+			body = *input.Body
+		}
+
+		author := &models.Author{ID: article.AuthorID, Name: article.Author}
+		if input.AuthorID != nil {
+			author, err = finder.FindAuthorById(*input.AuthorID)
+			if err != nil {
+				c.JSON(400, gin.H{"error": "Author not found"})
+				return
 			}
-			return retry.WithBackoff(context.Background(), operation)
 		}
-		go resync([]*models.Article{article})
 
-		c.JSON(201, article)
+		tags := article.Tags
+		if input.Tags != nil {
+			tags, err = tagsRepository.FindByLabels(*input.Tags)
+			if err != nil {
+				c.JSON(400, gin.H{"error": "Could not find one (or more) tags"})
+				return
+			}
+		}
+
+		article.Update(title, body, author, tags)
+
+		if err := repository.Update(article); err != nil {
+			c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to update article %d", id)})
+			return
+		}
+
+		enrichInBackground(func() error { return enricher.EnrichArticles([]*models.Article{article}) }, "tenant", tenantID, "article", article.ID)
+
+		c.JSON(200, article)
+	}
+}
+
+func DeleteArticle(repository models.ArticleRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		repository := repository.ForTenant(tenantID)
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid article id"})
+			return
+		}
+
+		err = repository.Delete(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(404, gin.H{"error": fmt.Sprintf("Could not find article %d", id)})
+			return
+		}
+		if err != nil {
+			c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to delete article %d", id)})
+			return
+		}
+
+		c.Status(204)
 	}
 }
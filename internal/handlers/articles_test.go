@@ -0,0 +1,84 @@
+package handlers_test
+
+import (
+	"fmt"
+	"mini-search-platform/internal/adapters"
+	"mini-search-platform/internal/database"
+	"mini-search-platform/internal/handlers"
+	"mini-search-platform/internal/middleware"
+	"mini-search-platform/internal/models"
+	"mini-search-platform/internal/translation"
+	"mini-search-platform/pkg/sqlite"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPatchArticle_RejectsBlankTitleAndBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, err := sqlite.Open(sqlite.MemoryDSN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlite.Close(db)
+
+	if err := database.Create(db); err != nil {
+		t.Fatal(err)
+	}
+
+	tenants := adapters.NewSQLliteTenantsRepository(db)
+	tokens := adapters.NewSQLliteAPITokensRepository(db)
+	authors := adapters.NewSQLliteAuthorsRepository(db)
+	articles := adapters.NewSQLliteArticleRepository(db)
+	tags := adapters.NewSQLliteTagsRepository(db)
+	enricher := translation.NewEnricher(nil, articles, adapters.NewSQLliteProductsRepository(db),
+		adapters.NewSQLliteCataloguesRepository(db), adapters.NewSQLliteTranslationsRepository(db))
+
+	tenantID, err := tenants.Save(models.NewTenant("patch"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiToken, plain, err := models.NewAPIToken(tenantID, "ci", []models.Scope{models.ScopeCatalogWrite})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tokens.Save(apiToken); err != nil {
+		t.Fatal(err)
+	}
+	author := models.NewAuthor(0, "Jane Doe")
+	if author.ID, err = authors.ForTenant(tenantID).Save(author); err != nil {
+		t.Fatal(err)
+	}
+	id, err := articles.ForTenant(tenantID).Save(models.NewArticle("Jeans", "Blue jeans", author, []*models.Tag{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	router.PATCH("/articles/:id", middleware.Authenticate(tokens), handlers.PatchArticle(articles, authors, tags, enricher))
+
+	for body, expected := range map[string]int{
+		`{"title": ""}`:      http.StatusBadRequest,
+		`{"body": "  "}`:     http.StatusBadRequest,
+		`{"title": "Slim"}`:  http.StatusOK,
+		`{"author_id": 999}`: http.StatusBadRequest,
+	} {
+		req := httptest.NewRequest("PATCH", fmt.Sprintf("/articles/%d", id), strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+plain)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != expected {
+			t.Errorf("%s: expected status %d, got %d: %s", body, expected, w.Code, w.Body.String())
+		}
+	}
+
+	article, err := articles.ForTenant(tenantID).FindById(id)
+	if err != nil || article.Title != "Slim" || article.Body != "Blue jeans" {
+		t.Errorf("expected only the valid patch to be applied, got %+v, %v", article, err)
+	}
+}
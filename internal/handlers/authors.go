@@ -1,15 +1,16 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"mini-search-platform/internal/middleware"
 	"mini-search-platform/internal/models"
 
 	"github.com/gin-gonic/gin"
 )
 
-type AuthorsRepository interface {
-	Save(*models.Author) (int, error)
-}
-
+// AuthorInput optionally carries the id of the author, which is scoped to
+// the tenant; it is generated if missing.
 type AuthorInput struct {
 	Name     string `json:"name" binding:"required"`
 	AuthorID int    `json:"author_id"`
@@ -26,8 +27,11 @@ type AddAuthorsResponse struct {
 	Failed   []map[string]models.Author `json:"failed"`
 }
 
-func AddAuthors(repository AuthorsRepository) gin.HandlerFunc {
+func AddAuthors(repository models.AuthorsRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		repository := repository.ForTenant(tenantID)
+
 		var inputs []AuthorInput
 		if err := c.ShouldBindJSON(&inputs); err != nil {
 			c.JSON(400, gin.H{"error": err.Error()})
@@ -62,8 +66,11 @@ func AddAuthors(repository AuthorsRepository) gin.HandlerFunc {
 	}
 }
 
-func AddAuthor(repository AuthorsRepository) gin.HandlerFunc {
+func AddAuthor(repository models.AuthorsRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		repository := repository.ForTenant(tenantID)
+
 		var input AuthorInput
 		if err := c.ShouldBindJSON(&input); err != nil {
 			c.JSON(400, gin.H{"error": err.Error()})
@@ -73,6 +80,14 @@ func AddAuthor(repository AuthorsRepository) gin.HandlerFunc {
 		author := models.NewAuthor(input.AuthorID, input.Name)
 
 		lastInsertedId, err := repository.Save(author)
+		if errors.Is(err, models.ErrAlreadyExists) {
+			message := fmt.Sprintf("Author '%s' already exists", input.Name)
+			if input.AuthorID != 0 {
+				message = fmt.Sprintf("Author %d or an author named '%s' already exists", input.AuthorID, input.Name)
+			}
+			c.JSON(409, gin.H{"error": message})
+			return
+		}
 		if err != nil {
 			c.JSON(500, gin.H{"error": "Failed to insert author"})
 			return
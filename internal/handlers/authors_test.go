@@ -0,0 +1,90 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"mini-search-platform/internal/adapters"
+	"mini-search-platform/internal/database"
+	"mini-search-platform/internal/handlers"
+	"mini-search-platform/internal/middleware"
+	"mini-search-platform/internal/models"
+	"mini-search-platform/pkg/sqlite"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAddAuthor_ScopesIdsToTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, err := sqlite.Open(sqlite.MemoryDSN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlite.Close(db)
+
+	if err := database.Create(db); err != nil {
+		t.Fatal(err)
+	}
+
+	tenants := adapters.NewSQLliteTenantsRepository(db)
+	tokens := adapters.NewSQLliteAPITokensRepository(db)
+	authors := adapters.NewSQLliteAuthorsRepository(db)
+
+	router := gin.New()
+	router.POST("/authors", middleware.Authenticate(tokens), handlers.AddAuthor(authors))
+
+	tenantIDs, plains := []int{}, []string{}
+	for _, name := range []string{"authors-a", "authors-b"} {
+		tenantID, err := tenants.Save(models.NewTenant(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		apiToken, plain, err := models.NewAPIToken(tenantID, "ci", []models.Scope{models.ScopeCatalogWrite})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tokens.Save(apiToken); err != nil {
+			t.Fatal(err)
+		}
+		tenantIDs, plains = append(tenantIDs, tenantID), append(plains, plain)
+	}
+
+	post := func(plain, body string) (int, models.Author) {
+		req := httptest.NewRequest("POST", "/authors", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+plain)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var author models.Author
+		if w.Code == http.StatusCreated {
+			if err := json.Unmarshal(w.Body.Bytes(), &author); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return w.Code, author
+	}
+
+	// both tenants ask for the same id
+	for i, name := range []string{"Jane Doe", "John Roe"} {
+		code, author := post(plains[i], fmt.Sprintf(`{"author_id": 1, "name": %q}`, name))
+		if code != http.StatusCreated || author.ID != 1 {
+			t.Fatalf("tenant %d: expected author 1 to be created, got %d, %+v", tenantIDs[i], code, author)
+		}
+	}
+	for i, name := range []string{"Jane Doe", "John Roe"} {
+		if found, err := authors.ForTenant(tenantIDs[i]).FindAuthorById(1); err != nil || found.Name != name {
+			t.Errorf("tenant %d: expected its own author %s, got %v, %v", tenantIDs[i], name, found, err)
+		}
+	}
+
+	if code, _ := post(plains[0], `{"author_id": 1, "name": "Max Roe"}`); code != http.StatusConflict {
+		t.Errorf("expected a taken id to be refused with 409, got %d", code)
+	}
+	if code, author := post(plains[0], `{"name": "Max Roe"}`); code != http.StatusCreated || author.ID != 2 {
+		t.Errorf("expected the next id of the tenant to be generated, got %d, %+v", code, author)
+	}
+}
@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"mini-search-platform/internal/middleware"
+	"mini-search-platform/internal/models"
+	"mini-search-platform/internal/search"
+	"mini-search-platform/internal/translation"
+	"regexp"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// catalogueCodePattern matches marketplace/language codes such as "de-DE"
+// or "uk-en". Codes end up in index names, hence the strict alphabet.
+var catalogueCodePattern = regexp.MustCompile(`^[A-Za-z]{2,3}-[A-Za-z]{2,3}$`)
+
+type CatalogueInput struct {
+	Code     string `json:"code" binding:"required"`
+	Language string `json:"language" binding:"required"`
+	Name     string `json:"name" binding:"required"`
+}
+
+func AddCatalogue(repository models.CataloguesRepository, enricher *translation.Enricher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		repository := repository.ForTenant(tenantID)
+		enricher := enricher.ForTenant(tenantID)
+
+		var input CatalogueInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		if !catalogueCodePattern.MatchString(input.Code) {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("Invalid catalogue code '%s', expected e.g. 'de-DE'", input.Code)})
+			return
+		}
+
+		if _, ok := search.LanguageProfiles[input.Language]; !ok {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("Unsupported catalogue language '%s'", input.Language)})
+			return
+		}
+
+		if _, err := repository.FindByCode(input.Code); err == nil {
+			c.JSON(409, gin.H{"error": fmt.Sprintf("Catalogue '%s' already exists", input.Code)})
+			return
+		}
+
+		catalogue := models.NewCatalogue(input.Code, input.Language, input.Name)
+
+		lastInsertedId, err := repository.Save(catalogue)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to save catalogue"})
+			return
+		}
+
+		catalogue.ID = lastInsertedId
+
+		enrichInBackground(func() error { return enricher.EnrichCatalogue(catalogue) }, "tenant", tenantID, "catalogue", catalogue.Code)
+
+		c.JSON(201, catalogue)
+	}
+}
+
+func ListCatalogues(repository models.CataloguesRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		repository := repository.ForTenant(tenantID)
+
+		catalogues, err := repository.FindAll()
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to fetch catalogues"})
+			return
+		}
+
+		c.JSON(200, catalogues)
+	}
+}
+
+func GetCatalogue(repository models.CataloguesRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		repository := repository.ForTenant(tenantID)
+
+		catalogue, ok := findCatalogue(c, repository, c.Param("code"))
+		if !ok {
+			return
+		}
+
+		c.JSON(200, catalogue)
+	}
+}
+
+type ArticleTranslationInput struct {
+	Title string `json:"title" binding:"required"`
+	Body  string `json:"body" binding:"required"`
+}
+
+type ProductTranslationInput struct {
+	Title string `json:"title" binding:"required"`
+}
+
+// PutArticleTranslation creates or replaces the localized title and body of
+// an article within a catalogue.
+func PutArticleTranslation(cataloguesRepository models.CataloguesRepository, articlesRepository models.ArticleRepository, translationsRepository models.TranslationsRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		cataloguesRepository := cataloguesRepository.ForTenant(tenantID)
+		articlesRepository := articlesRepository.ForTenant(tenantID)
+		translationsRepository := translationsRepository.ForTenant(tenantID)
+
+		catalogue, ok := findCatalogue(c, cataloguesRepository, c.Param("code"))
+		if !ok {
+			return
+		}
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid article id"})
+			return
+		}
+
+		var input ArticleTranslationInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		_, err = articlesRepository.FindById(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(404, gin.H{"error": fmt.Sprintf("Could not find article %d", id)})
+			return
+		}
+		if err != nil {
+			c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to fetch article %d", id)})
+			return
+		}
+
+		translation := models.NewTranslation(catalogue.ID, models.EntityArticle, strconv.Itoa(id), input.Title, input.Body)
+
+		if err := translationsRepository.Save(translation); err != nil {
+			c.JSON(500, gin.H{"error": "Failed to save translation"})
+			return
+		}
+
+		c.JSON(200, translation)
+	}
+}
+
+// PutProductTranslation creates or replaces the localized title of a product
+// within a catalogue.
+func PutProductTranslation(cataloguesRepository models.CataloguesRepository, productsRepository models.ProductRepository, translationsRepository models.TranslationsRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		cataloguesRepository := cataloguesRepository.ForTenant(tenantID)
+		productsRepository := productsRepository.ForTenant(tenantID)
+		translationsRepository := translationsRepository.ForTenant(tenantID)
+
+		catalogue, ok := findCatalogue(c, cataloguesRepository, c.Param("code"))
+		if !ok {
+			return
+		}
+
+		articleID := c.Param("article_id")
+
+		var input ProductTranslationInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		_, err := productsRepository.FindById(articleID)
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(404, gin.H{"error": fmt.Sprintf("Could not find product %s", articleID)})
+			return
+		}
+		if err != nil {
+			c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to fetch product %s", articleID)})
+			return
+		}
+
+		translation := models.NewTranslation(catalogue.ID, models.EntityProduct, articleID, input.Title, "")
+
+		if err := translationsRepository.Save(translation); err != nil {
+			c.JSON(500, gin.H{"error": "Failed to save translation"})
+			return
+		}
+
+		c.JSON(200, translation)
+	}
+}
+
+// GetTranslation returns the translation of the entity type taken from the
+// route, identified by the named route parameter.
+func GetTranslation(cataloguesRepository models.CataloguesRepository, translationsRepository models.TranslationsRepository, entityType, param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		cataloguesRepository := cataloguesRepository.ForTenant(tenantID)
+		translationsRepository := translationsRepository.ForTenant(tenantID)
+
+		catalogue, ok := findCatalogue(c, cataloguesRepository, c.Param("code"))
+		if !ok {
+			return
+		}
+
+		entityID := c.Param(param)
+
+		translation, err := translationsRepository.Find(catalogue.ID, entityType, entityID)
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(404, gin.H{"error": fmt.Sprintf("Could not find %s translation for %s in catalogue '%s'", entityType, entityID, catalogue.Code)})
+			return
+		}
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to fetch translation"})
+			return
+		}
+
+		c.JSON(200, translation)
+	}
+}
+
+type ListTranslationsQueryParams struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending translated"`
+}
+
+// ListTranslations lists the translations of a catalogue by status, pending
+// ones by default, so that unfinished machine translations are visible.
+func ListTranslations(cataloguesRepository models.CataloguesRepository, translationsRepository models.TranslationsRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		cataloguesRepository := cataloguesRepository.ForTenant(tenantID)
+		translationsRepository := translationsRepository.ForTenant(tenantID)
+
+		var params ListTranslationsQueryParams
+		if err := c.ShouldBindQuery(&params); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		if params.Status == "" {
+			params.Status = models.TranslationPending
+		}
+
+		catalogue, ok := findCatalogue(c, cataloguesRepository, c.Param("code"))
+		if !ok {
+			return
+		}
+
+		translations, err := translationsRepository.FindByStatus(catalogue.ID, params.Status)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to fetch translations"})
+			return
+		}
+
+		c.JSON(200, translations)
+	}
+}
+
+// RetryTranslations retries all pending machine translations of a catalogue
+// in the background.
+func RetryTranslations(cataloguesRepository models.CataloguesRepository, enricher *translation.Enricher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		cataloguesRepository := cataloguesRepository.ForTenant(tenantID)
+		enricher := enricher.ForTenant(tenantID)
+
+		catalogue, ok := findCatalogue(c, cataloguesRepository, c.Param("code"))
+		if !ok {
+			return
+		}
+
+		enrichInBackground(func() error { return enricher.RetryPending(catalogue) }, "tenant", tenantID, "catalogue", catalogue.Code)
+
+		c.JSON(202, gin.H{"message": fmt.Sprintf("Retrying pending translations of catalogue '%s'", catalogue.Code)})
+	}
+}
+
+// findCatalogue looks up the catalogue by code and writes the error response
+// if it cannot be found.
+func findCatalogue(c *gin.Context, repository models.CataloguesRepository, code string) (*models.Catalogue, bool) {
+	catalogue, err := repository.FindByCode(code)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(404, gin.H{"error": fmt.Sprintf("Could not find catalogue '%s'", code)})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to fetch catalogue '%s'", code)})
+		return nil, false
+	}
+
+	return catalogue, true
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"log/slog"
+	"mini-search-platform/internal/models"
+)
+
+// enrichInBackground runs enrich, which translates entities for the
+// catalogues of a tenant, without holding up the response. Its failures are
+// logged with the attributes, which name the tenant and the entities.
+func enrichInBackground(enrich func() error, attributes ...interface{}) {
+	go func() {
+		if err := enrich(); err != nil {
+			slog.Error("translation enrichment failed", append(attributes, "error", err)...)
+		}
+	}()
+}
+
+func articleIDs(articles []*models.Article) []int {
+	ids := make([]int, len(articles))
+	for i, article := range articles {
+		ids[i] = article.ID
+	}
+
+	return ids
+}
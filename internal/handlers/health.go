@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"mini-search-platform/internal/search"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Health reports whether the service is up, whether searches are served by
+// the primary search engine or by its fallback and whether the indexes are
+// provisioned yet. Until they are, the service is degraded.
+func Health(engine search.SearchEngine, bootstrap *search.Bootstrap) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		health := search.EngineHealth{Status: search.HealthOK}
+		if reporter, ok := engine.(search.HealthReporter); ok {
+			health = reporter.Health()
+		}
+		status := bootstrap.Status()
+
+		overall := health.Status
+		if status.Status != search.HealthOK {
+			overall = search.HealthDegraded
+		}
+
+		c.JSON(200, gin.H{"status": overall, "search": health, "bootstrap": status})
+	}
+}
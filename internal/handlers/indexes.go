@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mini-search-platform/internal/middleware"
+	"mini-search-platform/internal/models"
+	"mini-search-platform/internal/search"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RebuildIndexQueryParams struct {
+	Catalogue string `form:"catalogue"`
+}
+
+// RebuildIndex starts a full rebuild of the tenant's index in the
+// background. The rebuilt index is swapped in once every document was
+// written; its progress is reported by GetRebuildStatus.
+func RebuildIndex(sync *search.IndexSyncManager, catalogues models.CataloguesRepository, tracker *search.RebuildTracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		catalogues := catalogues.ForTenant(tenantID)
+
+		name := c.Param("name")
+		if !search.IsIndex(name) {
+			c.JSON(404, gin.H{"error": fmt.Sprintf("Could not find index '%s'", name)})
+			return
+		}
+
+		var params RebuildIndexQueryParams
+		if err := c.ShouldBindQuery(&params); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		var catalogue *models.Catalogue
+		if params.Catalogue != "" {
+			var ok bool
+			if catalogue, ok = findCatalogue(c, catalogues, params.Catalogue); !ok {
+				return
+			}
+		}
+
+		status, started := tracker.Start(tenantID, name, params.Catalogue)
+		if !started {
+			c.JSON(409, gin.H{"error": fmt.Sprintf("Index '%s' is already being rebuilt", name), "rebuild": status})
+			return
+		}
+
+		go func() {
+			err := sync.ForTenant(tenantID).Rebuild(name, catalogue, func(indexed, total int) {
+				tracker.Progress(tenantID, name, params.Catalogue, indexed, total)
+			})
+			if err != nil {
+				slog.Error("index rebuild failed", "index", name, "tenant", tenantID, "error", err)
+			}
+			tracker.Finish(tenantID, name, params.Catalogue, err)
+		}()
+
+		c.JSON(202, status)
+	}
+}
+
+// GetRebuildStatus returns the status of the latest rebuild of the index.
+func GetRebuildStatus(tracker *search.RebuildTracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+
+		var params RebuildIndexQueryParams
+		if err := c.ShouldBindQuery(&params); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		name := c.Param("name")
+		status, ok := tracker.Status(tenantID, name, params.Catalogue)
+		if !ok {
+			c.JSON(404, gin.H{"error": fmt.Sprintf("Index '%s' has not been rebuilt", name)})
+			return
+		}
+
+		c.JSON(200, status)
+	}
+}
+
+type SearchSettingsInput struct {
+	Synonyms     map[string][]string `json:"synonyms"`
+	StopWords    []string            `json:"stop_words"`
+	RankingRules []string            `json:"ranking_rules"`
+}
+
+// GetSearchSettings returns the synonyms, stop words and ranking rules of
+// the tenant's index.
+func GetSearchSettings(repository models.SearchSettingsRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		repository := repository.ForTenant(tenantID)
+
+		name := c.Param("name")
+		if !search.IsIndex(name) {
+			c.JSON(404, gin.H{"error": fmt.Sprintf("Could not find index '%s'", name)})
+			return
+		}
+
+		settings, err := repository.FindByIndex(name)
+		if errors.Is(err, sql.ErrNoRows) {
+			settings = models.NewSearchSettings(name)
+		} else if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to fetch search settings"})
+			return
+		}
+
+		c.JSON(200, settings)
+	}
+}
+
+// UpdateSearchSettings replaces the search settings of the tenant's index.
+// They are applied to the index and the localized index of every catalogue
+// through the outbox, and again whenever one of them is rebuilt.
+func UpdateSearchSettings(repository models.SearchSettingsRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		repository := repository.ForTenant(tenantID)
+
+		name := c.Param("name")
+		if !search.IsIndex(name) {
+			c.JSON(404, gin.H{"error": fmt.Sprintf("Could not find index '%s'", name)})
+			return
+		}
+
+		var input SearchSettingsInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		settings := models.NewSearchSettings(name)
+		if input.Synonyms != nil {
+			settings.Synonyms = input.Synonyms
+		}
+		if input.StopWords != nil {
+			settings.StopWords = input.StopWords
+		}
+		if input.RankingRules != nil {
+			settings.RankingRules = input.RankingRules
+		}
+
+		if err := search.IndexSettingsFor(name, nil).NormalizeSearchSettings(settings); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		settings.Touch()
+		if err := repository.Save(settings); err != nil {
+			c.JSON(500, gin.H{"error": "Failed to save search settings"})
+			return
+		}
+
+		c.JSON(200, settings)
+	}
+}
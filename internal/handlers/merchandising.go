@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"mini-search-platform/internal/middleware"
+	"mini-search-platform/internal/models"
+	"mini-search-platform/internal/search"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MerchandisingRuleInput struct {
+	Match    string       `json:"match" binding:"required"`
+	Query    string       `json:"query" binding:"required"`
+	Pinned   []models.Pin `json:"pinned"`
+	Buried   []string     `json:"buried"`
+	StartsAt string       `json:"starts_at"`
+	EndsAt   string       `json:"ends_at"`
+}
+
+// apply copies the input to the rule and validates it against the index.
+func (input MerchandisingRuleInput) apply(rule *models.MerchandisingRule) error {
+	rule.Match = input.Match
+	rule.Query = input.Query
+	rule.Pinned = []models.Pin{}
+	rule.Buried = []string{}
+	rule.StartsAt = input.StartsAt
+	rule.EndsAt = input.EndsAt
+	if input.Pinned != nil {
+		rule.Pinned = input.Pinned
+	}
+	if input.Buried != nil {
+		rule.Buried = input.Buried
+	}
+
+	return search.IndexSettingsFor(rule.Index, nil).NormalizeMerchandisingRule(rule)
+}
+
+func ListMerchandisingRules(repository models.MerchandisingRulesRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		repository := repository.ForTenant(tenantID)
+
+		name := c.Param("name")
+		if !search.IsIndex(name) {
+			c.JSON(404, gin.H{"error": fmt.Sprintf("Could not find index '%s'", name)})
+			return
+		}
+
+		rules, err := repository.FindByIndex(name)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to fetch merchandising rules"})
+			return
+		}
+
+		c.JSON(200, rules)
+	}
+}
+
+// AddMerchandisingRule creates a rule pinning and burying documents of the
+// index, identified by its primary key, for matching queries.
+func AddMerchandisingRule(repository models.MerchandisingRulesRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		repository := repository.ForTenant(tenantID)
+
+		name := c.Param("name")
+		if !search.IsIndex(name) {
+			c.JSON(404, gin.H{"error": fmt.Sprintf("Could not find index '%s'", name)})
+			return
+		}
+
+		var input MerchandisingRuleInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		rule := models.NewMerchandisingRule(name, input.Match, input.Query)
+		if err := input.apply(rule); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		id, err := repository.Save(rule)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to save merchandising rule"})
+			return
+		}
+		rule.ID = id
+
+		c.JSON(201, rule)
+	}
+}
+
+func UpdateMerchandisingRule(repository models.MerchandisingRulesRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		repository := repository.ForTenant(tenantID)
+
+		rule, ok := findMerchandisingRule(c, repository)
+		if !ok {
+			return
+		}
+
+		var input MerchandisingRuleInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := input.apply(rule); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		rule.Touch()
+		if err := repository.Update(rule); err != nil {
+			c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to update merchandising rule %d", rule.ID)})
+			return
+		}
+
+		c.JSON(200, rule)
+	}
+}
+
+func DeleteMerchandisingRule(repository models.MerchandisingRulesRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		repository := repository.ForTenant(tenantID)
+
+		rule, ok := findMerchandisingRule(c, repository)
+		if !ok {
+			return
+		}
+
+		if err := repository.Delete(rule.ID); err != nil {
+			c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to delete merchandising rule %d", rule.ID)})
+			return
+		}
+
+		c.Status(204)
+	}
+}
+
+// findMerchandisingRule loads the rule of the id parameter, responding with
+// 404 unless it belongs to the index of the name parameter.
+func findMerchandisingRule(c *gin.Context, repository models.MerchandisingRulesRepository) (*models.MerchandisingRule, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid merchandising rule id"})
+		return nil, false
+	}
+
+	rule, err := repository.FindById(id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && rule.Index != c.Param("name")) {
+		c.JSON(404, gin.H{"error": fmt.Sprintf("Could not find merchandising rule %d", id)})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch merchandising rule"})
+		return nil, false
+	}
+
+	return rule, true
+}
+
+// merchandising returns the active rules of the index matching the search.
+func merchandising(c *gin.Context, repository models.MerchandisingRulesRepository, index, query string, filter search.Filter) (search.Merchandising, bool) {
+	rules, err := repository.FindActive(index, time.Now())
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch merchandising rules"})
+		return search.Merchandising{}, false
+	}
+
+	return search.MatchMerchandising(rules, query, filter), true
+}
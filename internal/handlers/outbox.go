@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"mini-search-platform/internal/middleware"
+	"mini-search-platform/internal/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ListOutboxQueryParams struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending dead"`
+}
+
+// ListOutboxEntries lists the tenant's index outbox entries by status, dead
+// ones by default, so that lost index updates can be spotted.
+func ListOutboxEntries(repository models.OutboxRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		repository := repository.ForTenant(tenantID)
+
+		var params ListOutboxQueryParams
+		if err := c.ShouldBindQuery(&params); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		if params.Status == "" {
+			params.Status = models.OutboxDead
+		}
+
+		entries, err := repository.FindByStatus(params.Status)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to fetch outbox entries"})
+			return
+		}
+
+		c.JSON(200, entries)
+	}
+}
+
+// RetryOutboxEntry moves a dead outbox entry back to pending.
+func RetryOutboxEntry(repository models.OutboxRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		repository := repository.ForTenant(tenantID)
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid outbox entry id"})
+			return
+		}
+
+		err = repository.Requeue(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(404, gin.H{"error": fmt.Sprintf("Could not find dead outbox entry %d", id)})
+			return
+		}
+		if err != nil {
+			c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to retry outbox entry %d", id)})
+			return
+		}
+
+		c.Status(202)
+	}
+}
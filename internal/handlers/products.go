@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"mini-search-platform/internal/middleware"
+	"mini-search-platform/internal/models"
+	"mini-search-platform/internal/translation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mcuadros/go-defaults"
+)
+
+type ProductInput struct {
+	ArticleID string `json:"article_id" binding:"required"`
+	Title     string `json:"title" binding:"required"`
+	Brand     string `json:"brand" binding:"required"`
+	Category  string `json:"category" binding:"required"`
+}
+
+func AddProduct(repository models.ProductRepository, enricher *translation.Enricher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		repository := repository.ForTenant(tenantID)
+		enricher := enricher.ForTenant(tenantID)
+
+		var input ProductInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		product := models.NewProduct(input.ArticleID, input.Title, input.Brand, input.Category)
+
+		err := repository.Save(product)
+		if errors.Is(err, models.ErrAlreadyExists) {
+			c.JSON(409, gin.H{"error": fmt.Sprintf("Product '%s' already exists", input.ArticleID)})
+			return
+		}
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to save product"})
+			return
+		}
+
+		enrichInBackground(func() error { return enricher.EnrichProducts([]*models.Product{product}) }, "tenant", tenantID, "product", product.ArticleID)
+
+		c.JSON(201, product)
+	}
+}
+
+type ListProductsQueryParams struct {
+	Limit  int `form:"limit" default:"10" binding:"min=1,max=100"`
+	Offset int `form:"offset" default:"0" binding:"min=0"`
+}
+
+type ListProductsResponse struct {
+	Products []*models.Product `json:"products"`
+	Offset   int               `json:"offset"`
+	Limit    int               `json:"limit"`
+	Total    int               `json:"total"`
+}
+
+func ListProducts(repository models.ProductRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		repository := repository.ForTenant(tenantID)
+
+		var params ListProductsQueryParams
+
+		defaults.SetDefaults(&params)
+
+		if err := c.ShouldBindQuery(&params); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		products, err := repository.FindAll(params.Limit, params.Offset)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to fetch products"})
+			return
+		}
+
+		total, err := repository.Count()
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to count products"})
+			return
+		}
+
+		c.JSON(200, ListProductsResponse{
+			Products: products,
+			Offset:   params.Offset,
+			Limit:    params.Limit,
+			Total:    total,
+		})
+	}
+}
+
+func GetProduct(repository models.ProductRepository, variantsRepository models.VariantRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		repository := repository.ForTenant(tenantID)
+		variantsRepository := variantsRepository.ForTenant(tenantID)
+
+		articleID := c.Param("article_id")
+
+		product, err := repository.FindById(articleID)
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(404, gin.H{"error": fmt.Sprintf("Could not find product '%s'", articleID)})
+			return
+		}
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to fetch product"})
+			return
+		}
+
+		product.Variants, err = variantsRepository.FindByArticleId(articleID)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to fetch product variants"})
+			return
+		}
+
+		c.JSON(200, product)
+	}
+}
+
+type PatchProductInput struct {
+	Title    *string `json:"title"`
+	Brand    *string `json:"brand"`
+	Category *string `json:"category"`
+}
+
+func PatchProduct(repository models.ProductRepository, enricher *translation.Enricher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		repository := repository.ForTenant(tenantID)
+		enricher := enricher.ForTenant(tenantID)
+
+		articleID := c.Param("article_id")
+
+		var input PatchProductInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		product, err := repository.FindById(articleID)
+		if err != nil {
+			c.JSON(404, gin.H{"error": fmt.Sprintf("Could not find product '%s'", articleID)})
+			return
+		}
+
+		title, brand, category := product.Title, product.Brand, product.Category
+		if input.Title != nil {
+			title = *input.Title
+		}
+		if input.Brand != nil {
+			brand = *input.Brand
+		}
+		if input.Category != nil {
+			category = *input.Category
+		}
+
+		product.Update(title, brand, category)
+
+		if err := repository.Update(product); err != nil {
+			c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to update product '%s'", articleID)})
+			return
+		}
+
+		enrichInBackground(func() error { return enricher.EnrichProducts([]*models.Product{product}) }, "tenant", tenantID, "product", product.ArticleID)
+
+		c.JSON(200, product)
+	}
+}
+
+func DeleteProduct(repository models.ProductRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		repository := repository.ForTenant(tenantID)
+
+		articleID := c.Param("article_id")
+
+		err := repository.Delete(articleID)
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(404, gin.H{"error": fmt.Sprintf("Could not find product '%s'", articleID)})
+			return
+		}
+		if err != nil {
+			c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to delete product '%s'", articleID)})
+			return
+		}
+
+		c.Status(204)
+	}
+}
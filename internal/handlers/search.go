@@ -1,22 +1,45 @@
 package handlers
 
 import (
+	"mini-search-platform/internal/middleware"
+	"mini-search-platform/internal/models"
 	"mini-search-platform/internal/search"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/mcuadros/go-defaults"
 )
 
+// searchParams are the query parameters of SearchArticles that are not
+// filter fields.
+var searchParams = []string{
+	"q", "limit", "offset", "sort", "facets", "catalogue",
+	"highlight", "crop", "highlight_pre_tag", "highlight_post_tag", "attributes_to_retrieve",
+}
+
 type SearchQueryParams struct {
-	Query  string `form:"q" binding:"required"`
-	Limit  int    `form:"limit" default:"10"`
-	Offset int    `form:"offset" default:"0"`
-	Filter string `form:"filter" default:""`
-	Sort   string `form:"sort" default:"title:asc"`
+	Query                string `form:"q" binding:"required"`
+	Limit                int    `form:"limit" default:"10" binding:"min=1,max=100"`
+	Offset               int    `form:"offset" default:"0" binding:"min=0"`
+	Sort                 string `form:"sort"`
+	Facets               string `form:"facets"`
+	Catalogue            string `form:"catalogue"`
+	Highlight            string `form:"highlight"`
+	Crop                 string `form:"crop"`
+	HighlightPreTag      string `form:"highlight_pre_tag" default:"<mark>"`
+	HighlightPostTag     string `form:"highlight_post_tag" default:"</mark>"`
+	AttributesToRetrieve string `form:"attributes_to_retrieve"`
 }
 
-func SearchArticles(engine search.SearchEngine) gin.HandlerFunc {
+// SearchArticles searches the articles index, applying the active
+// merchandising rules matching the query.
+func SearchArticles(engine search.SearchEngine, cataloguesRepository models.CataloguesRepository, rulesRepository models.MerchandisingRulesRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		engine := engine.ForTenant(tenantID)
+		cataloguesRepository := cataloguesRepository.ForTenant(tenantID)
+		rulesRepository := rulesRepository.ForTenant(tenantID)
+
 		var params SearchQueryParams
 
 		defaults.SetDefaults(&params)
@@ -26,17 +49,223 @@ func SearchArticles(engine search.SearchEngine) gin.HandlerFunc {
 			return
 		}
 
-		articles, err := engine.Search(params.Query, search.SearchOptions{
+		if params.Catalogue != "" {
+			catalogue, ok := findCatalogue(c, cataloguesRepository, params.Catalogue)
+			if !ok {
+				return
+			}
+			engine = engine.ForCatalogue(catalogue)
+		}
+
+		facets, ok := facetNames(c, params.Facets, search.ArticlesIndexSettings)
+		if !ok {
+			return
+		}
+
+		filter, ok := parseFilter(c, search.ArticlesIndexSettings, searchParams...)
+		if !ok {
+			return
+		}
+
+		sort, ok := parseSort(c, params.Sort, search.ArticlesIndexSettings)
+		if !ok {
+			return
+		}
+
+		options := search.SearchOptions{
+			Limit:            params.Limit,
+			Offset:           params.Offset,
+			Filter:           filter,
+			Sort:             sort,
+			Facets:           facets,
+			HighlightPreTag:  params.HighlightPreTag,
+			HighlightPostTag: params.HighlightPostTag,
+		}
+		if !parseFormat(c, params, search.ArticlesIndexSettings, &options) {
+			return
+		}
+
+		merchandising, ok := merchandising(c, rulesRepository, search.ARTICLES_INDEX_NAME, params.Query, filter)
+		if !ok {
+			return
+		}
+
+		articles, err := merchandising.SearchArticles(engine, params.Query, options)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to search articles"})
+			return
+		}
+
+		c.JSON(200, articles)
+	}
+}
+
+var searchProductsParams = []string{"q", "limit", "offset", "sort", "facets", "catalogue", "size", "color", "min_price", "max_price"}
+
+type SearchProductsQueryParams struct {
+	Query     string   `form:"q" binding:"required"`
+	Limit     int      `form:"limit" default:"10" binding:"min=1,max=100"`
+	Offset    int      `form:"offset" default:"0" binding:"min=0"`
+	Sort      string   `form:"sort"`
+	Facets    string   `form:"facets"`
+	Catalogue string   `form:"catalogue"`
+	Size      string   `form:"size"`
+	Color     string   `form:"color"`
+	MinPrice  *float64 `form:"min_price"`
+	MaxPrice  *float64 `form:"max_price"`
+}
+
+// SearchProducts searches the products index and hydrates every hit with its
+// variants from the relational store. Variants not matching the size, color
+// and price parameters are dropped, and so are products left without any.
+// With a catalogue parameter the localized index of that catalogue is
+// searched instead. Active merchandising rules matching the query reorder
+// the products before they are hydrated.
+func SearchProducts(engine search.SearchEngine, cataloguesRepository models.CataloguesRepository, variantsRepository models.VariantRepository, rulesRepository models.MerchandisingRulesRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		engine := engine.ForTenant(tenantID)
+		cataloguesRepository := cataloguesRepository.ForTenant(tenantID)
+		variantsRepository := variantsRepository.ForTenant(tenantID)
+		rulesRepository := rulesRepository.ForTenant(tenantID)
+
+		var params SearchProductsQueryParams
+
+		defaults.SetDefaults(&params)
+
+		if err := c.ShouldBindQuery(&params); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		if params.Catalogue != "" {
+			catalogue, ok := findCatalogue(c, cataloguesRepository, params.Catalogue)
+			if !ok {
+				return
+			}
+			engine = engine.ForCatalogue(catalogue)
+		}
+
+		facets, ok := facetNames(c, params.Facets, search.ProductsIndexSettings)
+		if !ok {
+			return
+		}
+
+		filter, ok := parseFilter(c, search.ProductsIndexSettings, searchProductsParams...)
+		if !ok {
+			return
+		}
+
+		sort, ok := parseSort(c, params.Sort, search.ProductsIndexSettings)
+		if !ok {
+			return
+		}
+
+		merchandising, ok := merchandising(c, rulesRepository, search.PRODUCTS_INDEX_NAME, params.Query, filter)
+		if !ok {
+			return
+		}
+
+		products, err := merchandising.SearchProducts(engine, params.Query, search.SearchOptions{
 			Limit:  params.Limit,
 			Offset: params.Offset,
-			Filter: params.Filter,
-			Sort:   []string{params.Sort},
+			Filter: filter,
+			Sort:   sort,
+			Facets: facets,
 		})
 		if err != nil {
-			c.JSON(500, gin.H{"error": "Failed to search articles"})
+			c.JSON(500, gin.H{"error": "Failed to search products"})
 			return
 		}
 
-		c.JSON(200, articles)
+		articleIDs := make([]string, len(products.Hits))
+		for i, hit := range products.Hits {
+			articleIDs[i] = hit.ArticleID
+		}
+
+		variants, err := variantsRepository.FindByArticleIds(articleIDs)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to fetch product variants"})
+			return
+		}
+
+		c.JSON(200, search.HydrateProductHits(products, variants, search.VariantFilter{
+			Size:     params.Size,
+			Color:    params.Color,
+			MinPrice: params.MinPrice,
+			MaxPrice: params.MaxPrice,
+		}))
+	}
+}
+
+// facetNames splits the comma-separated facets parameter and rejects names
+// the index has no facet for.
+func facetNames(c *gin.Context, param string, settings search.IndexSettings) ([]string, bool) {
+	if param == "" {
+		return nil, true
 	}
+
+	names := strings.Split(param, ",")
+	for i, name := range names {
+		names[i] = strings.TrimSpace(name)
+	}
+
+	if _, err := settings.FacetAttributes(names); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	return names, true
+}
+
+// parseFilter builds the filter from the query parameters other than params,
+// rejecting unknown and non-filterable fields.
+func parseFilter(c *gin.Context, settings search.IndexSettings, params ...string) (search.Filter, bool) {
+	filter, err := settings.ParseFilter(c.Request.URL.Query(), params...)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	return filter, true
+}
+
+// parseSort turns the comma-separated sort parameter into engine sort rules,
+// rejecting keys that are not sortable.
+func parseSort(c *gin.Context, param string, settings search.IndexSettings) ([]string, bool) {
+	sort, err := settings.ParseSort(param)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	return sort, true
+}
+
+// parseFormat sets the highlighting, cropping and attributes to retrieve of
+// the options, rejecting attributes hits do not have.
+func parseFormat(c *gin.Context, params SearchQueryParams, settings search.IndexSettings, options *search.SearchOptions) bool {
+	highlight, err := settings.ParseHighlight(params.Highlight)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return false
+	}
+
+	crop, err := settings.ParseCrop(params.Crop)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return false
+	}
+
+	attributes, err := settings.ParseAttributesToRetrieve(params.AttributesToRetrieve)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return false
+	}
+
+	options.Highlight = highlight
+	options.Crop = crop
+	options.AttributesToRetrieve = attributes
+
+	return true
 }
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"log/slog"
+	"mini-search-platform/internal/middleware"
+	"mini-search-platform/internal/models"
+	"mini-search-platform/internal/search"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mcuadros/go-defaults"
+)
+
+type SuggestQueryParams struct {
+	Query     string `form:"q" binding:"required"`
+	Limit     int    `form:"limit" default:"5" binding:"min=1,max=20"`
+	Catalogue string `form:"catalogue"`
+}
+
+// Suggestions holds the completions of a query by type.
+type Suggestions struct {
+	Query    string               `json:"query"`
+	Articles []*models.Suggestion `json:"articles"`
+	Products []*models.Suggestion `json:"products"`
+	Tags     []*models.Suggestion `json:"tags"`
+	Authors  []*models.Suggestion `json:"authors"`
+	// Degraded is set when some of the types could not be looked up and
+	// are left empty.
+	Degraded bool `json:"degraded,omitempty"`
+}
+
+// Suggest completes the query with up to limit article and product titles,
+// ranked by the search engine, and tag labels and author names, the ones
+// with the most articles first. The types are looked up concurrently, and
+// the ones that fail are left empty unless all of them fail.
+func Suggest(engine search.SearchEngine, cataloguesRepository models.CataloguesRepository, tagsRepository models.TagsRepository, authorsRepository models.AuthorsRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		engine := engine.ForTenant(tenantID)
+		cataloguesRepository := cataloguesRepository.ForTenant(tenantID)
+		tagsRepository := tagsRepository.ForTenant(tenantID)
+		authorsRepository := authorsRepository.ForTenant(tenantID)
+
+		var params SuggestQueryParams
+
+		defaults.SetDefaults(&params)
+
+		if err := c.ShouldBindQuery(&params); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		if params.Catalogue != "" {
+			catalogue, ok := findCatalogue(c, cataloguesRepository, params.Catalogue)
+			if !ok {
+				return
+			}
+			engine = engine.ForCatalogue(catalogue)
+		}
+
+		suggestions := Suggestions{Query: params.Query}
+
+		// the sources are looked up concurrently, each filling its own field
+		lookups := []struct {
+			name   string
+			lookup func() error
+		}{
+			{"articles", func() error {
+				articles, err := engine.Search(params.Query, search.SearchOptions{
+					Limit:                params.Limit,
+					AttributesToRetrieve: []string{"id", "title"},
+				})
+				if err != nil {
+					return err
+				}
+				suggestions.Articles = make([]*models.Suggestion, len(articles.Hits))
+				for i, hit := range articles.Hits {
+					suggestions.Articles[i] = &models.Suggestion{ID: strconv.Itoa(hit.ID), Text: hit.Title}
+				}
+				return nil
+			}},
+			{"products", func() error {
+				products, err := engine.SearchProducts(params.Query, search.SearchOptions{Limit: params.Limit})
+				if err != nil {
+					return err
+				}
+				suggestions.Products = make([]*models.Suggestion, len(products.Hits))
+				for i, hit := range products.Hits {
+					suggestions.Products[i] = &models.Suggestion{ID: hit.ArticleID, Text: hit.Title}
+				}
+				return nil
+			}},
+			{"tags", func() (err error) {
+				suggestions.Tags, err = tagsRepository.FindPopularByPrefix(params.Query, params.Limit)
+				return err
+			}},
+			{"authors", func() (err error) {
+				suggestions.Authors, err = authorsRepository.FindPopularByPrefix(params.Query, params.Limit)
+				return err
+			}},
+		}
+
+		errs := make([]error, len(lookups))
+		var wg sync.WaitGroup
+		for i, source := range lookups {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = source.lookup()
+			}()
+		}
+		wg.Wait()
+
+		// a failed source leaves its suggestions empty, only when every
+		// source fails there is nothing to return
+		failed := 0
+		for i, err := range errs {
+			if err != nil {
+				slog.Error("suggestion lookup failed", "source", lookups[i].name, "tenant", tenantID, "error", err)
+				failed++
+			}
+		}
+		if failed == len(lookups) {
+			c.JSON(500, gin.H{"error": "Failed to suggest"})
+			return
+		}
+		suggestions.Degraded = failed > 0
+		if suggestions.Articles == nil {
+			suggestions.Articles = []*models.Suggestion{}
+		}
+		if suggestions.Products == nil {
+			suggestions.Products = []*models.Suggestion{}
+		}
+		if suggestions.Tags == nil {
+			suggestions.Tags = []*models.Suggestion{}
+		}
+		if suggestions.Authors == nil {
+			suggestions.Authors = []*models.Suggestion{}
+		}
+
+		c.JSON(200, suggestions)
+	}
+}
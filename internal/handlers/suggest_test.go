@@ -0,0 +1,85 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"mini-search-platform/internal/adapters"
+	"mini-search-platform/internal/database"
+	"mini-search-platform/internal/handlers"
+	"mini-search-platform/internal/middleware"
+	"mini-search-platform/internal/models"
+	"mini-search-platform/internal/search"
+	"mini-search-platform/pkg/sqlite"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// downEngine fails every search.
+type downEngine struct {
+	search.SearchEngine
+}
+
+func (e downEngine) ForTenant(int) search.SearchEngine { return e }
+
+func (downEngine) Search(string, search.SearchOptions) (search.SearchResponse, error) {
+	return search.SearchResponse{}, errors.New("engine down")
+}
+
+func (downEngine) SearchProducts(string, search.SearchOptions) (search.ProductSearchResponse, error) {
+	return search.ProductSearchResponse{}, errors.New("engine down")
+}
+
+func TestSuggest_ReturnsPartialSuggestionsWhenTheEngineFails(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, err := sqlite.Open(sqlite.MemoryDSN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlite.Close(db)
+
+	if err := database.Create(db); err != nil {
+		t.Fatal(err)
+	}
+
+	tenants := adapters.NewSQLliteTenantsRepository(db)
+	tokens := adapters.NewSQLliteAPITokensRepository(db)
+	authors := adapters.NewSQLliteAuthorsRepository(db)
+
+	tenantID, err := tenants.Save(models.NewTenant("suggest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiToken, plain, err := models.NewAPIToken(tenantID, "ci", []models.Scope{models.ScopeSearch})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tokens.Save(apiToken); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authors.ForTenant(tenantID).Save(models.NewAuthor(0, "Dennis Ritchie")); err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	router.GET("/suggest", middleware.Authenticate(tokens), handlers.Suggest(downEngine{},
+		adapters.NewSQLliteCataloguesRepository(db), adapters.NewSQLliteTagsRepository(db), authors))
+
+	req := httptest.NewRequest("GET", "/suggest?q=den", nil)
+	req.Header.Set("Authorization", "Bearer "+plain)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var suggestions handlers.Suggestions
+	if w.Code != 200 {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &suggestions); err != nil {
+		t.Fatal(err)
+	}
+	if !suggestions.Degraded || len(suggestions.Articles) != 0 || len(suggestions.Authors) != 1 {
+		t.Errorf("expected degraded suggestions with the author only, got %s", w.Body.String())
+	}
+}
@@ -1,11 +1,9 @@
 package handlers
 
 import (
-	"context"
 	"fmt"
+	"mini-search-platform/internal/middleware"
 	"mini-search-platform/internal/models"
-	"mini-search-platform/internal/search"
-	"mini-search-platform/pkg/retry"
 
 	"github.com/gin-gonic/gin"
 )
@@ -27,6 +25,9 @@ type AddTagsInBatchResponse struct {
 
 func AddTagsInBatch(repository models.TagsRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		repository := repository.ForTenant(tenantID)
+
 		var inputs []TagInput
 		if err := c.ShouldBindJSON(&inputs); err != nil {
 			c.JSON(400, gin.H{"error": err.Error()})
@@ -63,6 +64,8 @@ func AddTagsInBatch(repository models.TagsRepository) gin.HandlerFunc {
 
 func AddTag(repository models.TagsRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		repository := repository.ForTenant(tenantID)
 
 		var input TagInput
 		if err := c.ShouldBindJSON(&input); err != nil {
@@ -88,8 +91,11 @@ type UpdateTagInput struct {
 	NewLabel string `json:"label" binding:"required"`
 }
 
-func UpdateTagWithLabel(repository models.TagsRepository, sync *search.IndexSyncManager) gin.HandlerFunc {
+func UpdateTagWithLabel(repository models.TagsRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		repository := repository.ForTenant(tenantID)
+
 		label := c.Param("label")
 
 		var input UpdateTagInput
@@ -114,20 +120,15 @@ func UpdateTagWithLabel(repository models.TagsRepository, sync *search.IndexSync
 
 		retrieved, _ := repository.FindById(lastInsertedId)
 
-		resync := func(tagToSync *models.Tag) error {
-			operation := func() error {
-				return sync.SyncAfterTagsChanged(tagToSync)
-			}
-			return retry.WithBackoff(context.Background(), operation)
-		}
-		go resync(retrieved)
-
 		c.JSON(200, retrieved)
 	}
 }
 
 func ListAllTags(repository models.TagsRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		repository := repository.ForTenant(tenantID)
+
 		tags, err := repository.FindAll()
 		if err != nil {
 			c.JSON(500, gin.H{"error": "Failed to fetch tags"})
@@ -140,6 +141,9 @@ func ListAllTags(repository models.TagsRepository) gin.HandlerFunc {
 
 func GetTagByLabel(repository models.TagsRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		repository := repository.ForTenant(tenantID)
+
 		label := c.Param("label")
 		tag, err := repository.FindByLabel(label)
 		if err != nil {
@@ -153,6 +157,10 @@ func GetTagByLabel(repository models.TagsRepository) gin.HandlerFunc {
 
 func FindArticlesByLabels(articlesRepository models.ArticleRepository, tagsRepository models.TagsRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		articlesRepository := articlesRepository.ForTenant(tenantID)
+		tagsRepository := tagsRepository.ForTenant(tenantID)
+
 		label := c.Param("label")
 		if label == "" {
 			c.JSON(400, gin.H{"error": "Label is required"})
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"mini-search-platform/internal/middleware"
+	"mini-search-platform/internal/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TenantInput struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type TokenInput struct {
+	Name   string   `json:"name" binding:"required"`
+	Scopes []string `json:"scopes" binding:"required,min=1"`
+}
+
+// CreateTokenResponse is the only place where the plain API token is ever
+// exposed; afterwards only its hash is known to the platform.
+type CreateTokenResponse struct {
+	*models.APIToken
+	Token string `json:"token"`
+}
+
+type AddTenantResponse struct {
+	Tenant *models.Tenant       `json:"tenant"`
+	Token  *CreateTokenResponse `json:"token"`
+}
+
+func AddTenant(repository models.TenantsRepository, tokensRepository models.APITokensRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input TenantInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		tenant := models.NewTenant(input.Name)
+
+		lastInsertedId, err := repository.Save(tenant)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to create tenant"})
+			return
+		}
+
+		tenant.ID = lastInsertedId
+
+		apiToken, plain, err := models.NewAPIToken(tenant.ID, "default", []models.Scope{models.ScopeAdmin})
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to generate API token"})
+			return
+		}
+
+		apiToken.ID, err = tokensRepository.Save(apiToken)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to save API token"})
+			return
+		}
+
+		c.JSON(201, AddTenantResponse{
+			Tenant: tenant,
+			Token:  &CreateTokenResponse{APIToken: apiToken, Token: plain},
+		})
+	}
+}
+
+func AddToken(repository models.APITokensRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+
+		var input TokenInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		scopes, err := models.ParseScopes(input.Scopes)
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		apiToken, plain, err := models.NewAPIToken(tenantID, input.Name, scopes)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to generate API token"})
+			return
+		}
+
+		apiToken.ID, err = repository.Save(apiToken)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to save API token"})
+			return
+		}
+
+		c.JSON(201, CreateTokenResponse{APIToken: apiToken, Token: plain})
+	}
+}
+
+func ListTokens(repository models.APITokensRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokens, err := repository.FindByTenant(middleware.TenantID(c))
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to fetch API tokens"})
+			return
+		}
+
+		c.JSON(200, tokens)
+	}
+}
+
+func DeleteToken(repository models.APITokensRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid token id"})
+			return
+		}
+
+		err = repository.Delete(middleware.TenantID(c), id)
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(404, gin.H{"error": fmt.Sprintf("Could not find token %d", id)})
+			return
+		}
+		if err != nil {
+			c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to delete token %d", id)})
+			return
+		}
+
+		c.Status(204)
+	}
+}
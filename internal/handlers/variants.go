@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"mini-search-platform/internal/middleware"
+	"mini-search-platform/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type VariantInput struct {
+	VariantID    string  `json:"variant_id" binding:"required"`
+	Size         string  `json:"size" binding:"required"`
+	Color        string  `json:"color" binding:"required"`
+	Price        float64 `json:"price" binding:"gte=0"`
+	Availability *bool   `json:"availability" binding:"required"`
+}
+
+func AddVariant(productsRepository models.ProductRepository, repository models.VariantRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		productsRepository := productsRepository.ForTenant(tenantID)
+		repository := repository.ForTenant(tenantID)
+
+		articleID := c.Param("article_id")
+
+		var input VariantInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		if _, err := productsRepository.FindById(articleID); err != nil {
+			c.JSON(404, gin.H{"error": fmt.Sprintf("Could not find product '%s'", articleID)})
+			return
+		}
+
+		variant := models.NewVariant(input.VariantID, articleID, input.Size, input.Color, input.Price, *input.Availability)
+
+		err := repository.Save(variant)
+		if errors.Is(err, models.ErrAlreadyExists) {
+			c.JSON(409, gin.H{"error": fmt.Sprintf("Variant '%s' already exists", input.VariantID)})
+			return
+		}
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to save variant"})
+			return
+		}
+
+		c.JSON(201, variant)
+	}
+}
+
+func ListVariants(productsRepository models.ProductRepository, repository models.VariantRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		productsRepository := productsRepository.ForTenant(tenantID)
+		repository := repository.ForTenant(tenantID)
+
+		articleID := c.Param("article_id")
+
+		if _, err := productsRepository.FindById(articleID); err != nil {
+			c.JSON(404, gin.H{"error": fmt.Sprintf("Could not find product '%s'", articleID)})
+			return
+		}
+
+		variants, err := repository.FindByArticleId(articleID)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to fetch variants"})
+			return
+		}
+
+		c.JSON(200, variants)
+	}
+}
+
+func DeleteVariant(productsRepository models.ProductRepository, repository models.VariantRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		productsRepository := productsRepository.ForTenant(tenantID)
+		repository := repository.ForTenant(tenantID)
+
+		articleID := c.Param("article_id")
+		variantID := c.Param("variant_id")
+
+		if _, err := productsRepository.FindById(articleID); err != nil {
+			c.JSON(404, gin.H{"error": fmt.Sprintf("Could not find product '%s'", articleID)})
+			return
+		}
+
+		variant, err := repository.FindById(variantID)
+		if err != nil || variant.ArticleID != articleID {
+			c.JSON(404, gin.H{"error": fmt.Sprintf("Could not find variant '%s'", variantID)})
+			return
+		}
+
+		err = repository.Delete(variantID)
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(404, gin.H{"error": fmt.Sprintf("Could not find variant '%s'", variantID)})
+			return
+		}
+		if err != nil {
+			c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to delete variant '%s'", variantID)})
+			return
+		}
+
+		c.Status(204)
+	}
+}
+
+type PatchVariantInput struct {
+	Size         *string  `json:"size"`
+	Color        *string  `json:"color"`
+	Price        *float64 `json:"price" binding:"omitempty,gte=0"`
+	Availability *bool    `json:"availability"`
+}
+
+// UpdateVariant is the fast path for price and stock updates: the variant is
+// written to the relational store right away, while its product is only
+// reindexed if the change affects the product's facet data.
+func UpdateVariant(repository models.VariantRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := middleware.TenantID(c)
+		repository := repository.ForTenant(tenantID)
+
+		variantID := c.Param("variant_id")
+
+		var input PatchVariantInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		variant, err := repository.FindById(variantID)
+		if err != nil {
+			c.JSON(404, gin.H{"error": fmt.Sprintf("Could not find variant '%s'", variantID)})
+			return
+		}
+
+		size, color, price, availability := variant.Size, variant.Color, variant.Price, variant.Availability
+		if input.Size != nil {
+			size = *input.Size
+		}
+		if input.Color != nil {
+			color = *input.Color
+		}
+		if input.Price != nil {
+			price = *input.Price
+		}
+		if input.Availability != nil {
+			availability = *input.Availability
+		}
+
+		variant.Update(size, color, price, availability)
+
+		if err := repository.Update(variant); err != nil {
+			c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to update variant '%s'", variantID)})
+			return
+		}
+
+		c.JSON(200, variant)
+	}
+}
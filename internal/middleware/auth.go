@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"mini-search-platform/internal/models"
+	"mini-search-platform/pkg/token"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	tenantIDKey = "tenant_id"
+	apiTokenKey = "api_token"
+)
+
+// Authenticate resolves the tenant from the bearer token of the request.
+// Requests without a known token are rejected before reaching the handler.
+func Authenticate(tokens models.APITokensRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		plain, ok := bearerToken(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "Missing API token",
+			})
+			return
+		}
+
+		apiToken, err := tokens.FindByHash(token.Hash(plain))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid API token",
+			})
+			return
+		}
+		if apiToken.Expired(time.Now()) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "Expired API token",
+			})
+			return
+		}
+
+		c.Set(tenantIDKey, apiToken.TenantID)
+		c.Set(apiTokenKey, apiToken)
+		c.Next()
+	}
+}
+
+// RequireScope rejects requests whose API token does not grant the scope.
+// It must run after Authenticate.
+func RequireScope(scope models.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		apiToken := APIToken(c)
+		if apiToken == nil || !apiToken.HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": fmt.Sprintf("API token is missing the required scope '%s'", scope),
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireAdminKey protects platform level routes, such as creating tenants,
+// with a static key. An empty key disables the routes entirely.
+func RequireAdminKey(key string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		plain, ok := bearerToken(c)
+		if key == "" || !ok || subtle.ConstantTimeCompare([]byte(plain), []byte(key)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid admin key",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// TenantID returns the tenant resolved by Authenticate. It returns 0, which
+// never matches any tenant, when the request was not authenticated.
+func TenantID(c *gin.Context) int {
+	return c.GetInt(tenantIDKey)
+}
+
+// APIToken returns the token the request was authenticated with.
+func APIToken(c *gin.Context) *models.APIToken {
+	apiToken, _ := c.Get(apiTokenKey)
+	t, _ := apiToken.(*models.APIToken)
+	return t
+}
+
+func bearerToken(c *gin.Context) (string, bool) {
+	header := c.GetHeader("Authorization")
+	plain, found := strings.CutPrefix(header, "Bearer ")
+	if !found || plain == "" {
+		return "", false
+	}
+
+	return plain, true
+}
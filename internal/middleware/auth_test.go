@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"database/sql"
+	"mini-search-platform/internal/models"
+	"mini-search-platform/pkg/token"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakeTokensRepository struct {
+	models.APITokensRepository
+	tokens map[string]*models.APIToken
+}
+
+func (r *fakeTokensRepository) FindByHash(hash string) (*models.APIToken, error) {
+	apiToken, ok := r.tokens[hash]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return apiToken, nil
+}
+
+func newAuthRouter(plain string, tenantID int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	tokens := &fakeTokensRepository{tokens: map[string]*models.APIToken{
+		token.Hash(plain):         {ID: 1, TenantID: tenantID},
+		token.Hash("msp_expired"): {ID: 2, TenantID: tenantID, ExpiresAt: "2020-01-01T00:00:00Z"},
+	}}
+
+	router := gin.New()
+	router.GET("/test", Authenticate(tokens), func(c *gin.Context) {
+		c.JSON(200, gin.H{"tenant_id": TenantID(c)})
+	})
+	return router
+}
+
+func TestAuthenticate_RejectsMissingUnknownAndExpiredTokens(t *testing.T) {
+	router := newAuthRouter("msp_valid", 7)
+
+	for _, header := range []string{"", "Bearer ", "Basic msp_valid", "Bearer msp_unknown", "Bearer msp_expired"} {
+		req := httptest.NewRequest("GET", "/test", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Authorization %q: expected status 401, got %d", header, w.Code)
+		}
+	}
+}
+
+func TestAuthenticate_ResolvesTenantFromToken(t *testing.T) {
+	router := newAuthRouter("msp_valid", 7)
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("Authorization", "Bearer msp_valid")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if w.Body.String() != `{"tenant_id":7}` {
+		t.Errorf("Expected tenant 7 to be resolved, got %s", w.Body.String())
+	}
+}
+
+func TestRequireAdminKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		key    string
+		header string
+		status int
+	}{
+		{"secret", "Bearer secret", http.StatusOK},
+		{"secret", "Bearer wrong", http.StatusUnauthorized},
+		{"", "Bearer ", http.StatusUnauthorized},
+	}
+
+	for _, tc := range cases {
+		router := gin.New()
+		router.POST("/tenants", RequireAdminKey(tc.key), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		req := httptest.NewRequest("POST", "/tenants", nil)
+		req.Header.Set("Authorization", tc.header)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tc.status {
+			t.Errorf("key %q, header %q: expected status %d, got %d", tc.key, tc.header, tc.status, w.Code)
+		}
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tokens := &fakeTokensRepository{tokens: map[string]*models.APIToken{
+		token.Hash("msp_storefront"): {ID: 1, TenantID: 1, Scopes: []models.Scope{models.ScopeSearch}},
+		token.Hash("msp_pim"):        {ID: 2, TenantID: 1, Scopes: []models.Scope{models.ScopeCatalogRead, models.ScopeCatalogWrite}},
+		token.Hash("msp_admin"):      {ID: 3, TenantID: 1, Scopes: []models.Scope{models.ScopeAdmin}},
+	}}
+
+	router := gin.New()
+	api := router.Group("/", Authenticate(tokens))
+	api.GET("/search", RequireScope(models.ScopeSearch), func(c *gin.Context) { c.Status(http.StatusOK) })
+	api.POST("/articles", RequireScope(models.ScopeCatalogWrite), func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	cases := []struct {
+		token  string
+		method string
+		path   string
+		status int
+	}{
+		{"msp_storefront", "GET", "/search", http.StatusOK},
+		{"msp_storefront", "POST", "/articles", http.StatusForbidden},
+		{"msp_pim", "GET", "/search", http.StatusForbidden},
+		{"msp_pim", "POST", "/articles", http.StatusCreated},
+		{"msp_admin", "GET", "/search", http.StatusOK},
+		{"msp_admin", "POST", "/articles", http.StatusCreated},
+	}
+
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		req.Header.Set("Authorization", "Bearer "+tc.token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tc.status {
+			t.Errorf("%s %s with %s: expected status %d, got %d", tc.method, tc.path, tc.token, tc.status, w.Code)
+		}
+		if w.Code == http.StatusForbidden && w.Body.String() == "" {
+			t.Errorf("%s %s with %s: expected a JSON error body", tc.method, tc.path, tc.token)
+		}
+	}
+}
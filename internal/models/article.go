@@ -23,7 +23,24 @@ func NewArticle(title, body string, author *Author, tags []*Tag) *Article {
 	}
 }
 
+func (a *Article) Update(title, body string, author *Author, tags []*Tag) {
+	a.Title = title
+	a.Body = body
+	a.Author = author.Name
+	a.AuthorID = author.ID
+	a.Tags = tags
+}
+
 type ArticleRepository interface {
 	Save(*Article) (int, error)
+	Update(*Article) error
+	Delete(id int) error
+	FindById(id int) (*Article, error)
+	FindAll(limit, offset int) ([]*Article, error)
+	// FindAfter returns the first articles with an id greater than afterID,
+	// ordered by id.
+	FindAfter(afterID, limit int) ([]*Article, error)
+	Count() (int, error)
 	FindByTag(tags *Tag) ([]*Article, error)
+	ForTenant(tenantID int) ArticleRepository
 }
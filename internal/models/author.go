@@ -15,3 +15,12 @@ func NewAuthor(AuthorId int, Name string) *Author {
 		CreatedAt: time.Now().Format(time.RFC3339),
 	}
 }
+
+type AuthorsRepository interface {
+	Save(*Author) (int, error)
+	FindAuthorById(id int) (*Author, error)
+	// FindPopularByPrefix suggests the names having a word starting with
+	// the prefix, the ones with the most articles first.
+	FindPopularByPrefix(prefix string, limit int) ([]*Suggestion, error)
+	ForTenant(tenantID int) AuthorsRepository
+}
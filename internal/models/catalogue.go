@@ -0,0 +1,105 @@
+package models
+
+import "time"
+
+// Catalogue is a marketplace and/or language specific view on the tenant's
+// articles and products, e.g. "de-DE" or "uk-en".
+type Catalogue struct {
+	ID        int    `json:"id"`
+	Code      string `json:"code"`
+	Language  string `json:"language"`
+	Name      string `json:"name"`
+	CreatedAt string `json:"created_at"`
+}
+
+func NewCatalogue(code, language, name string) *Catalogue {
+	return &Catalogue{
+		Code:      code,
+		Language:  language,
+		Name:      name,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+}
+
+type CataloguesRepository interface {
+	Save(*Catalogue) (int, error)
+	FindById(id int) (*Catalogue, error)
+	FindByCode(code string) (*Catalogue, error)
+	FindAll() ([]*Catalogue, error)
+	ForTenant(tenantID int) CataloguesRepository
+}
+
+const (
+	EntityArticle = "article"
+	EntityProduct = "product"
+)
+
+const (
+	// TranslationManual is entered through the API and never overwritten by
+	// machine translations.
+	TranslationManual = "manual"
+	// TranslationMachine is produced by the translation pipeline.
+	TranslationMachine = "machine"
+)
+
+const (
+	TranslationPending    = "pending"
+	TranslationTranslated = "translated"
+)
+
+// Translation holds the localized content of an article or a product within
+// a catalogue. Products only have a localized title. Machine translations
+// stay pending, with their attempts and last error, until the translation
+// provider succeeds.
+type Translation struct {
+	CatalogueID int    `json:"catalogue_id"`
+	EntityType  string `json:"entity_type"`
+	EntityID    string `json:"entity_id"`
+	Title       string `json:"title"`
+	Body        string `json:"body,omitempty"`
+	Source      string `json:"source"`
+	Status      string `json:"status"`
+	Attempts    int    `json:"attempts"`
+	LastError   string `json:"last_error,omitempty"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+func NewTranslation(catalogueID int, entityType, entityID, title, body string) *Translation {
+	return &Translation{
+		CatalogueID: catalogueID,
+		EntityType:  entityType,
+		EntityID:    entityID,
+		Title:       title,
+		Body:        body,
+		Source:      TranslationManual,
+		Status:      TranslationTranslated,
+		UpdatedAt:   time.Now().Format(time.RFC3339),
+	}
+}
+
+func NewPendingTranslation(catalogueID int, entityType, entityID string) *Translation {
+	return &Translation{
+		CatalogueID: catalogueID,
+		EntityType:  entityType,
+		EntityID:    entityID,
+		Source:      TranslationMachine,
+		Status:      TranslationPending,
+		UpdatedAt:   time.Now().Format(time.RFC3339),
+	}
+}
+
+// IsTranslated reports whether the translation can be served in place of
+// the original content.
+func (t *Translation) IsTranslated() bool {
+	return t != nil && t.Status == TranslationTranslated
+}
+
+type TranslationsRepository interface {
+	// Save creates or replaces a translation. Machine translations never
+	// replace manual ones. Finished translations are queued for indexing.
+	Save(*Translation) error
+	Find(catalogueID int, entityType, entityID string) (*Translation, error)
+	FindByEntities(catalogueID int, entityType string, entityIDs []string) (map[string]*Translation, error)
+	FindByStatus(catalogueID int, status string) ([]*Translation, error)
+	ForTenant(tenantID int) TranslationsRepository
+}
@@ -0,0 +1,7 @@
+package models
+
+import "errors"
+
+// ErrAlreadyExists is returned by repositories saving an entity whose id is
+// taken already.
+var ErrAlreadyExists = errors.New("already exists")
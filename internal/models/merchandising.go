@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+const (
+	// MatchExact applies a rule to queries equal to its query.
+	MatchExact = "exact"
+	// MatchContains applies a rule to queries containing its query as
+	// whole words.
+	MatchContains = "contains"
+	// MatchTag applies a rule to article searches filtered by its query as
+	// tag label.
+	MatchTag = "tag"
+)
+
+// MerchandisingRule pins documents of an index (articles or products) to
+// fixed positions and buries others at the end of the results of matching
+// queries. Documents are identified by the primary key of the index.
+type MerchandisingRule struct {
+	ID     int      `json:"id"`
+	Index  string   `json:"index"`
+	Match  string   `json:"match"`
+	Query  string   `json:"query"`
+	Pinned []Pin    `json:"pinned"`
+	Buried []string `json:"buried"`
+	// StartsAt and EndsAt bound the campaign of the rule as RFC 3339 UTC
+	// times; empty ones leave it open.
+	StartsAt  string `json:"starts_at,omitempty"`
+	EndsAt    string `json:"ends_at,omitempty"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// Pin places a document at a position of the results, starting at 1.
+type Pin struct {
+	ID       string `json:"id"`
+	Position int    `json:"position"`
+}
+
+func NewMerchandisingRule(index, match, query string) *MerchandisingRule {
+	now := time.Now().UTC().Format(time.RFC3339)
+	return &MerchandisingRule{
+		Index:     index,
+		Match:     match,
+		Query:     query,
+		Pinned:    []Pin{},
+		Buried:    []string{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func (r *MerchandisingRule) Touch() {
+	r.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+}
+
+type MerchandisingRulesRepository interface {
+	Save(*MerchandisingRule) (int, error)
+	Update(*MerchandisingRule) error
+	Delete(id int) error
+	FindById(id int) (*MerchandisingRule, error)
+	// FindByIndex returns the rules of the index, oldest first.
+	FindByIndex(index string) ([]*MerchandisingRule, error)
+	// FindActive returns the rules of the index whose campaign runs at the
+	// given time, oldest first.
+	FindActive(index string, at time.Time) ([]*MerchandisingRule, error)
+	ForTenant(tenantID int) MerchandisingRulesRepository
+}
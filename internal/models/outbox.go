@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// Operations recorded in the index outbox. Entries only carry identifiers;
+// the worker loads the current state when it processes them.
+const (
+	OutboxTenantCreated             = "tenant.created"
+	OutboxArticlesChanged           = "articles.changed"
+	OutboxArticlesDeleted           = "articles.deleted"
+	OutboxTagChanged                = "tag.changed"
+	OutboxProductsChanged           = "products.changed"
+	OutboxProductsDeleted           = "products.deleted"
+	OutboxProductFacetsChanged      = "product_facets.changed"
+	OutboxCatalogueCreated          = "catalogue.created"
+	OutboxArticleTranslationChanged = "article_translation.changed"
+	OutboxProductTranslationChanged = "product_translation.changed"
+	OutboxSearchSettingsChanged     = "search_settings.changed"
+)
+
+const (
+	OutboxPending = "pending"
+	// OutboxDead marks entries that failed too often and are no longer
+	// retried automatically.
+	OutboxDead = "dead"
+)
+
+// OutboxEntry is a pending index update, written in the same transaction as
+// the change it originates from.
+type OutboxEntry struct {
+	ID          int      `json:"id"`
+	TenantID    int      `json:"-"`
+	Operation   string   `json:"operation"`
+	CatalogueID int      `json:"catalogue_id,omitempty"`
+	EntityIDs   []string `json:"entity_ids"`
+	Status      string   `json:"status"`
+	Attempts    int      `json:"attempts"`
+	LastError   string   `json:"last_error,omitempty"`
+	AvailableAt string   `json:"available_at"`
+	CreatedAt   string   `json:"created_at"`
+}
+
+func NewOutboxEntry(operation string, entityIDs ...string) *OutboxEntry {
+	now := time.Now().UTC()
+	return &OutboxEntry{
+		Operation:   operation,
+		EntityIDs:   entityIDs,
+		Status:      OutboxPending,
+		AvailableAt: now.Format(time.RFC3339),
+		CreatedAt:   now.Format(time.RFC3339),
+	}
+}
+
+type OutboxRepository interface {
+	// FindDue returns pending entries of all tenants that are due at the given
+	// time, oldest first.
+	FindDue(now time.Time, limit int) ([]*OutboxEntry, error)
+	// Complete removes a processed entry.
+	Complete(id int) error
+	// Fail records a failed attempt and either schedules the next one or
+	// moves the entry to the dead-letter state.
+	Fail(entry *OutboxEntry, lastError string, retryAt time.Time, dead bool) error
+	FindByStatus(status string) ([]*OutboxEntry, error)
+	// Requeue moves a dead entry back to pending with its attempts reset.
+	Requeue(id int) error
+	ForTenant(tenantID int) OutboxRepository
+}
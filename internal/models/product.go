@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+type Product struct {
+	ArticleID string     `json:"article_id"`
+	Title     string     `json:"title"`
+	Brand     string     `json:"brand"`
+	Category  string     `json:"category"`
+	CreatedAt string     `json:"created_at"`
+	UpdatedAt string     `json:"updated_at"`
+	Variants  []*Variant `json:"variants,omitempty"`
+	// IndexedFacetVersion is bumped whenever the facet data the product is
+	// indexed with is saved, see ProductRepository.SaveIndexedFacets.
+	IndexedFacetVersion int `json:"-"`
+}
+
+func NewProduct(articleID, title, brand, category string) *Product {
+	return &Product{
+		ArticleID: articleID,
+		Title:     title,
+		Brand:     brand,
+		Category:  category,
+		CreatedAt: time.Now().Format(time.RFC3339),
+		UpdatedAt: time.Now().Format(time.RFC3339),
+	}
+}
+
+func (p *Product) Update(title, brand, category string) {
+	p.Title = title
+	p.Brand = brand
+	p.Category = category
+	p.UpdatedAt = time.Now().Format(time.RFC3339)
+}
+
+type ProductRepository interface {
+	Save(*Product) error
+	Update(*Product) error
+	Delete(articleID string) error
+	FindById(articleID string) (*Product, error)
+	FindAll(limit, offset int) ([]*Product, error)
+	// FindAfter returns the first products with an article id greater than
+	// afterArticleID, ordered by article id.
+	FindAfter(afterArticleID string, limit int) ([]*Product, error)
+	Count() (int, error)
+	// FindIndexedFacets returns the facet data the product was last indexed
+	// with, nil if it never was, and its version.
+	FindIndexedFacets(articleID string) (*FacetData, int, error)
+	// SaveIndexedFacets records the facet data the product was indexed with
+	// unless another sync saved it since the version was read. It reports
+	// whether it did.
+	SaveIndexedFacets(articleID string, facets FacetData, version int) (bool, error)
+	// ClearIndexedFacets forgets the facet data every product of the tenant
+	// was indexed with, so that the next sync of a product reindexes it.
+	ClearIndexedFacets() error
+	ForTenant(tenantID int) ProductRepository
+}
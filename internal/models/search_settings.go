@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// SearchSettings tune the relevance of one of the tenant's indexes, named
+// after the index (articles or products). They apply to the localized
+// indexes of the tenant's catalogues as well.
+type SearchSettings struct {
+	Index string `json:"index"`
+	// Synonyms maps a word or phrase to the ones it also matches. Synonyms
+	// are one-way: "jeans" -> "denim" does not make "denim" match "jeans".
+	Synonyms map[string][]string `json:"synonyms"`
+	// StopWords are ignored in queries, in addition to the stop words of
+	// the catalogue language.
+	StopWords []string `json:"stop_words"`
+	// RankingRules replace the default ranking rules of the engine if set.
+	RankingRules []string `json:"ranking_rules"`
+	UpdatedAt    string   `json:"updated_at,omitempty"`
+}
+
+func NewSearchSettings(index string) *SearchSettings {
+	return &SearchSettings{
+		Index:        index,
+		Synonyms:     map[string][]string{},
+		StopWords:    []string{},
+		RankingRules: []string{},
+	}
+}
+
+func (s *SearchSettings) Touch() {
+	s.UpdatedAt = time.Now().Format(time.RFC3339)
+}
+
+type SearchSettingsRepository interface {
+	// FindByIndex returns sql.ErrNoRows if the index has the default
+	// settings.
+	FindByIndex(index string) (*SearchSettings, error)
+	// Save replaces the settings of the index and enqueues applying them
+	// to the search engine.
+	Save(*SearchSettings) error
+	ForTenant(tenantID int) SearchSettingsRepository
+}
@@ -0,0 +1,10 @@
+package models
+
+// Suggestion completes a search query.
+type Suggestion struct {
+	// ID identifies the suggested article or product.
+	ID   string `json:"id,omitempty"`
+	Text string `json:"text"`
+	// Count is the number of articles of the suggested tag or author.
+	Count int `json:"count,omitempty"`
+}
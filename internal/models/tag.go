@@ -28,4 +28,8 @@ type TagsRepository interface {
 	FindByLabel(label string) (*Tag, error)
 	FindByLabels(labels []string) ([]*Tag, error)
 	FindAll() ([]*Tag, error)
+	// FindPopularByPrefix suggests the labels having a word starting with
+	// the prefix, the ones with the most articles first.
+	FindPopularByPrefix(prefix string, limit int) ([]*Suggestion, error)
+	ForTenant(tenantID int) TagsRepository
 }
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+type Tenant struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	CreatedAt string `json:"created_at"`
+}
+
+func NewTenant(name string) *Tenant {
+	return &Tenant{
+		Name:      name,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+}
+
+type TenantsRepository interface {
+	Save(*Tenant) (int, error)
+	FindById(id int) (*Tenant, error)
+	FindAll() ([]*Tenant, error)
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"fmt"
+	"mini-search-platform/pkg/token"
+	"time"
+)
+
+// Scope is a permission granted to an API token.
+type Scope string
+
+const (
+	ScopeCatalogRead  Scope = "catalog:read"
+	ScopeCatalogWrite Scope = "catalog:write"
+	ScopeSearch       Scope = "search"
+	// ScopeAdmin grants every other scope and the management of tokens.
+	ScopeAdmin Scope = "admin"
+)
+
+var Scopes = []Scope{ScopeCatalogRead, ScopeCatalogWrite, ScopeSearch, ScopeAdmin}
+
+func ParseScopes(values []string) ([]Scope, error) {
+	scopes := make([]Scope, 0, len(values))
+	for _, value := range values {
+		scope := Scope(value)
+		if !scope.valid() {
+			return nil, fmt.Errorf("unknown scope '%s'", value)
+		}
+		scopes = append(scopes, scope)
+	}
+
+	return scopes, nil
+}
+
+func (s Scope) valid() bool {
+	for _, scope := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIToken grants access to the data of a single tenant. The plain token is
+// only known at creation time; the store keeps its hash.
+type APIToken struct {
+	ID        int     `json:"id"`
+	TenantID  int     `json:"tenant_id"`
+	Name      string  `json:"name"`
+	Scopes    []Scope `json:"scopes"`
+	TokenHash string  `json:"-"`
+	CreatedAt string  `json:"created_at"`
+	// ExpiresAt is the time the token stops being accepted, never if empty.
+	ExpiresAt string `json:"expires_at,omitempty"`
+}
+
+// NewAPIToken generates a new token for the tenant and returns it together
+// with its plain value, which must be handed over to the caller exactly once.
+func NewAPIToken(tenantID int, name string, scopes []Scope) (*APIToken, string, error) {
+	plain, err := token.Generate()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &APIToken{
+		TenantID:  tenantID,
+		Name:      name,
+		Scopes:    scopes,
+		TokenHash: token.Hash(plain),
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}, plain, nil
+}
+
+// Expired reports whether the token is no longer accepted at the time.
+func (t *APIToken) Expired(now time.Time) bool {
+	if t.ExpiresAt == "" {
+		return false
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339, t.ExpiresAt)
+	return err != nil || !now.Before(expiresAt)
+}
+
+// HasScope reports whether the token grants the scope. Admin tokens are
+// granted every scope.
+func (t *APIToken) HasScope(required Scope) bool {
+	for _, scope := range t.Scopes {
+		if scope == required || scope == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+type APITokensRepository interface {
+	Save(*APIToken) (int, error)
+	FindByHash(hash string) (*APIToken, error)
+	FindByTenant(tenantID int) ([]*APIToken, error)
+	Delete(tenantID, id int) error
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+type Variant struct {
+	VariantID    string  `json:"variant_id"`
+	ArticleID    string  `json:"article_id"`
+	Size         string  `json:"size"`
+	Color        string  `json:"color"`
+	Price        float64 `json:"price"`
+	Availability bool    `json:"availability"`
+	UpdatedAt    string  `json:"updated_at"`
+}
+
+func NewVariant(variantID, articleID, size, color string, price float64, availability bool) *Variant {
+	return &Variant{
+		VariantID:    variantID,
+		ArticleID:    articleID,
+		Size:         size,
+		Color:        color,
+		Price:        price,
+		Availability: availability,
+		UpdatedAt:    time.Now().Format(time.RFC3339),
+	}
+}
+
+func (v *Variant) Update(size, color string, price float64, availability bool) {
+	v.Size = size
+	v.Color = color
+	v.Price = price
+	v.Availability = availability
+	v.UpdatedAt = time.Now().Format(time.RFC3339)
+}
+
+type VariantRepository interface {
+	Save(*Variant) error
+	Update(*Variant) error
+	Delete(variantID string) error
+	FindById(variantID string) (*Variant, error)
+	FindByArticleId(articleID string) ([]*Variant, error)
+	FindByArticleIds(articleIDs []string) ([]*Variant, error)
+	ForTenant(tenantID int) VariantRepository
+}
+
+// FacetData is the aggregate of all variants of a product that is pushed
+// to the products index to drive filtering by size, color and stock.
+type FacetData struct {
+	AvailableSizes  []string `json:"available_sizes"`
+	AvailableColors []string `json:"available_colors"`
+	IsInStock       bool     `json:"is_in_stock"`
+}
+
+// NewFacetData aggregates the sizes and colors of the variants that are
+// currently available. Values are de-duplicated and sorted so that two
+// aggregates of the same variants are always identical.
+func NewFacetData(variants []*Variant) FacetData {
+	sizes := map[string]bool{}
+	colors := map[string]bool{}
+	for _, variant := range variants {
+		if !variant.Availability {
+			continue
+		}
+		sizes[variant.Size] = true
+		colors[variant.Color] = true
+	}
+
+	return FacetData{
+		AvailableSizes:  sortedKeys(sizes),
+		AvailableColors: sortedKeys(colors),
+		IsInStock:       len(sizes) > 0,
+	}
+}
+
+func (f FacetData) Equal(other FacetData) bool {
+	return f.IsInStock == other.IsInStock &&
+		equalStrings(f.AvailableSizes, other.AvailableSizes) &&
+		equalStrings(f.AvailableColors, other.AvailableColors)
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,168 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mini-search-platform/internal/models"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultBootstrapBaseDelay = time.Second
+	DefaultBootstrapMaxDelay  = time.Minute
+)
+
+// Bootstrap provisions the indexes of every tenant and of their catalogues:
+// missing indexes are created and only settings that differ from the
+// desired ones are applied, so that running it again changes nothing. Run
+// retries it in the background until it succeeds, so that the API starts,
+// and serves in degraded mode, while the search engine is unreachable.
+type Bootstrap struct {
+	Sync      *IndexSyncManager
+	Tenants   models.TenantsRepository
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Rebuilds is set for engines that lose their indexes on restart. Every
+	// index of a tenant is then rebuilt from the relational store once it
+	// is provisioned, and the rebuilds are reported like those started
+	// through the API.
+	Rebuilds *RebuildTracker
+
+	mu      sync.Mutex
+	status  BootstrapStatus
+	rebuilt map[int]bool
+}
+
+// BootstrapStatus is HealthOK once every index is provisioned and
+// HealthDegraded before. Errors are only logged, since the status is public.
+type BootstrapStatus struct {
+	Status   string `json:"status"`
+	Attempts int    `json:"attempts"`
+}
+
+func NewBootstrap(sync *IndexSyncManager, tenants models.TenantsRepository) *Bootstrap {
+	return &Bootstrap{
+		Sync:      sync,
+		Tenants:   tenants,
+		BaseDelay: DefaultBootstrapBaseDelay,
+		MaxDelay:  DefaultBootstrapMaxDelay,
+		status:    BootstrapStatus{Status: HealthDegraded},
+		rebuilt:   map[int]bool{},
+	}
+}
+
+// Run bootstraps until an attempt succeeds or the context is cancelled,
+// waiting an exponential delay between attempts.
+func (b *Bootstrap) Run(ctx context.Context) {
+	for attempt := 0; ; attempt++ {
+		err := b.Once()
+		if err == nil {
+			slog.Info("search indexes bootstrapped", "attempts", attempt+1)
+			return
+		}
+
+		delay := b.delay(attempt)
+		slog.Warn("search index bootstrap failed, serving degraded until it succeeds", "error", err, "retry_in", delay)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// Once bootstraps every tenant once. A failing tenant does not keep the
+// others from being bootstrapped; the errors of all of them are returned.
+func (b *Bootstrap) Once() error {
+	tenants, err := b.Tenants.FindAll()
+	if err == nil {
+		var errs []error
+		for _, tenant := range tenants {
+			sync := b.Sync.ForTenant(tenant.ID)
+			err := sync.EnsureIndexes()
+			if err == nil && b.Rebuilds != nil && !b.isRebuilt(tenant.ID) {
+				err = b.rebuild(tenant.ID, sync)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("tenant %d: %w", tenant.ID, err))
+			}
+		}
+		err = errors.Join(errs...)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.status.Attempts++
+	if err == nil {
+		b.status.Status = HealthOK
+	}
+
+	return err
+}
+
+// rebuild rebuilds every index of the tenant and of its catalogues. The
+// facet data recorded for the lost indexes is cleared first, otherwise
+// syncs would skip products whose facets did not change since.
+func (b *Bootstrap) rebuild(tenantID int, sync *IndexSyncManager) error {
+	if err := sync.ProductsRepository.ClearIndexedFacets(); err != nil {
+		return err
+	}
+
+	catalogues, err := sync.CataloguesRepository.FindAll()
+	if err != nil {
+		return err
+	}
+
+	for _, catalogue := range append([]*models.Catalogue{nil}, catalogues...) {
+		code := ""
+		if catalogue != nil {
+			code = catalogue.Code
+		}
+		for _, index := range []string{ARTICLES_INDEX_NAME, PRODUCTS_INDEX_NAME} {
+			// a rebuild started through the API in the meantime does the same
+			if _, started := b.Rebuilds.Start(tenantID, index, code); !started {
+				continue
+			}
+			err := sync.Rebuild(index, catalogue, func(indexed, total int) {
+				b.Rebuilds.Progress(tenantID, index, code, indexed, total)
+			})
+			b.Rebuilds.Finish(tenantID, index, code, err)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rebuilt[tenantID] = true
+
+	return nil
+}
+
+func (b *Bootstrap) isRebuilt(tenantID int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.rebuilt[tenantID]
+}
+
+func (b *Bootstrap) Status() BootstrapStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.status
+}
+
+func (b *Bootstrap) delay(attempts int) time.Duration {
+	delay := b.BaseDelay
+	for i := 0; i < attempts && delay < b.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, b.MaxDelay)
+}
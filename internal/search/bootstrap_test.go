@@ -0,0 +1,173 @@
+package search_test
+
+import (
+	"context"
+	"errors"
+	"mini-search-platform/internal/adapters"
+	"mini-search-platform/internal/database"
+	"mini-search-platform/internal/models"
+	"mini-search-platform/internal/search"
+	"mini-search-platform/pkg/sqlite"
+	"sync"
+	"testing"
+	"time"
+)
+
+// unreachableEngine fails to create indexes until it failed failures times.
+type unreachableEngine struct {
+	search.SearchEngine
+	mu       sync.Mutex
+	failures int
+	created  []int
+	settings []string
+}
+
+func (e *unreachableEngine) ForTenant(tenantID int) search.SearchEngine {
+	return &tenantEngine{unreachableEngine: e, tenantID: tenantID}
+}
+
+type tenantEngine struct {
+	*unreachableEngine
+	tenantID int
+}
+
+func (e *tenantEngine) ForCatalogue(*models.Catalogue) search.SearchEngine { return e }
+
+func (e *tenantEngine) CreateIndexes() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.failures > 0 {
+		e.failures--
+		return errors.New("search engine unavailable")
+	}
+	e.created = append(e.created, e.tenantID)
+	return nil
+}
+
+func (e *tenantEngine) UpdateSettings(index string, settings *models.SearchSettings) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.settings = append(e.settings, index)
+	return nil
+}
+
+func TestBootstrap_RetriesUntilIndexesAreProvisioned(t *testing.T) {
+	db, err := sqlite.Open(sqlite.MemoryDSN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlite.Close(db)
+
+	if err := database.Create(db); err != nil {
+		t.Fatal(err)
+	}
+
+	tenants := adapters.NewSQLliteTenantsRepository(db)
+	tenantID, err := tenants.Save(models.NewTenant("bootstrap"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	engine := &unreachableEngine{failures: 2}
+	sync := search.NewIndexSyncManager(
+		engine,
+		adapters.NewSQLliteArticleRepository(db),
+		adapters.NewSQLliteTagsRepository(db),
+		adapters.NewSQLliteProductsRepository(db),
+		adapters.NewSQLliteVariantsRepository(db),
+		adapters.NewSQLliteCataloguesRepository(db),
+		adapters.NewSQLliteTranslationsRepository(db),
+		adapters.NewSQLliteSearchSettingsRepository(db),
+	)
+	bootstrap := search.NewBootstrap(sync, tenants)
+	bootstrap.BaseDelay = time.Millisecond
+	bootstrap.MaxDelay = time.Millisecond
+
+	if status := bootstrap.Status(); status.Status != search.HealthDegraded {
+		t.Fatalf("expected a degraded status before bootstrapping, got %+v", status)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		bootstrap.Run(context.Background())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("bootstrap did not succeed")
+	}
+
+	if status := bootstrap.Status(); status.Status != search.HealthOK || status.Attempts != 3 {
+		t.Errorf("expected an ok status after 3 attempts, got %+v", status)
+	}
+	if len(engine.created) != 1 || engine.created[0] != tenantID {
+		t.Errorf("expected the indexes of tenant %d to be created once, got %v", tenantID, engine.created)
+	}
+	if len(engine.settings) != 2 {
+		t.Errorf("expected the settings of both indexes to be applied, got %v", engine.settings)
+	}
+}
+
+func TestBootstrap_RebuildsIndexesLostOnRestart(t *testing.T) {
+	db, err := sqlite.Open(sqlite.MemoryDSN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlite.Close(db)
+
+	if err := database.Create(db); err != nil {
+		t.Fatal(err)
+	}
+
+	tenants := adapters.NewSQLliteTenantsRepository(db)
+	tenantID, err := tenants.Save(models.NewTenant("restart"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	products := adapters.NewSQLliteProductsRepository(db)
+	variants := adapters.NewSQLliteVariantsRepository(db)
+	if err := products.ForTenant(tenantID).Save(models.NewProduct("sku-1", "Running Shoe", "Nike", "shoes")); err != nil {
+		t.Fatal(err)
+	}
+	if err := variants.ForTenant(tenantID).Save(models.NewVariant("sku-1-42", "sku-1", "42", "black", 99.90, true)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the facets were recorded before the restart, into an index now lost
+	facets := models.FacetData{AvailableSizes: []string{"42"}, AvailableColors: []string{"black"}, IsInStock: true}
+	if saved, err := products.ForTenant(tenantID).SaveIndexedFacets("sku-1", facets, 0); err != nil || !saved {
+		t.Fatalf("expected the facets to be saved, got %t, %v", saved, err)
+	}
+
+	engine := adapters.NewMemoryEngine()
+	sync := search.NewIndexSyncManager(
+		engine,
+		adapters.NewSQLliteArticleRepository(db),
+		adapters.NewSQLliteTagsRepository(db),
+		products,
+		variants,
+		adapters.NewSQLliteCataloguesRepository(db),
+		adapters.NewSQLliteTranslationsRepository(db),
+		adapters.NewSQLliteSearchSettingsRepository(db),
+	)
+	bootstrap := search.NewBootstrap(sync, tenants)
+	bootstrap.Rebuilds = search.NewRebuildTracker()
+
+	if err := bootstrap.Once(); err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := engine.ForTenant(tenantID).SearchProducts("shoe", search.SearchOptions{Limit: 10})
+	if err != nil || response.Total != 1 {
+		t.Errorf("expected the product to be rebuilt into the index, got %+v, %v", response, err)
+	}
+	if status, ok := bootstrap.Rebuilds.Status(tenantID, search.PRODUCTS_INDEX_NAME, ""); !ok || status.State != search.RebuildCompleted {
+		t.Errorf("expected the rebuild to be reported, got %+v, %t", status, ok)
+	}
+	if recorded, _, err := products.ForTenant(tenantID).FindIndexedFacets("sku-1"); err != nil || !recorded.Equal(facets) {
+		t.Errorf("expected the rebuild to record the facets again, got %+v, %v", recorded, err)
+	}
+}
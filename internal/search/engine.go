@@ -2,31 +2,91 @@ package search
 
 import (
 	"mini-search-platform/internal/models"
+	"time"
 )
 
 var (
 	ARTICLES_INDEX_NAME = "articles"
+	PRODUCTS_INDEX_NAME = "products"
 )
 
 type SearchEngine interface {
 	Search(q string, options SearchOptions) (SearchResponse, error)
 	IndexArticles(articles []*models.Article) error
+	DeleteArticles(ids []int) error
+	SearchProducts(q string, options SearchOptions) (ProductSearchResponse, error)
+	IndexProducts(products []*ProductDocument) error
+	DeleteProducts(articleIDs []string) error
+	CreateIndexes() error
+	ForTenant(tenantID int) SearchEngine
+	ForCatalogue(catalogue *models.Catalogue) SearchEngine
+	// BeginRebuild creates an empty staging copy of the index, named after
+	// ARTICLES_INDEX_NAME or PRODUCTS_INDEX_NAME, and returns an engine
+	// writing into it.
+	BeginRebuild(index string) (SearchEngine, error)
+	// CommitRebuild waits until the staging copy is fully written and swaps
+	// it atomically with the live index.
+	CommitRebuild(index string) error
+	// AbortRebuild drops the staging copy.
+	AbortRebuild(index string) error
+	// UpdateSettings applies the tenant's search settings to the index, and
+	// to its staging copy while it is rebuilt.
+	UpdateSettings(index string, settings *models.SearchSettings) error
 }
 
 type SearchOptions struct {
 	Limit  int      `json:"limit"`
 	Offset int      `json:"offset"`
 	Sort   []string `json:"sort"`
-	Filter string   `json:"filter"`
-	Facets string   `json:"facets"`
+	Filter Filter   `json:"filter"`
+	// Facets holds the facet names to count values for, see
+	// IndexSettings.Facets.
+	Facets []string `json:"facets"`
+	// Highlight holds the attributes whose matched query terms are wrapped
+	// in HighlightPreTag and HighlightPostTag, Crop the number of words
+	// attributes are cropped to around the matches. Both are returned in
+	// the _formatted view of every hit.
+	Highlight        []string       `json:"highlight"`
+	Crop             map[string]int `json:"crop"`
+	HighlightPreTag  string         `json:"highlight_pre_tag"`
+	HighlightPostTag string         `json:"highlight_post_tag"`
+	// AttributesToRetrieve limits the attributes returned with every hit.
+	// All are returned if it is empty.
+	AttributesToRetrieve []string `json:"attributes_to_retrieve"`
+}
+
+// ArticleDocument is the representation of an article in the articles index.
+// The creation date is indexed as Unix timestamp too, so that it can be
+// filtered by range.
+type ArticleDocument struct {
+	*models.Article
+	CreatedAtTimestamp int64 `json:"created_at_timestamp"`
+}
+
+func NewArticleDocuments(articles []*models.Article) []*ArticleDocument {
+	documents := make([]*ArticleDocument, len(articles))
+	for i, article := range articles {
+		documents[i] = &ArticleDocument{Article: article}
+		if createdAt, err := time.Parse(time.RFC3339, article.CreatedAt); err == nil {
+			documents[i].CreatedAtTimestamp = createdAt.Unix()
+		}
+	}
+
+	return documents
 }
 
 type SearchHit struct {
 	ID     int          `json:"id"`
-	Title  string       `json:"title"`
-	Author string       `json:"author"`
-	Body   string       `json:"body"`
-	Tags   []models.Tag `json:"tags"`
+	Title  string       `json:"title,omitempty"`
+	Author string       `json:"author,omitempty"`
+	Body   string       `json:"body,omitempty"`
+	Tags   []models.Tag `json:"tags,omitempty"`
+	// Snippet is the part of the article matching the query with the
+	// matched terms highlighted, for engines that provide one.
+	Snippet string `json:"snippet,omitempty"`
+	// Formatted holds the highlighted and cropped attributes requested by
+	// SearchOptions.Highlight and SearchOptions.Crop.
+	Formatted Formatted `json:"_formatted,omitempty"`
 }
 
 type SearchHits struct {
@@ -39,36 +99,8 @@ type SearchResponse struct {
 	Offset int         `json:"offset"`
 	Limit  int         `json:"limit"`
 	Total  int         `json:"total"`
-}
-
-type IndexSyncManager struct {
-	Engine             SearchEngine
-	ArticlesRepository models.ArticleRepository
-	TagsRepository     models.TagsRepository
-}
-
-func NewIndexSyncManager(engine SearchEngine, articlesRepository models.ArticleRepository, tagsRepository models.TagsRepository) *IndexSyncManager {
-	return &IndexSyncManager{
-		Engine:             engine,
-		ArticlesRepository: articlesRepository,
-		TagsRepository:     tagsRepository,
-	}
-}
-
-func (m *IndexSyncManager) SyncAfterTagsChanged(tagToSync *models.Tag) error {
-	articles, err := m.ArticlesRepository.FindByTag(tagToSync)
-	if err != nil {
-		return err
-	}
-
-	return m.Engine.IndexArticles(articles)
-}
-
-func (m *IndexSyncManager) SyncAfterArticlesChanged(articlesToSync []*models.Article) error {
-	err := m.Engine.IndexArticles(articlesToSync)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	// Facets holds the number of hits per value of every requested facet.
+	Facets map[string]map[string]int `json:"facets,omitempty"`
+	// Degraded is set when the response was served by a fallback engine.
+	Degraded bool `json:"degraded,omitempty"`
 }
@@ -0,0 +1,326 @@
+package search_test
+
+import (
+	"fmt"
+	"mini-search-platform/internal/adapters"
+	"mini-search-platform/internal/database"
+	"mini-search-platform/internal/models"
+	"mini-search-platform/internal/search"
+	"mini-search-platform/pkg/sqlite"
+	"reflect"
+	"testing"
+)
+
+type countingEngine struct {
+	search.SearchEngine
+	indexedProducts int
+}
+
+func (e *countingEngine) IndexProducts(products []*search.ProductDocument) error {
+	e.indexedProducts += len(products)
+	return nil
+}
+
+// catalogueEngine records the product titles indexed per catalogue.
+type catalogueEngine struct {
+	search.SearchEngine
+	code   string
+	titles map[string][]string
+}
+
+func (e *catalogueEngine) ForCatalogue(catalogue *models.Catalogue) search.SearchEngine {
+	return &catalogueEngine{code: catalogue.Code, titles: e.titles}
+}
+
+func (e *catalogueEngine) IndexProducts(products []*search.ProductDocument) error {
+	for _, product := range products {
+		e.titles[e.code] = append(e.titles[e.code], product.Title)
+	}
+	return nil
+}
+
+func TestSyncAfterProductsChanged_IndexesLocalizedCopiesPerCatalogue(t *testing.T) {
+	db, err := sqlite.Open(sqlite.MemoryDSN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlite.Close(db)
+
+	if err := database.Create(db); err != nil {
+		t.Fatal(err)
+	}
+
+	const tenantID = 7007
+	products := adapters.NewSQLliteProductsRepository(db).ForTenant(tenantID)
+	variants := adapters.NewSQLliteVariantsRepository(db).ForTenant(tenantID)
+	catalogues := adapters.NewSQLliteCataloguesRepository(db).ForTenant(tenantID)
+	translations := adapters.NewSQLliteTranslationsRepository(db).ForTenant(tenantID)
+	engine := &catalogueEngine{titles: map[string][]string{}}
+	sync := search.NewIndexSyncManager(engine, nil, nil, products, variants, catalogues, translations, nil)
+
+	product := models.NewProduct("catalogue-test-1", "Running Shoe", "Nike", "shoes")
+	if err := products.Save(product); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, catalogue := range []*models.Catalogue{
+		models.NewCatalogue("de-DE", "de", "Germany"),
+		models.NewCatalogue("uk-en", "en", "United Kingdom"),
+	} {
+		catalogue.ID, err = catalogues.Save(catalogue)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if catalogue.Code == "de-DE" {
+			translation := models.NewTranslation(catalogue.ID, models.EntityProduct, product.ArticleID, "Laufschuh", "")
+			if err := translations.Save(translation); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if err := sync.SyncAfterProductsChanged([]*models.Product{product}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{"": "Running Shoe", "de-DE": "Laufschuh", "uk-en": "Running Shoe"}
+	for code, title := range expected {
+		titles := engine.titles[code]
+		if len(titles) != 1 || titles[0] != title {
+			t.Errorf("catalogue %q: expected [%s], got %v", code, title, titles)
+		}
+	}
+}
+
+func TestSyncAfterVariantChanged_OnlyReindexesWhenFacetsChange(t *testing.T) {
+	db, err := sqlite.Open(sqlite.MemoryDSN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlite.Close(db)
+
+	if err := database.Create(db); err != nil {
+		t.Fatal(err)
+	}
+
+	products := adapters.NewSQLliteProductsRepository(db)
+	variants := adapters.NewSQLliteVariantsRepository(db)
+	engine := &countingEngine{}
+	catalogues := adapters.NewSQLliteCataloguesRepository(db)
+	translations := adapters.NewSQLliteTranslationsRepository(db)
+	sync := search.NewIndexSyncManager(engine, nil, nil, products, variants, catalogues, translations, nil)
+
+	product := models.NewProduct("sync-test-1", "Running Shoe", "Nike", "shoes")
+	if err := products.Save(product); err != nil {
+		t.Fatal(err)
+	}
+	if err := sync.SyncAfterProductsChanged([]*models.Product{product}); err != nil {
+		t.Fatal(err)
+	}
+
+	variant := models.NewVariant("sync-test-1-42", product.ArticleID, "42", "black", 99.90, true)
+	if err := variants.Save(variant); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		name      string
+		change    func(v *models.Variant)
+		reindexed bool
+	}{
+		{"new variant in stock", func(v *models.Variant) {}, true},
+		{"price change", func(v *models.Variant) { v.Update(v.Size, v.Color, 79.90, v.Availability) }, false},
+		{"sold out", func(v *models.Variant) { v.Update(v.Size, v.Color, v.Price, false) }, true},
+		{"price change while sold out", func(v *models.Variant) { v.Update(v.Size, v.Color, 59.90, v.Availability) }, false},
+	}
+
+	for _, step := range steps {
+		step.change(variant)
+		if err := variants.Update(variant); err != nil {
+			t.Fatal(err)
+		}
+
+		before := engine.indexedProducts
+		reindexed, err := sync.SyncAfterVariantChanged(variant)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", step.name, err)
+		}
+		if reindexed != step.reindexed {
+			t.Errorf("%s: expected reindexed=%v, got %v", step.name, step.reindexed, reindexed)
+		}
+		if reindexed != (engine.indexedProducts > before) {
+			t.Errorf("%s: search engine calls do not match reported result", step.name)
+		}
+	}
+}
+
+func TestNewProductDocument_AggregatesAvailableVariants(t *testing.T) {
+	product := models.NewProduct("shoe", "Running Shoe", "Nike", "shoes")
+	variants := []*models.Variant{
+		models.NewVariant("shoe-43-black", "shoe", "43", "black", 80, true),
+		models.NewVariant("shoe-42-white", "shoe", "42", "white", 80, true),
+		models.NewVariant("shoe-42-black", "shoe", "42", "black", 80, true),
+		models.NewVariant("shoe-44-red", "shoe", "44", "red", 80, false),
+	}
+
+	document := search.NewProductDocument(product, variants)
+
+	if document.ArticleID != "shoe" || document.Title != "Running Shoe" || document.Brand != "Nike" || document.Category != "shoes" {
+		t.Errorf("expected the fields of the product, got %+v", document)
+	}
+	// sizes and colors of unavailable variants are left out, the others
+	// are de-duplicated and sorted
+	expected := models.FacetData{AvailableSizes: []string{"42", "43"}, AvailableColors: []string{"black", "white"}, IsInStock: true}
+	if !reflect.DeepEqual(document.FacetData, expected) {
+		t.Errorf("expected facets %+v, got %+v", expected, document.FacetData)
+	}
+
+	// the order of the variants does not matter
+	reversed := []*models.Variant{variants[3], variants[2], variants[1], variants[0]}
+	if !models.NewFacetData(reversed).Equal(document.FacetData) {
+		t.Errorf("expected the same facets for reordered variants, got %+v", models.NewFacetData(reversed))
+	}
+
+	soldOut := search.NewProductDocument(product, variants[3:])
+	if soldOut.FacetData.IsInStock || len(soldOut.FacetData.AvailableSizes) != 0 || len(soldOut.FacetData.AvailableColors) != 0 {
+		t.Errorf("expected a sold out product without facets, got %+v", soldOut.FacetData)
+	}
+}
+
+// overtakenEngine keeps the facet data each product was last indexed with.
+// While the first write is in flight, it runs overtake before letting that
+// write land, so that the write of a concurrent sync lands first.
+type overtakenEngine struct {
+	search.SearchEngine
+	facets   map[string]models.FacetData
+	overtake func()
+}
+
+func (e *overtakenEngine) IndexProducts(products []*search.ProductDocument) error {
+	if overtake := e.overtake; overtake != nil {
+		e.overtake = nil
+		overtake()
+	}
+	for _, product := range products {
+		e.facets[product.ArticleID] = product.FacetData
+	}
+	return nil
+}
+
+func TestSyncAfterProductFacetsChanged_ResyncsWhenOvertaken(t *testing.T) {
+	db, err := sqlite.Open(sqlite.MemoryDSN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlite.Close(db)
+
+	if err := database.Create(db); err != nil {
+		t.Fatal(err)
+	}
+
+	products := adapters.NewSQLliteProductsRepository(db)
+	variants := adapters.NewSQLliteVariantsRepository(db)
+	engine := &overtakenEngine{facets: map[string]models.FacetData{}}
+	catalogues := adapters.NewSQLliteCataloguesRepository(db)
+	translations := adapters.NewSQLliteTranslationsRepository(db)
+	sync := search.NewIndexSyncManager(engine, nil, nil, products, variants, catalogues, translations, nil)
+
+	product := models.NewProduct("race-test-1", "Running Shoe", "Nike", "shoes")
+	if err := products.Save(product); err != nil {
+		t.Fatal(err)
+	}
+	if err := variants.Save(models.NewVariant("race-test-1-42", product.ArticleID, "42", "black", 99.90, true)); err != nil {
+		t.Fatal(err)
+	}
+
+	// a second variant is added while the first sync is in flight and
+	// synced by another worker, whose write to the index lands first
+	engine.overtake = func() {
+		if err := variants.Save(models.NewVariant("race-test-1-43", product.ArticleID, "43", "white", 99.90, true)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := sync.SyncAfterProductFacetsChanged(product.ArticleID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := sync.SyncAfterProductFacetsChanged(product.ArticleID); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := models.FacetData{AvailableSizes: []string{"42", "43"}, AvailableColors: []string{"black", "white"}, IsInStock: true}
+	if indexed := engine.facets[product.ArticleID]; !indexed.Equal(expected) {
+		t.Errorf("expected the product to be indexed with both variants, got %+v", indexed)
+	}
+	if recorded, _, err := products.FindIndexedFacets(product.ArticleID); err != nil || !recorded.Equal(expected) {
+		t.Errorf("expected both variants to be recorded, got %+v, %v", recorded, err)
+	}
+	if reindexed, err := sync.SyncAfterProductFacetsChanged(product.ArticleID); err != nil || reindexed {
+		t.Errorf("expected no reindex once index and record agree, got %t, %v", reindexed, err)
+	}
+}
+
+// deletingEngine rebuilds into itself and runs deleteFirst once the first
+// page of products was written.
+type deletingEngine struct {
+	search.SearchEngine
+	indexed     map[string]bool
+	deleteFirst func()
+}
+
+func (e *deletingEngine) BeginRebuild(index string) (search.SearchEngine, error) { return e, nil }
+func (e *deletingEngine) CommitRebuild(index string) error                       { return nil }
+func (e *deletingEngine) AbortRebuild(index string) error                        { return nil }
+
+func (e *deletingEngine) IndexProducts(products []*search.ProductDocument) error {
+	for _, product := range products {
+		e.indexed[product.ArticleID] = true
+	}
+	if deleteFirst := e.deleteFirst; deleteFirst != nil {
+		e.deleteFirst = nil
+		deleteFirst()
+	}
+	return nil
+}
+
+func TestRebuild_SkipsNoProductWhenEarlierOnesAreDeleted(t *testing.T) {
+	db, err := sqlite.Open(sqlite.MemoryDSN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlite.Close(db)
+
+	if err := database.Create(db); err != nil {
+		t.Fatal(err)
+	}
+
+	products := adapters.NewSQLliteProductsRepository(db)
+	variants := adapters.NewSQLliteVariantsRepository(db)
+	catalogues := adapters.NewSQLliteCataloguesRepository(db)
+	translations := adapters.NewSQLliteTranslationsRepository(db)
+	settings := adapters.NewSQLliteSearchSettingsRepository(db)
+	engine := &deletingEngine{indexed: map[string]bool{}}
+	sync := search.NewIndexSyncManager(engine, nil, nil, products, variants, catalogues, translations, settings)
+
+	// more than a page, so that the rebuild reads a second one
+	const total = 510
+	for i := 0; i < total; i++ {
+		if err := products.Save(models.NewProduct(fmt.Sprintf("rebuild-%03d", i), "Running Shoe", "Nike", "shoes")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the first product is deleted once the first page was written, which
+	// shifts every later product one row up
+	engine.deleteFirst = func() {
+		if err := products.Delete("rebuild-000"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sync.Rebuild(search.PRODUCTS_INDEX_NAME, nil, func(indexed, total int) {}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(engine.indexed) != total {
+		t.Errorf("expected all %d products to be indexed, got %d", total, len(engine.indexed))
+	}
+}
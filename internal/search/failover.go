@@ -0,0 +1,332 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mini-search-platform/internal/models"
+	"mini-search-platform/pkg/breaker"
+	"net"
+	"sync"
+	"time"
+)
+
+var (
+	ErrTimeout = errors.New("search engine timed out")
+	// ErrUnavailable marks errors of engines that could not be reached or
+	// failed to answer, as opposed to rejecting the request.
+	ErrUnavailable = errors.New("search engine unavailable")
+)
+
+// maxPendingWrites bounds the writes held back for an unavailable primary.
+const maxPendingWrites = 10000
+
+// ContextSearcher is implemented by engines whose searches can be
+// cancelled, which the failover engine bounds by its timeout.
+type ContextSearcher interface {
+	SearchContext(ctx context.Context, q string, options SearchOptions) (SearchResponse, error)
+	SearchProductsContext(ctx context.Context, q string, options SearchOptions) (ProductSearchResponse, error)
+}
+
+// FailoverEngine serves searches from the primary engine and falls back to
+// the secondary one when the primary is unavailable, times out or its
+// circuit breaker is open. Responses served by the secondary are marked as
+// degraded. Searches the primary rejects are not retried on the secondary,
+// and only unavailability counts towards the breaker. Searches on primaries
+// that are not a ContextSearcher, like the memory engine, are not bounded.
+//
+// Writes go to both engines so that the secondary stays warm. A write the
+// secondary took succeeds even if the primary is unavailable: it is held
+// back in memory and replayed in order before later writes reach the
+// primary. Held back writes are lost on restart, which cmd/reindex makes up
+// for. Creating indexes and rebuilds fail instead, so that they are
+// retried.
+type FailoverEngine struct {
+	Primary   SearchEngine
+	Secondary SearchEngine
+	// Timeout bounds every search on the primary.
+	Timeout time.Duration
+	Breaker *breaker.Breaker
+
+	pending *pendingWrites
+}
+
+// pendingWrites holds the writes the primary missed, oldest first. It is
+// shared by all engines derived from the same NewFailoverEngine call.
+type pendingWrites struct {
+	mu     sync.Mutex
+	writes []func() error
+}
+
+func NewFailoverEngine(primary, secondary SearchEngine) *FailoverEngine {
+	return &FailoverEngine{
+		Primary:   primary,
+		Secondary: secondary,
+		Timeout:   2 * time.Second,
+		Breaker:   breaker.New(5, 30*time.Second),
+		pending:   &pendingWrites{},
+	}
+}
+
+// EngineHealth reports whether searches are served by the primary engine.
+type EngineHealth struct {
+	Status  string            `json:"status"`
+	Breaker *breaker.Snapshot `json:"breaker,omitempty"`
+}
+
+// HealthReporter is implemented by engines that can be degraded.
+type HealthReporter interface {
+	Health() EngineHealth
+}
+
+const (
+	HealthOK       = "ok"
+	HealthDegraded = "degraded"
+)
+
+func (e *FailoverEngine) Health() EngineHealth {
+	snapshot := e.Breaker.Snapshot()
+
+	status := HealthOK
+	if snapshot.State != breaker.StateClosed {
+		status = HealthDegraded
+	}
+
+	return EngineHealth{Status: status, Breaker: &snapshot}
+}
+
+func (e *FailoverEngine) derive(primary, secondary SearchEngine) *FailoverEngine {
+	return &FailoverEngine{
+		Primary:   primary,
+		Secondary: secondary,
+		Timeout:   e.Timeout,
+		Breaker:   e.Breaker,
+		pending:   e.pending,
+	}
+}
+
+func (e *FailoverEngine) ForTenant(tenantID int) SearchEngine {
+	return e.derive(e.Primary.ForTenant(tenantID), e.Secondary.ForTenant(tenantID))
+}
+
+func (e *FailoverEngine) ForCatalogue(catalogue *models.Catalogue) SearchEngine {
+	return e.derive(e.Primary.ForCatalogue(catalogue), e.Secondary.ForCatalogue(catalogue))
+}
+
+// isUnavailable reports whether the error tells that the engine could not
+// answer, as opposed to rejecting the request.
+func isUnavailable(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, ErrUnavailable) || errors.Is(err, ErrTimeout) ||
+		errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr)
+}
+
+// primary runs the call on the primary engine unless the breaker is open.
+// The call fails with ErrTimeout once the context it is given expires.
+func (e *FailoverEngine) primary(timeout time.Duration, call func(ctx context.Context) error) error {
+	if !e.Breaker.Allow() {
+		return breaker.ErrOpen
+	}
+
+	ctx, cancel := context.Background(), func() {}
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	defer cancel()
+
+	err := call(ctx)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+
+	if err != nil && isUnavailable(err) {
+		before := e.Breaker.Snapshot().State
+		e.Breaker.Failure()
+		if after := e.Breaker.Snapshot().State; after != before && after == breaker.StateOpen {
+			slog.Warn("search failover: primary engine failed, serving from the secondary", "error", err)
+		}
+		return err
+	}
+
+	e.Breaker.Success()
+	return err
+}
+
+// fallBack reports whether the secondary has to serve instead of the
+// primary, which failed with the error.
+func fallBack(err error) bool {
+	return errors.Is(err, breaker.ErrOpen) || isUnavailable(err)
+}
+
+func (e *FailoverEngine) Search(q string, options SearchOptions) (SearchResponse, error) {
+	var response SearchResponse
+	err := e.primary(e.Timeout, func(ctx context.Context) (err error) {
+		if searcher, ok := e.Primary.(ContextSearcher); ok {
+			response, err = searcher.SearchContext(ctx, q, options)
+		} else {
+			response, err = e.Primary.Search(q, options)
+		}
+		return err
+	})
+	if !fallBack(err) {
+		return response, err
+	}
+
+	response, err = e.Secondary.Search(q, options)
+	response.Degraded = true
+	return response, err
+}
+
+func (e *FailoverEngine) SearchProducts(q string, options SearchOptions) (ProductSearchResponse, error) {
+	var response ProductSearchResponse
+	err := e.primary(e.Timeout, func(ctx context.Context) (err error) {
+		if searcher, ok := e.Primary.(ContextSearcher); ok {
+			response, err = searcher.SearchProductsContext(ctx, q, options)
+		} else {
+			response, err = e.Primary.SearchProducts(q, options)
+		}
+		return err
+	})
+	if !fallBack(err) {
+		return response, err
+	}
+
+	response, err = e.Secondary.SearchProducts(q, options)
+	response.Degraded = true
+	return response, err
+}
+
+// both runs the write on the secondary and then on the primary engine. If
+// the primary is unavailable the write is held back, see FailoverEngine.
+func (e *FailoverEngine) both(write func(SearchEngine) error) error {
+	if err := write(e.Secondary); err != nil {
+		return fmt.Errorf("secondary engine: %w", err)
+	}
+
+	e.pending.mu.Lock()
+	defer e.pending.mu.Unlock()
+
+	if len(e.pending.writes) >= maxPendingWrites {
+		return fmt.Errorf("%w: %d writes are held back for the primary engine", ErrUnavailable, len(e.pending.writes))
+	}
+	primary := e.Primary
+	e.pending.writes = append(e.pending.writes, func() error { return write(primary) })
+
+	return e.replay()
+}
+
+// replay runs the held back writes on the primary, oldest first, until the
+// primary is unavailable. The last write is the one of the caller, whose
+// rejection is returned; earlier ones already succeeded for their callers,
+// so their rejections are only logged. The caller holds pending.mu.
+func (e *FailoverEngine) replay() error {
+	for len(e.pending.writes) > 0 {
+		err := e.primary(0, func(context.Context) error { return e.pending.writes[0]() })
+		if fallBack(err) {
+			return nil
+		}
+
+		last := len(e.pending.writes) == 1
+		e.pending.writes = e.pending.writes[1:]
+		if err != nil {
+			if last {
+				return err
+			}
+			slog.Error("search failover: primary engine rejected a held back write", "error", err)
+		}
+	}
+
+	return nil
+}
+
+// flush replays the held back writes and fails if the primary did not take
+// all of them.
+func (e *FailoverEngine) flush() error {
+	e.pending.mu.Lock()
+	defer e.pending.mu.Unlock()
+
+	if err := e.replay(); err != nil {
+		return err
+	}
+	if len(e.pending.writes) > 0 {
+		return fmt.Errorf("%w: %d writes are held back for the primary engine", ErrUnavailable, len(e.pending.writes))
+	}
+
+	return nil
+}
+
+// all runs the operation on the secondary and then on the primary engine,
+// once the held back writes reached it, and fails if either fails.
+func (e *FailoverEngine) all(op func(SearchEngine) error) error {
+	if err := op(e.Secondary); err != nil {
+		return fmt.Errorf("secondary engine: %w", err)
+	}
+	if err := e.flush(); err != nil {
+		return err
+	}
+
+	return op(e.Primary)
+}
+
+func (e *FailoverEngine) IndexArticles(articles []*models.Article) error {
+	return e.both(func(engine SearchEngine) error { return engine.IndexArticles(articles) })
+}
+
+func (e *FailoverEngine) DeleteArticles(ids []int) error {
+	return e.both(func(engine SearchEngine) error { return engine.DeleteArticles(ids) })
+}
+
+func (e *FailoverEngine) IndexProducts(products []*ProductDocument) error {
+	return e.both(func(engine SearchEngine) error { return engine.IndexProducts(products) })
+}
+
+func (e *FailoverEngine) DeleteProducts(articleIDs []string) error {
+	return e.both(func(engine SearchEngine) error { return engine.DeleteProducts(articleIDs) })
+}
+
+func (e *FailoverEngine) CreateIndexes() error {
+	return e.all(func(engine SearchEngine) error { return engine.CreateIndexes() })
+}
+
+func (e *FailoverEngine) BeginRebuild(index string) (SearchEngine, error) {
+	secondary, err := e.Secondary.BeginRebuild(index)
+	if err != nil {
+		return nil, fmt.Errorf("secondary engine: %w", err)
+	}
+
+	primary, err := SearchEngine(nil), e.flush()
+	if err == nil {
+		primary, err = e.Primary.BeginRebuild(index)
+	}
+	if err != nil {
+		e.Secondary.AbortRebuild(index)
+		return nil, err
+	}
+
+	return e.derive(primary, secondary), nil
+}
+
+func (e *FailoverEngine) CommitRebuild(index string) error {
+	return e.all(func(engine SearchEngine) error { return engine.CommitRebuild(index) })
+}
+
+// AbortRebuild drops the staging copies even if the primary is unavailable,
+// after replaying the held back writes to them if it is not.
+func (e *FailoverEngine) AbortRebuild(index string) error {
+	e.flush()
+
+	var errs []error
+	if err := e.Secondary.AbortRebuild(index); err != nil {
+		errs = append(errs, fmt.Errorf("secondary engine: %w", err))
+	}
+	if err := e.Primary.AbortRebuild(index); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+func (e *FailoverEngine) UpdateSettings(index string, settings *models.SearchSettings) error {
+	return e.both(func(engine SearchEngine) error { return engine.UpdateSettings(index, settings) })
+}
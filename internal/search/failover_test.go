@@ -0,0 +1,170 @@
+package search_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mini-search-platform/internal/models"
+	"mini-search-platform/internal/search"
+	"mini-search-platform/pkg/breaker"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stubEngine answers searches with its name, or fails while down. Searches
+// are cancelled through their context, writes are recorded.
+type stubEngine struct {
+	search.SearchEngine
+	name     string
+	mu       sync.Mutex
+	down     bool
+	delay    time.Duration
+	calls    int
+	rejected error
+	indexed  []int
+}
+
+func (e *stubEngine) set(down bool, delay time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.down, e.delay = down, delay
+}
+
+func (e *stubEngine) Search(q string, options search.SearchOptions) (search.SearchResponse, error) {
+	return e.SearchContext(context.Background(), q, options)
+}
+
+func (e *stubEngine) SearchContext(ctx context.Context, q string, options search.SearchOptions) (search.SearchResponse, error) {
+	e.mu.Lock()
+	e.calls++
+	down, delay, rejected := e.down, e.delay, e.rejected
+	e.mu.Unlock()
+
+	select {
+	case <-time.After(delay):
+	case <-ctx.Done():
+		return search.SearchResponse{}, ctx.Err()
+	}
+	if down {
+		return search.SearchResponse{}, fmt.Errorf("%w: %s", search.ErrUnavailable, e.name)
+	}
+	if rejected != nil {
+		return search.SearchResponse{}, rejected
+	}
+	return search.SearchResponse{Query: e.name}, nil
+}
+
+func (e *stubEngine) SearchProductsContext(ctx context.Context, q string, options search.SearchOptions) (search.ProductSearchResponse, error) {
+	return search.ProductSearchResponse{}, nil
+}
+
+func (e *stubEngine) ForTenant(int) search.SearchEngine { return e }
+
+func (e *stubEngine) CreateIndexes() error { return nil }
+
+func (e *stubEngine) IndexArticles(articles []*models.Article) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.down {
+		return fmt.Errorf("%w: %s", search.ErrUnavailable, e.name)
+	}
+	for _, article := range articles {
+		e.indexed = append(e.indexed, article.ID)
+	}
+	return nil
+}
+
+func TestFailoverEngine_FallsBackWhileBreakerIsOpen(t *testing.T) {
+	primary := &stubEngine{name: "primary", down: true}
+	secondary := &stubEngine{name: "secondary"}
+	engine := search.NewFailoverEngine(primary, secondary)
+	engine.Breaker = breaker.New(2, 50*time.Millisecond)
+	engine.Timeout = 20 * time.Millisecond
+
+	for i := 0; i < 2; i++ {
+		response, err := engine.Search("q", search.SearchOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if response.Query != "secondary" || !response.Degraded {
+			t.Fatalf("expected a degraded response from the secondary, got %+v", response)
+		}
+	}
+	if health := engine.Health(); health.Status != search.HealthDegraded || health.Breaker.State != breaker.StateOpen {
+		t.Fatalf("expected the breaker to be open after 2 failures, got %+v", health)
+	}
+
+	// the open breaker keeps calls away from the primary
+	engine.Search("q", search.SearchOptions{})
+	primary.mu.Lock()
+	if primary.calls != 2 {
+		t.Errorf("expected the primary to be skipped while open, got %d calls", primary.calls)
+	}
+	primary.mu.Unlock()
+
+	// a slow primary counts as failing too
+	time.Sleep(60 * time.Millisecond)
+	primary.set(false, 40*time.Millisecond)
+	if response, _ := engine.Search("q", search.SearchOptions{}); !response.Degraded {
+		t.Errorf("expected the timed out trial call to be served by the secondary, got %+v", response)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	primary.set(false, 0)
+	response, err := engine.Search("q", search.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Query != "primary" || response.Degraded {
+		t.Errorf("expected the primary to serve again after a successful trial, got %+v", response)
+	}
+	if health := engine.Health(); health.Status != search.HealthOK {
+		t.Errorf("expected the engine to be healthy again, got %+v", health)
+	}
+}
+
+func TestFailoverEngine_OnlyUnavailabilityTripsTheBreaker(t *testing.T) {
+	primary := &stubEngine{name: "primary", rejected: errors.New("invalid filter")}
+	secondary := &stubEngine{name: "secondary"}
+	engine := search.NewFailoverEngine(primary, secondary)
+	engine.Breaker = breaker.New(1, time.Minute)
+
+	if _, err := engine.Search("q", search.SearchOptions{}); err != primary.rejected {
+		t.Errorf("expected the rejection of the primary, got %v", err)
+	}
+	if health := engine.Health(); health.Status != search.HealthOK || secondary.calls != 0 {
+		t.Errorf("expected a rejected search to neither trip the breaker nor fall back, got %+v", health)
+	}
+}
+
+func TestFailoverEngine_HoldsBackWritesWhilePrimaryIsDown(t *testing.T) {
+	primary := &stubEngine{name: "primary", down: true}
+	secondary := &stubEngine{name: "secondary"}
+	engine := search.NewFailoverEngine(primary, secondary)
+	engine.Breaker = breaker.New(1, time.Minute)
+
+	for _, id := range []int{1, 2} {
+		if err := engine.ForTenant(1).IndexArticles([]*models.Article{{ID: id}}); err != nil {
+			t.Fatalf("expected the write to succeed on the secondary, got %v", err)
+		}
+	}
+	if len(secondary.indexed) != 2 || len(primary.indexed) != 0 {
+		t.Fatalf("expected only the secondary to be written, got %v and %v", secondary.indexed, primary.indexed)
+	}
+	if err := engine.CreateIndexes(); !errors.Is(err, search.ErrUnavailable) {
+		t.Errorf("expected creating indexes to fail while writes are held back, got %v", err)
+	}
+
+	// the breaker lets the next write through once the primary is back
+	primary.set(false, 0)
+	engine.Breaker = breaker.New(1, time.Minute)
+	if err := engine.IndexArticles([]*models.Article{{ID: 3}}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(primary.indexed, []int{1, 2, 3}) {
+		t.Errorf("expected the held back writes to be replayed in order, got %v", primary.indexed)
+	}
+}
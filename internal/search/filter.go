@@ -0,0 +1,140 @@
+package search
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	// FilterIn matches documents having any of the values.
+	FilterIn = "in"
+	// FilterAtLeast and FilterAtMost compare numbers.
+	FilterAtLeast = ">="
+	FilterAtMost  = "<="
+)
+
+const (
+	FilterString    = "string"
+	FilterBoolean   = "boolean"
+	FilterTimestamp = "timestamp"
+)
+
+// Filter is an engine-neutral filter. A document matches if it matches every
+// condition. Engines compile it into their own filter syntax.
+type Filter []Condition
+
+type Condition struct {
+	Attribute string
+	Operator  string
+	Values    []string
+}
+
+// FilterField is a query parameter clients filter on.
+type FilterField struct {
+	Attribute string
+	Operator  string
+	// Type validates the values. Timestamps are given as RFC 3339 dates
+	// (2006-01-02 or 2006-01-02T15:04:05Z07:00) and compared as Unix
+	// timestamps.
+	Type string
+}
+
+// FilterFieldError reports the query parameter a filter was rejected for.
+type FilterFieldError struct {
+	Field  string
+	Reason string
+}
+
+func (e *FilterFieldError) Error() string {
+	return fmt.Sprintf("invalid filter field '%s': %s", e.Field, e.Reason)
+}
+
+// ParseFilter builds a filter from query parameters, e.g.
+// tags=denim&tags=summer&author=Jane. Values of the same field are combined
+// with OR, fields with AND. Parameters listed in ignore are skipped; any
+// other parameter has to be one of the FilterFields of the index.
+func (s IndexSettings) ParseFilter(params url.Values, ignore ...string) (Filter, error) {
+	skip := map[string]bool{}
+	for _, name := range ignore {
+		skip[name] = true
+	}
+
+	fields := make([]string, 0, len(params))
+	for field := range params {
+		if !skip[field] {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	filter := Filter{}
+	for _, field := range fields {
+		definition, ok := s.FilterFields[field]
+		if !ok {
+			reason := "unknown field"
+			if s.isAttribute(field) {
+				reason = "attribute is not filterable"
+			}
+			return nil, &FilterFieldError{Field: field, Reason: reason}
+		}
+
+		values := []string{}
+		for _, value := range params[field] {
+			value, err := definition.parse(value)
+			if err != nil {
+				return nil, &FilterFieldError{Field: field, Reason: err.Error()}
+			}
+			values = append(values, value)
+		}
+
+		if definition.Operator != FilterIn && len(values) > 1 {
+			return nil, &FilterFieldError{Field: field, Reason: "expected a single value"}
+		}
+
+		filter = append(filter, Condition{
+			Attribute: definition.Attribute,
+			Operator:  definition.Operator,
+			Values:    values,
+		})
+	}
+
+	return filter, nil
+}
+
+func (f FilterField) parse(value string) (string, error) {
+	switch f.Type {
+	case FilterBoolean:
+		boolean, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("'%s' is not a boolean", value)
+		}
+		return strconv.FormatBool(boolean), nil
+	case FilterTimestamp:
+		for _, layout := range []string{time.DateOnly, time.RFC3339} {
+			if date, err := time.Parse(layout, value); err == nil {
+				return strconv.FormatInt(date.Unix(), 10), nil
+			}
+		}
+		return "", fmt.Errorf("'%s' is not a date", value)
+	}
+
+	if value == "" {
+		return "", fmt.Errorf("empty value")
+	}
+	return value, nil
+}
+
+func (s IndexSettings) isAttribute(name string) bool {
+	for _, attributes := range [][]string{s.Searchable, s.Filterable, s.Sortable} {
+		for _, attribute := range attributes {
+			if attribute == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,42 @@
+package search_test
+
+import (
+	"errors"
+	"mini-search-platform/internal/search"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestParseFilter(t *testing.T) {
+	params, _ := url.ParseQuery("q=jeans&tags=denim&tags=summer&author=Jane&created_after=2024-01-01")
+
+	filter, err := search.ArticlesIndexSettings.ParseFilter(params, "q")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := search.Filter{
+		{Attribute: "author", Operator: search.FilterIn, Values: []string{"Jane"}},
+		{Attribute: "created_at_timestamp", Operator: search.FilterAtLeast, Values: []string{"1704067200"}},
+		{Attribute: "tags.label", Operator: search.FilterIn, Values: []string{"denim", "summer"}},
+	}
+	if !reflect.DeepEqual(filter, expected) {
+		t.Errorf("expected %+v, got %+v", expected, filter)
+	}
+
+	for query, field := range map[string]string{
+		"colour=red":             "colour",
+		"body=denim":             "body",
+		"created_before=someday": "created_before",
+		"q=jeans":                "q",
+	} {
+		params, _ := url.ParseQuery(query)
+		_, err := search.ArticlesIndexSettings.ParseFilter(params)
+
+		var fieldErr *search.FilterFieldError
+		if !errors.As(err, &fieldErr) || fieldErr.Field != field {
+			t.Errorf("%s: expected an error for field '%s', got %v", query, field, err)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultCropLength is the number of words an attribute is cropped to if the
+// crop parameter gives no length.
+const DefaultCropLength = 10
+
+// CropMarker marks the text cut off by cropping.
+const CropMarker = "…"
+
+// Formatted holds the attributes of a hit with the query terms highlighted
+// and cropped around the matches.
+type Formatted map[string]string
+
+// UnmarshalJSON keeps the string attributes only, as engines may format the
+// whole document.
+func (f *Formatted) UnmarshalJSON(data []byte) error {
+	var attributes map[string]interface{}
+	if err := json.Unmarshal(data, &attributes); err != nil {
+		return err
+	}
+
+	*f = Formatted{}
+	for attribute, value := range attributes {
+		if text, ok := value.(string); ok {
+			(*f)[attribute] = text
+		}
+	}
+
+	return nil
+}
+
+// FormattedAttributes returns the attributes to highlight or crop.
+func (o SearchOptions) FormattedAttributes() []string {
+	attributes := append([]string{}, o.Highlight...)
+	for attribute := range o.Crop {
+		if !contains(attributes, attribute) {
+			attributes = append(attributes, attribute)
+		}
+	}
+
+	return attributes
+}
+
+// ParseHighlight parses the comma-separated highlight parameter, rejecting
+// attributes the index cannot format.
+func (s IndexSettings) ParseHighlight(param string) ([]string, error) {
+	attributes := []string{}
+	for _, attribute := range splitList(param) {
+		if !contains(s.Formattable, attribute) {
+			return nil, fmt.Errorf("attribute '%s' cannot be highlighted", attribute)
+		}
+		attributes = append(attributes, attribute)
+	}
+
+	return attributes, nil
+}
+
+// ParseCrop parses the comma-separated crop parameter, e.g. body:30,title,
+// into the number of words every attribute is cropped to.
+func (s IndexSettings) ParseCrop(param string) (map[string]int, error) {
+	crop := map[string]int{}
+	for _, rule := range splitList(param) {
+		attribute, length, found := strings.Cut(rule, ":")
+		if !contains(s.Formattable, attribute) {
+			return nil, fmt.Errorf("attribute '%s' cannot be cropped", attribute)
+		}
+
+		crop[attribute] = DefaultCropLength
+		if found {
+			words, err := strconv.Atoi(length)
+			if err != nil || words < 1 {
+				return nil, fmt.Errorf("invalid crop length '%s' for attribute '%s'", length, attribute)
+			}
+			crop[attribute] = words
+		}
+	}
+
+	return crop, nil
+}
+
+// ParseAttributesToRetrieve parses the comma-separated list of attributes to
+// return with every hit. The primary key is always returned.
+func (s IndexSettings) ParseAttributesToRetrieve(param string) ([]string, error) {
+	attributes := []string{}
+	for _, attribute := range splitList(param) {
+		if !contains(s.Displayed, attribute) {
+			return nil, fmt.Errorf("unknown attribute '%s'", attribute)
+		}
+		attributes = append(attributes, attribute)
+	}
+
+	if len(attributes) > 0 && !contains(attributes, s.PrimaryKey) {
+		attributes = append(attributes, s.PrimaryKey)
+	}
+
+	return attributes, nil
+}
+
+// Retain clears the attributes of the hit that are not listed. All are kept
+// if none are listed.
+func (h *SearchHit) Retain(attributes []string) {
+	if len(attributes) == 0 {
+		return
+	}
+
+	if !contains(attributes, "title") {
+		h.Title = ""
+	}
+	if !contains(attributes, "author") {
+		h.Author = ""
+	}
+	if !contains(attributes, "body") {
+		h.Body = ""
+	}
+	if !contains(attributes, "tags") {
+		h.Tags = nil
+	}
+}
+
+func splitList(param string) []string {
+	values := []string{}
+	for _, value := range strings.Split(param, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
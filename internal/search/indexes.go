@@ -0,0 +1,174 @@
+package search
+
+import (
+	"fmt"
+	"mini-search-platform/internal/models"
+)
+
+// IndexSettings is the schema every engine applies when it creates an index.
+type IndexSettings struct {
+	PrimaryKey string
+	Searchable []string
+	Filterable []string
+	Sortable   []string
+	// Facets maps the facet names clients request to the filterable
+	// attributes whose values they count.
+	Facets map[string]string
+	// FilterFields are the query parameters clients filter on.
+	FilterFields map[string]FilterField
+	// SortFields maps the sort keys clients request to the sortable
+	// attributes they order by, where the two differ. Other sortable
+	// attributes are requested by their name, see SortAttribute.
+	SortFields map[string]string
+	// Formattable are the text attributes of hits that can be highlighted
+	// and cropped, Displayed the attributes hits can be limited to.
+	Formattable []string
+	Displayed   []string
+	Locales     []string
+	StopWords   []string
+	// Synonyms and RankingRules are set by the tenant's search settings,
+	// see WithSearchSettings.
+	Synonyms     map[string][]string
+	RankingRules []string
+}
+
+var ArticlesIndexSettings = IndexSettings{
+	PrimaryKey: "id",
+	Searchable: []string{"title", "body", "author", "tags"},
+	// the primary keys are filterable to look up merchandised documents
+	Filterable: []string{"id", "author", "tags", "created_at_timestamp"},
+	Sortable:   []string{"author", "title", "created_at_timestamp"},
+	Facets: map[string]string{
+		"tags":   "tags.label",
+		"author": "author",
+	},
+	FilterFields: map[string]FilterField{
+		"tags":           {Attribute: "tags.label", Operator: FilterIn, Type: FilterString},
+		"author":         {Attribute: "author", Operator: FilterIn, Type: FilterString},
+		"created_after":  {Attribute: "created_at_timestamp", Operator: FilterAtLeast, Type: FilterTimestamp},
+		"created_before": {Attribute: "created_at_timestamp", Operator: FilterAtMost, Type: FilterTimestamp},
+	},
+	SortFields: map[string]string{
+		"created_at": "created_at_timestamp",
+	},
+	Formattable: []string{"title", "body", "author"},
+	Displayed:   []string{"id", "title", "author", "body", "tags"},
+}
+
+var ProductsIndexSettings = IndexSettings{
+	PrimaryKey: "article_id",
+	Searchable: []string{"title", "brand", "category"},
+	Filterable: []string{
+		"article_id",
+		"brand",
+		"category",
+		"facet_data.available_sizes",
+		"facet_data.available_colors",
+		"facet_data.is_in_stock",
+	},
+	Sortable: []string{"title", "brand", "category"},
+	Facets: map[string]string{
+		"brand":            "brand",
+		"category":         "category",
+		"available_sizes":  "facet_data.available_sizes",
+		"available_colors": "facet_data.available_colors",
+	},
+	FilterFields: map[string]FilterField{
+		"brand":            {Attribute: "brand", Operator: FilterIn, Type: FilterString},
+		"category":         {Attribute: "category", Operator: FilterIn, Type: FilterString},
+		"available_sizes":  {Attribute: "facet_data.available_sizes", Operator: FilterIn, Type: FilterString},
+		"available_colors": {Attribute: "facet_data.available_colors", Operator: FilterIn, Type: FilterString},
+		"in_stock":         {Attribute: "facet_data.is_in_stock", Operator: FilterIn, Type: FilterBoolean},
+	},
+}
+
+// SortAttribute returns the sortable attribute a sort key orders by, and
+// whether the attribute is one of the sortable attributes of the index.
+func (s IndexSettings) SortAttribute(field string) (string, bool) {
+	attribute := field
+	if renamed, ok := s.SortFields[field]; ok {
+		attribute = renamed
+	}
+
+	return attribute, contains(s.Sortable, attribute)
+}
+
+// FacetAttributes returns the attributes of the named facets. It fails on
+// the first unknown name.
+func (s IndexSettings) FacetAttributes(names []string) ([]string, error) {
+	attributes := make([]string, len(names))
+	for i, name := range names {
+		attribute, ok := s.Facets[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown facet '%s'", name)
+		}
+		attributes[i] = attribute
+	}
+
+	return attributes, nil
+}
+
+// LanguageProfile tunes an index for the language of a catalogue.
+type LanguageProfile struct {
+	// Locale is the ISO 639-3 code the engines expect.
+	Locale    string
+	StopWords []string
+}
+
+// LanguageProfiles holds the supported catalogue languages by ISO 639-1 code.
+var LanguageProfiles = map[string]LanguageProfile{
+	"en": {Locale: "eng", StopWords: []string{"a", "an", "and", "for", "in", "of", "on", "the", "to", "with"}},
+	"de": {Locale: "deu", StopWords: []string{"das", "der", "die", "ein", "eine", "für", "im", "mit", "und", "von"}},
+	"fr": {Locale: "fra", StopWords: []string{"avec", "de", "des", "du", "et", "la", "le", "les", "pour", "un", "une"}},
+	"es": {Locale: "spa", StopWords: []string{"con", "de", "del", "el", "la", "las", "los", "para", "un", "una", "y"}},
+	"it": {Locale: "ita", StopWords: []string{"con", "di", "e", "gli", "il", "la", "le", "lo", "per", "un", "una"}},
+	"nl": {Locale: "nld", StopWords: []string{"de", "een", "en", "het", "met", "van", "voor"}},
+	"pl": {Locale: "pol", StopWords: []string{"dla", "do", "i", "na", "w", "z"}},
+}
+
+// ForLanguage returns a copy of the settings tuned for the language profile.
+func (s IndexSettings) ForLanguage(profile LanguageProfile) IndexSettings {
+	s.Locales = []string{profile.Locale}
+	s.StopWords = profile.StopWords
+	return s
+}
+
+// IndexPrefix is prepended to the name of every index, so that several
+// deployments can share a search engine. Set it before creating engines.
+var IndexPrefix = ""
+
+// IndexName returns the name of a tenant's index. Every tenant gets its own
+// set of indexes so that documents can never be served across tenants.
+func IndexName(tenantID int, base string) string {
+	return fmt.Sprintf("%stenant_%d_%s", IndexPrefix, tenantID, base)
+}
+
+// CatalogueIndexName returns the name of the index holding the localized
+// documents of a tenant's catalogue.
+func CatalogueIndexName(tenantID int, base, catalogueCode string) string {
+	return fmt.Sprintf("%s_%s", IndexName(tenantID, base), catalogueCode)
+}
+
+// IndexNameFor returns the name of the tenant's index, or of the catalogue's
+// localized index if a catalogue is given.
+func IndexNameFor(tenantID int, base string, catalogue *models.Catalogue) string {
+	if catalogue != nil {
+		return CatalogueIndexName(tenantID, base, catalogue.Code)
+	}
+
+	return IndexName(tenantID, base)
+}
+
+// IndexSettingsFor returns the settings of the index, tuned for the language
+// of the catalogue if one is given.
+func IndexSettingsFor(base string, catalogue *models.Catalogue) IndexSettings {
+	settings := ArticlesIndexSettings
+	if base == PRODUCTS_INDEX_NAME {
+		settings = ProductsIndexSettings
+	}
+	if catalogue != nil {
+		settings = settings.ForLanguage(LanguageProfiles[catalogue.Language])
+	}
+
+	return settings
+}
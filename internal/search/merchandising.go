@@ -0,0 +1,328 @@
+package search
+
+import (
+	"fmt"
+	"mini-search-platform/internal/models"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Merchandising is the combined effect of the merchandising rules matching
+// a search.
+type Merchandising struct {
+	// Pinned are sorted by position; no two share a position or a document.
+	Pinned []models.Pin
+	Buried []string
+}
+
+// NormalizeMerchandisingRule validates the rule against the index and
+// normalizes its query and campaign bounds.
+func (s IndexSettings) NormalizeMerchandisingRule(rule *models.MerchandisingRule) error {
+	switch rule.Match {
+	case models.MatchExact, models.MatchContains:
+	case models.MatchTag:
+		if _, ok := s.FilterFields["tags"]; !ok {
+			return fmt.Errorf("match: the index has no tags")
+		}
+	default:
+		return fmt.Errorf("match: unknown match '%s', expected %s, %s or %s", rule.Match, models.MatchExact, models.MatchContains, models.MatchTag)
+	}
+
+	rule.Query = normalizeWord(rule.Query)
+	if rule.Query == "" {
+		return fmt.Errorf("query: empty query")
+	}
+
+	if len(rule.Pinned) == 0 && len(rule.Buried) == 0 {
+		return fmt.Errorf("the rule neither pins nor buries documents")
+	}
+
+	seen := map[string]bool{}
+	positions := map[int]bool{}
+	for i, pin := range rule.Pinned {
+		pin.ID = strings.TrimSpace(pin.ID)
+		if pin.ID == "" {
+			return fmt.Errorf("pinned: empty id")
+		}
+		if pin.Position < 1 {
+			return fmt.Errorf("pinned: position of '%s' has to be 1 or more", pin.ID)
+		}
+		if seen[pin.ID] || positions[pin.Position] {
+			return fmt.Errorf("pinned: '%s' or position %d is listed more than once", pin.ID, pin.Position)
+		}
+		seen[pin.ID], positions[pin.Position] = true, true
+		rule.Pinned[i] = pin
+	}
+	for i, id := range rule.Buried {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			return fmt.Errorf("buried: empty id")
+		}
+		if seen[id] {
+			return fmt.Errorf("buried: '%s' is listed more than once or pinned", id)
+		}
+		seen[id] = true
+		rule.Buried[i] = id
+	}
+
+	var err error
+	if rule.StartsAt, err = normalizeTime(rule.StartsAt); err != nil {
+		return fmt.Errorf("starts_at: %v", err)
+	}
+	if rule.EndsAt, err = normalizeTime(rule.EndsAt); err != nil {
+		return fmt.Errorf("ends_at: %v", err)
+	}
+	if rule.StartsAt != "" && rule.EndsAt != "" && rule.EndsAt <= rule.StartsAt {
+		return fmt.Errorf("ends_at: has to be after starts_at")
+	}
+
+	return nil
+}
+
+// normalizeTime converts an RFC 3339 time to UTC, so that times compare as
+// text.
+func normalizeTime(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return "", fmt.Errorf("expected an RFC 3339 time like 2006-01-02T15:04:05Z")
+	}
+
+	return t.UTC().Format(time.RFC3339), nil
+}
+
+// MatchMerchandising combines the rules matching the query, or the tags the
+// search is filtered by. Rules are expected oldest first; older rules win
+// when two pin to the same position or pin and bury the same document.
+func MatchMerchandising(rules []*models.MerchandisingRule, query string, filter Filter) Merchandising {
+	query = normalizeWord(query)
+
+	tags := []string{}
+	for _, condition := range filter {
+		if condition.Attribute == ArticlesIndexSettings.FilterFields["tags"].Attribute {
+			for _, value := range condition.Values {
+				tags = append(tags, normalizeWord(value))
+			}
+		}
+	}
+
+	merchandising := Merchandising{}
+	seen := map[string]bool{}
+	positions := map[int]bool{}
+	for _, rule := range rules {
+		switch {
+		case rule.Match == models.MatchExact && query == rule.Query:
+		case rule.Match == models.MatchContains && strings.Contains(" "+query+" ", " "+rule.Query+" "):
+		case rule.Match == models.MatchTag && contains(tags, rule.Query):
+		default:
+			continue
+		}
+
+		for _, pin := range rule.Pinned {
+			if !seen[pin.ID] && !positions[pin.Position] {
+				seen[pin.ID], positions[pin.Position] = true, true
+				merchandising.Pinned = append(merchandising.Pinned, pin)
+			}
+		}
+		for _, id := range rule.Buried {
+			if !seen[id] {
+				seen[id] = true
+				merchandising.Buried = append(merchandising.Buried, id)
+			}
+		}
+	}
+
+	sort.Slice(merchandising.Pinned, func(a, b int) bool {
+		return merchandising.Pinned[a].Position < merchandising.Pinned[b].Position
+	})
+
+	return merchandising
+}
+
+func (m Merchandising) IsEmpty() bool {
+	return len(m.Pinned) == 0 && len(m.Buried) == 0
+}
+
+// SearchArticles searches the articles and reorders the hits: pinned
+// articles are moved to their positions, even if they do not match the
+// query, and buried ones to the end of the results. Pinned and buried
+// articles still have to pass the filter.
+func (m Merchandising) SearchArticles(engine SearchEngine, query string, options SearchOptions) (SearchResponse, error) {
+	if m.IsEmpty() {
+		return engine.Search(query, options)
+	}
+
+	hits := map[string]SearchHit{}
+	collect := func(response SearchResponse) []string {
+		ids := make([]string, len(response.Hits))
+		for i, hit := range response.Hits {
+			ids[i] = strconv.Itoa(hit.ID)
+			hits[ids[i]] = hit
+		}
+		return ids
+	}
+
+	pinned, err := engine.Search("", m.lookup(options, ArticlesIndexSettings.PrimaryKey, m.pinnedIDs()))
+	if err != nil {
+		return SearchResponse{Query: query}, err
+	}
+	available := collect(pinned)
+
+	matched, err := engine.Search(query, m.lookup(options, ArticlesIndexSettings.PrimaryKey, append(m.pinnedIDs(), m.Buried...)))
+	if err != nil {
+		return SearchResponse{Query: query}, err
+	}
+	special := collect(matched)
+
+	response, err := engine.Search(query, m.window(options))
+	if err != nil {
+		return SearchResponse{Query: query}, err
+	}
+	organic := collect(response)
+
+	ids, total := m.arrange(organic, special, available, response.Total, options)
+	response.Hits = make([]SearchHit, len(ids))
+	for i, id := range ids {
+		response.Hits[i] = hits[id]
+	}
+	response.Total, response.Offset, response.Limit = total, options.Offset, options.Limit
+
+	return response, nil
+}
+
+// SearchProducts searches the products and reorders the hits like
+// SearchArticles.
+func (m Merchandising) SearchProducts(engine SearchEngine, query string, options SearchOptions) (ProductSearchResponse, error) {
+	if m.IsEmpty() {
+		return engine.SearchProducts(query, options)
+	}
+
+	hits := map[string]ProductHit{}
+	collect := func(response ProductSearchResponse) []string {
+		ids := make([]string, len(response.Hits))
+		for i, hit := range response.Hits {
+			ids[i] = hit.ArticleID
+			hits[ids[i]] = hit
+		}
+		return ids
+	}
+
+	pinned, err := engine.SearchProducts("", m.lookup(options, ProductsIndexSettings.PrimaryKey, m.pinnedIDs()))
+	if err != nil {
+		return ProductSearchResponse{Query: query}, err
+	}
+	available := collect(pinned)
+
+	matched, err := engine.SearchProducts(query, m.lookup(options, ProductsIndexSettings.PrimaryKey, append(m.pinnedIDs(), m.Buried...)))
+	if err != nil {
+		return ProductSearchResponse{Query: query}, err
+	}
+	special := collect(matched)
+
+	response, err := engine.SearchProducts(query, m.window(options))
+	if err != nil {
+		return ProductSearchResponse{Query: query}, err
+	}
+	organic := collect(response)
+
+	ids, total := m.arrange(organic, special, available, response.Total, options)
+	response.Hits = make([]ProductHit, len(ids))
+	for i, id := range ids {
+		response.Hits[i] = hits[id]
+	}
+	response.Total, response.Offset, response.Limit = total, options.Offset, options.Limit
+
+	return response, nil
+}
+
+func (m Merchandising) pinnedIDs() []string {
+	ids := make([]string, len(m.Pinned))
+	for i, pin := range m.Pinned {
+		ids[i] = pin.ID
+	}
+
+	return ids
+}
+
+// lookup returns the options finding the given documents among the ones
+// passing the filter, without facets.
+func (m Merchandising) lookup(options SearchOptions, primaryKey string, ids []string) SearchOptions {
+	options.Filter = append(append(Filter{}, options.Filter...), Condition{Attribute: primaryKey, Operator: FilterIn, Values: ids})
+	options.Offset, options.Limit = 0, max(len(ids), 1)
+	options.Sort, options.Facets = nil, nil
+
+	return options
+}
+
+// window returns the options fetching enough hits from the start to fill
+// the requested page once the pinned and buried documents are taken out.
+func (m Merchandising) window(options SearchOptions) SearchOptions {
+	options.Limit = max(options.Offset, 0) + pageLimit(options.Limit) + len(m.Pinned) + len(m.Buried)
+	options.Offset = 0
+
+	return options
+}
+
+// arrange returns the ids of the requested page and the total number of
+// results. The organic hits, without pinned and buried documents, are
+// interleaved with the available pinned documents at their positions;
+// pinned documents beyond the organic hits follow them in order, and the
+// buried documents matching the query come last.
+func (m Merchandising) arrange(organic, matched, available []string, total int, options SearchOptions) ([]string, int) {
+	special := map[string]bool{}
+	for _, id := range m.pinnedIDs() {
+		special[id] = true
+	}
+	buried := []string{}
+	for _, id := range m.Buried {
+		special[id] = true
+		if contains(matched, id) {
+			buried = append(buried, id)
+		}
+	}
+
+	hits := []string{}
+	for _, id := range organic {
+		if !special[id] {
+			hits = append(hits, id)
+		}
+	}
+
+	pins := []models.Pin{}
+	for _, pin := range m.Pinned {
+		if contains(available, pin.ID) {
+			pins = append(pins, pin)
+			if !contains(matched, pin.ID) {
+				total++
+			}
+		}
+	}
+
+	offset := max(options.Offset, 0)
+	end := offset + pageLimit(options.Limit)
+	ids := []string{}
+	for len(ids) < end && (len(hits) > 0 || len(pins) > 0) {
+		if len(pins) > 0 && (pins[0].Position-1 <= len(ids) || len(hits) == 0) {
+			ids, pins = append(ids, pins[0].ID), pins[1:]
+		} else {
+			ids, hits = append(ids, hits[0]), hits[1:]
+		}
+	}
+	ids = append(ids, buried[:min(len(buried), max(end-len(ids), 0))]...)
+
+	return ids[min(offset, len(ids)):], total
+}
+
+// pageLimit applies the default limit of the engines.
+func pageLimit(limit int) int {
+	if limit <= 0 {
+		return 20
+	}
+
+	return limit
+}
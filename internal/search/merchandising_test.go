@@ -0,0 +1,123 @@
+package search_test
+
+import (
+	"mini-search-platform/internal/adapters"
+	"mini-search-platform/internal/models"
+	"mini-search-platform/internal/search"
+	"reflect"
+	"testing"
+)
+
+func hitIDs(response search.SearchResponse) []int {
+	ids := []int{}
+	for _, hit := range response.Hits {
+		ids = append(ids, hit.ID)
+	}
+	return ids
+}
+
+func TestMerchandising_PinsAndBuriesArticles(t *testing.T) {
+	engine := adapters.NewMemoryEngine().ForTenant(1)
+	if err := engine.CreateIndexes(); err != nil {
+		t.Fatal(err)
+	}
+
+	articles := []*models.Article{}
+	for id, title := range map[int]string{
+		1: "Summer sale on shirts",
+		2: "Summer sale on shorts",
+		3: "Summer sale on shoes",
+		4: "Winter coats",
+		5: "Summer sale on hats",
+	} {
+		tag := models.NewTag("summer")
+		if id == 4 {
+			tag = models.NewTag("winter")
+		}
+		article := models.NewArticle(title, title, models.NewAuthor(1, "Jane"), []*models.Tag{tag})
+		article.ID = id
+		articles = append(articles, article)
+	}
+	if err := engine.IndexArticles(articles); err != nil {
+		t.Fatal(err)
+	}
+
+	rule := models.NewMerchandisingRule(search.ARTICLES_INDEX_NAME, models.MatchContains, " Summer  SALE")
+	rule.Pinned = []models.Pin{{ID: "4", Position: 2}}
+	rule.Buried = []string{"1"}
+	if err := search.ArticlesIndexSettings.NormalizeMerchandisingRule(rule); err != nil {
+		t.Fatal(err)
+	}
+	exact := models.NewMerchandisingRule(search.ARTICLES_INDEX_NAME, models.MatchExact, "summer")
+	exact.Buried = []string{"2"}
+
+	rules := []*models.MerchandisingRule{rule, exact}
+	merchandising := search.MatchMerchandising(rules, "summer sale shoes", nil)
+	if !reflect.DeepEqual(merchandising.Pinned, rule.Pinned) || !reflect.DeepEqual(merchandising.Buried, rule.Buried) {
+		t.Fatalf("expected only the contains rule to match, got %+v", merchandising)
+	}
+
+	options := search.SearchOptions{Limit: 10, Sort: []string{"title:asc"}}
+	response, err := merchandising.SearchArticles(engine, "summer sale", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the pinned article does not match the query but is counted
+	if ids := hitIDs(response); !reflect.DeepEqual(ids, []int{5, 4, 3, 2, 1}) || response.Total != 5 {
+		t.Errorf("expected 5, 4, 3, 2, 1, got %v (total %d)", ids, response.Total)
+	}
+
+	options.Offset, options.Limit = 3, 2
+	response, err = merchandising.SearchArticles(engine, "summer sale", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := hitIDs(response); !reflect.DeepEqual(ids, []int{2, 1}) || response.Offset != 3 {
+		t.Errorf("expected the last page to hold 2, 1, got %v", ids)
+	}
+
+	options.Offset = -3
+	response, err = merchandising.SearchArticles(engine, "summer sale", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := hitIDs(response); !reflect.DeepEqual(ids, []int{5, 4}) {
+		t.Errorf("expected a negative offset to return the first page 5, 4, got %v", ids)
+	}
+
+	// pinned articles have to pass the filter
+	options.Offset, options.Limit = 0, 10
+	options.Filter = search.Filter{{Attribute: "tags.label", Operator: search.FilterIn, Values: []string{"Summer"}}}
+	tagged := models.NewMerchandisingRule(search.ARTICLES_INDEX_NAME, models.MatchTag, "summer")
+	tagged.Pinned = []models.Pin{{ID: "3", Position: 1}, {ID: "4", Position: 2}}
+	merchandising = search.MatchMerchandising([]*models.MerchandisingRule{tagged}, "sale", options.Filter)
+	response, err = merchandising.SearchArticles(engine, "sale", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := hitIDs(response); !reflect.DeepEqual(ids, []int{3, 5, 1, 2}) || response.Total != 4 {
+		t.Errorf("expected the tag rule to pin 3, got %v (total %d)", ids, response.Total)
+	}
+}
+
+func TestNormalizeMerchandisingRule(t *testing.T) {
+	for name, rule := range map[string]*models.MerchandisingRule{
+		"unknown match":     {Match: "fuzzy", Query: "sale", Buried: []string{"1"}},
+		"empty query":       {Match: models.MatchExact, Query: " ", Buried: []string{"1"}},
+		"nothing to do":     {Match: models.MatchExact, Query: "sale"},
+		"position 0":        {Match: models.MatchExact, Query: "sale", Pinned: []models.Pin{{ID: "1"}}},
+		"pinned twice":      {Match: models.MatchExact, Query: "sale", Pinned: []models.Pin{{ID: "1", Position: 1}, {ID: "2", Position: 1}}},
+		"pinned buried":     {Match: models.MatchExact, Query: "sale", Pinned: []models.Pin{{ID: "1", Position: 1}}, Buried: []string{"1"}},
+		"invalid start":     {Match: models.MatchExact, Query: "sale", Buried: []string{"1"}, StartsAt: "2026-06-01"},
+		"ends before start": {Match: models.MatchExact, Query: "sale", Buried: []string{"1"}, StartsAt: "2026-06-01T00:00:00+02:00", EndsAt: "2026-05-31T22:00:00Z"},
+	} {
+		if err := search.ArticlesIndexSettings.NormalizeMerchandisingRule(rule); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	rule := &models.MerchandisingRule{Match: models.MatchTag, Query: "Summer", Buried: []string{"1"}}
+	if err := search.ProductsIndexSettings.NormalizeMerchandisingRule(rule); err == nil {
+		t.Error("expected tag rules to be rejected for products")
+	}
+}
@@ -0,0 +1,224 @@
+package search
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mini-search-platform/internal/models"
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultOutboxMaxAttempts  = 10
+	DefaultOutboxBatchSize    = 50
+	DefaultOutboxPollInterval = time.Second
+	DefaultOutboxBaseDelay    = 2 * time.Second
+	DefaultOutboxMaxDelay     = 10 * time.Minute
+)
+
+// OutboxWorker drains the index outbox into the search engine. Failed
+// entries are retried with an exponential delay and moved to the
+// dead-letter state after MaxAttempts failures. Since entries only carry
+// identifiers, processing them again is always safe.
+type OutboxWorker struct {
+	Repository   models.OutboxRepository
+	Sync         *IndexSyncManager
+	MaxAttempts  int
+	BatchSize    int
+	PollInterval time.Duration
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+}
+
+func NewOutboxWorker(repository models.OutboxRepository, sync *IndexSyncManager) *OutboxWorker {
+	return &OutboxWorker{
+		Repository:   repository,
+		Sync:         sync,
+		MaxAttempts:  DefaultOutboxMaxAttempts,
+		BatchSize:    DefaultOutboxBatchSize,
+		PollInterval: DefaultOutboxPollInterval,
+		BaseDelay:    DefaultOutboxBaseDelay,
+		MaxDelay:     DefaultOutboxMaxDelay,
+	}
+}
+
+// Run processes due entries until the context is cancelled.
+func (w *OutboxWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		processed, err := w.ProcessBatch(time.Now())
+		if err != nil {
+			slog.Error("index outbox", "error", err)
+		}
+
+		// keep draining without waiting while there is a backlog
+		if err == nil && processed == w.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessBatch processes the entries due at the given time and returns how
+// many were picked up.
+func (w *OutboxWorker) ProcessBatch(now time.Time) (int, error) {
+	entries, err := w.Repository.FindDue(now, w.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, entry := range entries {
+		if err := w.process(entry); err != nil {
+			dead := entry.Attempts+1 >= w.MaxAttempts
+			if err := w.Repository.Fail(entry, err.Error(), now.Add(w.delay(entry.Attempts)), dead); err != nil {
+				return 0, err
+			}
+			if dead {
+				slog.Warn("index outbox: entry moved to dead-letter", "entry", entry.ID, "operation", entry.Operation, "error", err)
+			}
+			continue
+		}
+
+		if err := w.Repository.Complete(entry.ID); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(entries), nil
+}
+
+func (w *OutboxWorker) delay(attempts int) time.Duration {
+	delay := w.BaseDelay
+	for i := 0; i < attempts && delay < w.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, w.MaxDelay)
+}
+
+func (w *OutboxWorker) process(entry *models.OutboxEntry) error {
+	sync := w.Sync.ForTenant(entry.TenantID)
+
+	switch entry.Operation {
+	case models.OutboxTenantCreated:
+		return sync.SyncAfterTenantCreated()
+	case models.OutboxArticlesChanged:
+		ids, err := intIDs(entry.EntityIDs)
+		if err != nil {
+			return err
+		}
+		articles := make([]*models.Article, 0, len(ids))
+		for _, id := range ids {
+			article, err := sync.ArticlesRepository.FindById(id)
+			if errors.Is(err, sql.ErrNoRows) {
+				continue // deleted in the meantime
+			}
+			if err != nil {
+				return err
+			}
+			articles = append(articles, article)
+		}
+		if len(articles) == 0 {
+			return nil
+		}
+		return sync.SyncAfterArticlesChanged(articles)
+	case models.OutboxArticlesDeleted:
+		ids, err := intIDs(entry.EntityIDs)
+		if err != nil {
+			return err
+		}
+		return sync.SyncAfterArticlesDeleted(ids)
+	case models.OutboxTagChanged:
+		ids, err := intIDs(entry.EntityIDs)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if err := sync.SyncAfterTagsChanged(&models.Tag{ID: id}); err != nil {
+				return err
+			}
+		}
+		return nil
+	case models.OutboxProductsChanged:
+		products := make([]*models.Product, 0, len(entry.EntityIDs))
+		for _, articleID := range entry.EntityIDs {
+			product, err := sync.ProductsRepository.FindById(articleID)
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			products = append(products, product)
+		}
+		if len(products) == 0 {
+			return nil
+		}
+		return sync.SyncAfterProductsChanged(products)
+	case models.OutboxProductsDeleted:
+		return sync.SyncAfterProductsDeleted(entry.EntityIDs)
+	case models.OutboxProductFacetsChanged:
+		for _, articleID := range entry.EntityIDs {
+			_, err := sync.SyncAfterProductFacetsChanged(articleID)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+		}
+		return nil
+	case models.OutboxCatalogueCreated:
+		catalogue, err := sync.CataloguesRepository.FindById(entry.CatalogueID)
+		if err != nil {
+			return err
+		}
+		return sync.SyncAfterCatalogueCreated(catalogue)
+	case models.OutboxSearchSettingsChanged:
+		for _, index := range entry.EntityIDs {
+			if err := sync.SyncAfterSearchSettingsChanged(index); err != nil {
+				return err
+			}
+		}
+		return nil
+	case models.OutboxArticleTranslationChanged, models.OutboxProductTranslationChanged:
+		catalogue, err := sync.CataloguesRepository.FindById(entry.CatalogueID)
+		if err != nil {
+			return err
+		}
+		entityType := models.EntityArticle
+		if entry.Operation == models.OutboxProductTranslationChanged {
+			entityType = models.EntityProduct
+		}
+		for _, entityID := range entry.EntityIDs {
+			translation := &models.Translation{CatalogueID: catalogue.ID, EntityType: entityType, EntityID: entityID}
+			err := sync.SyncAfterTranslationChanged(catalogue, translation)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unknown outbox operation '%s'", entry.Operation)
+}
+
+func intIDs(entityIDs []string) ([]int, error) {
+	ids := make([]int, len(entityIDs))
+	for i, entityID := range entityIDs {
+		id, err := strconv.Atoi(entityID)
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = id
+	}
+
+	return ids, nil
+}
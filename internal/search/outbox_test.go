@@ -0,0 +1,116 @@
+package search_test
+
+import (
+	"errors"
+	"mini-search-platform/internal/adapters"
+	"mini-search-platform/internal/database"
+	"mini-search-platform/internal/models"
+	"mini-search-platform/internal/search"
+	"mini-search-platform/pkg/sqlite"
+	"testing"
+	"time"
+)
+
+// flakyEngine fails to index products until it is marked as up.
+type flakyEngine struct {
+	search.SearchEngine
+	up      bool
+	indexed []string
+}
+
+func (e *flakyEngine) ForTenant(int) search.SearchEngine { return e }
+
+func (e *flakyEngine) IndexProducts(products []*search.ProductDocument) error {
+	if !e.up {
+		return errors.New("search engine unavailable")
+	}
+	for _, product := range products {
+		e.indexed = append(e.indexed, product.ArticleID)
+	}
+	return nil
+}
+
+func TestOutboxWorker_RetriesAndDeadLetters(t *testing.T) {
+	db, err := sqlite.Open(sqlite.MemoryDSN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlite.Close(db)
+
+	if err := database.Create(db); err != nil {
+		t.Fatal(err)
+	}
+
+	const tenantID = 9009
+	products := adapters.NewSQLliteProductsRepository(db)
+	outbox := adapters.NewSQLliteOutboxRepository(db)
+	engine := &flakyEngine{}
+	sync := search.NewIndexSyncManager(
+		engine,
+		adapters.NewSQLliteArticleRepository(db),
+		adapters.NewSQLliteTagsRepository(db),
+		products,
+		adapters.NewSQLliteVariantsRepository(db),
+		adapters.NewSQLliteCataloguesRepository(db),
+		adapters.NewSQLliteTranslationsRepository(db),
+		adapters.NewSQLliteSearchSettingsRepository(db),
+	)
+	worker := search.NewOutboxWorker(outbox, sync)
+	worker.MaxAttempts = 2
+
+	product := models.NewProduct("outbox-test-1", "Running Shoe", "Nike", "shoes")
+	if err := products.ForTenant(tenantID).Save(product); err != nil {
+		t.Fatal(err)
+	}
+
+	tenantOutbox := outbox.ForTenant(tenantID)
+	now := time.Now()
+
+	if _, err := worker.ProcessBatch(now); err != nil {
+		t.Fatal(err)
+	}
+	pending, err := tenantOutbox.FindByStatus(models.OutboxPending)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].Attempts != 1 || pending[0].LastError == "" {
+		t.Fatalf("expected 1 pending entry after the first failure, got %+v", pending)
+	}
+
+	// not due yet: the entry is delayed by the backoff
+	if processed, _ := worker.ProcessBatch(now); processed != 0 {
+		t.Fatalf("expected the failed entry to be delayed, %d processed", processed)
+	}
+
+	if _, err := worker.ProcessBatch(now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	dead, err := tenantOutbox.FindByStatus(models.OutboxDead)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dead) != 1 || dead[0].Attempts != 2 {
+		t.Fatalf("expected the entry to be dead after 2 attempts, got %+v", dead)
+	}
+
+	engine.up = true
+	if err := tenantOutbox.Requeue(dead[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := worker.ProcessBatch(time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(engine.indexed) != 1 || engine.indexed[0] != product.ArticleID {
+		t.Errorf("expected product to be indexed once, got %v", engine.indexed)
+	}
+	for _, status := range []string{models.OutboxPending, models.OutboxDead} {
+		entries, err := tenantOutbox.FindByStatus(status)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 0 {
+			t.Errorf("expected no %s entries left, got %d", status, len(entries))
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package search
+
+import "mini-search-platform/internal/models"
+
+// ProductDocument is the representation of a product in the products index.
+// Variants are not indexed one by one; their sizes, colors and stock are
+// aggregated into FacetData instead (see ADR 0003).
+type ProductDocument struct {
+	ArticleID string           `json:"article_id"`
+	Title     string           `json:"title"`
+	Brand     string           `json:"brand"`
+	Category  string           `json:"category"`
+	FacetData models.FacetData `json:"facet_data"`
+}
+
+func NewProductDocument(product *models.Product, variants []*models.Variant) *ProductDocument {
+	return &ProductDocument{
+		ArticleID: product.ArticleID,
+		Title:     product.Title,
+		Brand:     product.Brand,
+		Category:  product.Category,
+		FacetData: models.NewFacetData(variants),
+	}
+}
+
+type ProductHit struct {
+	ProductDocument
+	Variants []*models.Variant `json:"variants"`
+}
+
+type ProductHits struct {
+	Hits []ProductHit `json:"hits"`
+}
+
+type ProductSearchResponse struct {
+	Query  string       `json:"query"`
+	Hits   []ProductHit `json:"hits"`
+	Offset int          `json:"offset"`
+	Limit  int          `json:"limit"`
+	Total  int          `json:"total"`
+	// Facets holds the number of hits per value of every requested facet.
+	Facets map[string]map[string]int `json:"facets,omitempty"`
+	// Degraded is set when the response was served by a fallback engine.
+	Degraded bool `json:"degraded,omitempty"`
+}
+
+// VariantFilter narrows down the variants attached to product hits.
+// Empty fields do not filter.
+type VariantFilter struct {
+	Size     string
+	Color    string
+	MinPrice *float64
+	MaxPrice *float64
+}
+
+func (f VariantFilter) Matches(variant *models.Variant) bool {
+	if f.Size != "" && variant.Size != f.Size {
+		return false
+	}
+	if f.Color != "" && variant.Color != f.Color {
+		return false
+	}
+	if f.MinPrice != nil && variant.Price < *f.MinPrice {
+		return false
+	}
+	if f.MaxPrice != nil && variant.Price > *f.MaxPrice {
+		return false
+	}
+	return true
+}
+
+// HydrateProductHits attaches the variants matching the filter to each hit of
+// the response. Hits left without any matching variant are dropped.
+func HydrateProductHits(response ProductSearchResponse, variants []*models.Variant, filter VariantFilter) ProductSearchResponse {
+	byArticleID := make(map[string][]*models.Variant)
+	for _, variant := range variants {
+		if filter.Matches(variant) {
+			byArticleID[variant.ArticleID] = append(byArticleID[variant.ArticleID], variant)
+		}
+	}
+
+	hits := []ProductHit{}
+	for _, hit := range response.Hits {
+		matching, ok := byArticleID[hit.ArticleID]
+		if !ok {
+			continue
+		}
+		hit.Variants = matching
+		hits = append(hits, hit)
+	}
+
+	response.Hits = hits
+	return response
+}
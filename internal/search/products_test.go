@@ -0,0 +1,39 @@
+package search
+
+import (
+	"mini-search-platform/internal/models"
+	"testing"
+)
+
+func TestHydrateProductHits_DropsProductsWithoutMatchingVariants(t *testing.T) {
+	response := ProductSearchResponse{
+		Hits: []ProductHit{
+			{ProductDocument: ProductDocument{ArticleID: "shoe"}},
+			{ProductDocument: ProductDocument{ArticleID: "boot"}},
+			{ProductDocument: ProductDocument{ArticleID: "sandal"}},
+		},
+	}
+	variants := []*models.Variant{
+		models.NewVariant("shoe-42-black", "shoe", "42", "black", 80, true),
+		models.NewVariant("shoe-43-black", "shoe", "43", "black", 80, true),
+		models.NewVariant("boot-42-brown", "boot", "42", "brown", 120, true),
+		models.NewVariant("sandal-42-black", "sandal", "42", "black", 30, true),
+	}
+	maxPrice := 100.0
+
+	hydrated := HydrateProductHits(response, variants, VariantFilter{
+		Size:     "42",
+		Color:    "black",
+		MaxPrice: &maxPrice,
+	})
+
+	if len(hydrated.Hits) != 2 {
+		t.Fatalf("Expected 2 hits, got %d", len(hydrated.Hits))
+	}
+	if hydrated.Hits[0].ArticleID != "shoe" || hydrated.Hits[1].ArticleID != "sandal" {
+		t.Errorf("Expected hits to keep their ranking order, got %s, %s", hydrated.Hits[0].ArticleID, hydrated.Hits[1].ArticleID)
+	}
+	if len(hydrated.Hits[0].Variants) != 1 || hydrated.Hits[0].Variants[0].VariantID != "shoe-42-black" {
+		t.Errorf("Expected only the matching variant to be attached, got %v", hydrated.Hits[0].Variants)
+	}
+}
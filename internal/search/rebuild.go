@@ -0,0 +1,265 @@
+package search
+
+import (
+	"fmt"
+	"mini-search-platform/internal/models"
+	"sync"
+	"time"
+)
+
+const rebuildPageSize = 500
+
+const (
+	RebuildRunning   = "running"
+	RebuildCompleted = "completed"
+	RebuildFailed    = "failed"
+)
+
+// RebuildStatus reports the progress of an index rebuild.
+type RebuildStatus struct {
+	Index      string `json:"index"`
+	Catalogue  string `json:"catalogue,omitempty"`
+	State      string `json:"state"`
+	Indexed    int    `json:"indexed"`
+	Total      int    `json:"total"`
+	StartedAt  string `json:"started_at"`
+	FinishedAt string `json:"finished_at,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// IsIndex reports whether the name is one of the rebuildable indexes.
+func IsIndex(name string) bool {
+	return name == ARTICLES_INDEX_NAME || name == PRODUCTS_INDEX_NAME
+}
+
+// Rebuild streams every article or product of the tenant from the
+// relational store into a fresh copy of the index, page by page, and swaps
+// the copy into place once it is complete. With a catalogue the localized
+// index of that catalogue is rebuilt. progress is called after every page
+// with the number of documents written so far and the total. The facet data
+// products were indexed with is only recorded once the copy is live.
+func (m *IndexSyncManager) Rebuild(index string, catalogue *models.Catalogue, progress func(indexed, total int)) error {
+	if !IsIndex(index) {
+		return fmt.Errorf("unknown index '%s'", index)
+	}
+
+	engine := m.Engine
+	if catalogue != nil {
+		engine = engine.ForCatalogue(catalogue)
+	}
+
+	staging, err := engine.BeginRebuild(index)
+	if err != nil {
+		return err
+	}
+
+	// the staging copy starts out with the default settings
+	var indexed []indexedFacets
+	err = m.applySearchSettings(staging, index)
+	if err == nil {
+		if index == ARTICLES_INDEX_NAME {
+			err = m.rebuildArticles(staging, catalogue, progress)
+		} else {
+			indexed, err = m.rebuildProducts(staging, catalogue, progress)
+		}
+	}
+	if err != nil {
+		engine.AbortRebuild(index)
+		return err
+	}
+
+	if err := engine.CommitRebuild(index); err != nil {
+		return err
+	}
+
+	for _, facets := range indexed {
+		if err := m.recordIndexedFacets(facets.document, facets.version); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// indexedFacets is the facet data a product was written to a staging copy
+// with, and the version read together with the product.
+type indexedFacets struct {
+	document *ProductDocument
+	version  int
+}
+
+func (m *IndexSyncManager) rebuildArticles(staging SearchEngine, catalogue *models.Catalogue, progress func(indexed, total int)) error {
+	total, err := m.ArticlesRepository.Count()
+	if err != nil {
+		return err
+	}
+
+	indexed, lastID := 0, 0
+	progress(indexed, total)
+	for {
+		articles, err := m.ArticlesRepository.FindAfter(lastID, rebuildPageSize)
+		if err != nil {
+			return err
+		}
+		if len(articles) == 0 {
+			return nil
+		}
+		lastID = articles[len(articles)-1].ID
+
+		if catalogue != nil {
+			articles, err = m.localizeArticles(catalogue, articles)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := staging.IndexArticles(articles); err != nil {
+			return err
+		}
+
+		indexed += len(articles)
+		progress(indexed, max(total, indexed))
+	}
+}
+
+// rebuildProducts returns the facet data the products were indexed with,
+// unless a localized index is rebuilt.
+func (m *IndexSyncManager) rebuildProducts(staging SearchEngine, catalogue *models.Catalogue, progress func(indexed, total int)) ([]indexedFacets, error) {
+	total, err := m.ProductsRepository.Count()
+	if err != nil {
+		return nil, err
+	}
+
+	var facets []indexedFacets
+	indexed, lastArticleID := 0, ""
+	progress(indexed, total)
+	for {
+		products, err := m.ProductsRepository.FindAfter(lastArticleID, rebuildPageSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(products) == 0 {
+			return facets, nil
+		}
+		lastArticleID = products[len(products)-1].ArticleID
+
+		articleIDs := make([]string, len(products))
+		for i, product := range products {
+			articleIDs[i] = product.ArticleID
+		}
+
+		variants, err := m.VariantsRepository.FindByArticleIds(articleIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		variantsByProduct := make(map[string][]*models.Variant, len(products))
+		for _, variant := range variants {
+			variantsByProduct[variant.ArticleID] = append(variantsByProduct[variant.ArticleID], variant)
+		}
+
+		documents := make([]*ProductDocument, len(products))
+		for i, product := range products {
+			documents[i] = NewProductDocument(product, variantsByProduct[product.ArticleID])
+		}
+
+		if catalogue != nil {
+			documents, err = m.localizeProducts(catalogue, documents)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if err := staging.IndexProducts(documents); err != nil {
+			return nil, err
+		}
+
+		if catalogue == nil {
+			for i, document := range documents {
+				facets = append(facets, indexedFacets{
+					document: &ProductDocument{ArticleID: document.ArticleID, FacetData: document.FacetData},
+					version:  products[i].IndexedFacetVersion,
+				})
+			}
+		}
+
+		indexed += len(products)
+		progress(indexed, max(total, indexed))
+	}
+}
+
+// RebuildTracker keeps the status of the latest rebuild of every index and
+// makes sure an index is never rebuilt twice at the same time.
+type RebuildTracker struct {
+	mu       sync.Mutex
+	statuses map[string]*RebuildStatus
+}
+
+func NewRebuildTracker() *RebuildTracker {
+	return &RebuildTracker{statuses: make(map[string]*RebuildStatus)}
+}
+
+func rebuildKey(tenantID int, index, catalogue string) string {
+	return fmt.Sprintf("%d/%s/%s", tenantID, index, catalogue)
+}
+
+// Start registers a new rebuild. It returns false together with the status
+// of the running rebuild if there is one already.
+func (t *RebuildTracker) Start(tenantID int, index, catalogue string) (RebuildStatus, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := rebuildKey(tenantID, index, catalogue)
+	if status, ok := t.statuses[key]; ok && status.State == RebuildRunning {
+		return *status, false
+	}
+
+	status := &RebuildStatus{
+		Index:     index,
+		Catalogue: catalogue,
+		State:     RebuildRunning,
+		StartedAt: time.Now().Format(time.RFC3339),
+	}
+	t.statuses[key] = status
+
+	return *status, true
+}
+
+func (t *RebuildTracker) Progress(tenantID int, index, catalogue string, indexed, total int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if status, ok := t.statuses[rebuildKey(tenantID, index, catalogue)]; ok {
+		status.Indexed = indexed
+		status.Total = total
+	}
+}
+
+func (t *RebuildTracker) Finish(tenantID int, index, catalogue string, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	status, ok := t.statuses[rebuildKey(tenantID, index, catalogue)]
+	if !ok {
+		return
+	}
+
+	status.State = RebuildCompleted
+	if err != nil {
+		status.State = RebuildFailed
+		status.Error = err.Error()
+	}
+	status.FinishedAt = time.Now().Format(time.RFC3339)
+}
+
+func (t *RebuildTracker) Status(tenantID int, index, catalogue string) (RebuildStatus, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	status, ok := t.statuses[rebuildKey(tenantID, index, catalogue)]
+	if !ok {
+		return RebuildStatus{}, false
+	}
+
+	return *status, true
+}
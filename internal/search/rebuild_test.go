@@ -0,0 +1,86 @@
+package search_test
+
+import (
+	"mini-search-platform/internal/adapters"
+	"mini-search-platform/internal/database"
+	"mini-search-platform/internal/models"
+	"mini-search-platform/internal/search"
+	"mini-search-platform/pkg/sqlite"
+	"testing"
+)
+
+// rebuildEngine records the calls of a rebuild; documents written to the
+// staging copy are counted separately from the live index.
+type rebuildEngine struct {
+	search.SearchEngine
+	staging   *countingEngine
+	live      int
+	committed bool
+	products  models.ProductRepository
+	// recordedEarly is set if facet data was recorded before the commit
+	recordedEarly bool
+}
+
+func (e *rebuildEngine) IndexProducts(products []*search.ProductDocument) error {
+	e.live += len(products)
+	return nil
+}
+
+func (e *rebuildEngine) BeginRebuild(index string) (search.SearchEngine, error) {
+	e.staging = &countingEngine{}
+	return e.staging, nil
+}
+
+func (e *rebuildEngine) CommitRebuild(index string) error {
+	e.committed = true
+	if facets, _, err := e.products.FindIndexedFacets("rebuild-test-1"); err != nil || facets != nil {
+		e.recordedEarly = true
+	}
+	return nil
+}
+
+func TestRebuild_WritesStagingCopyAndSwapsIt(t *testing.T) {
+	db, err := sqlite.Open(sqlite.MemoryDSN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlite.Close(db)
+
+	if err := database.Create(db); err != nil {
+		t.Fatal(err)
+	}
+
+	const tenantID = 10010
+	products := adapters.NewSQLliteProductsRepository(db).ForTenant(tenantID)
+	variants := adapters.NewSQLliteVariantsRepository(db).ForTenant(tenantID)
+	engine := &rebuildEngine{products: products}
+	settings := adapters.NewSQLliteSearchSettingsRepository(db).ForTenant(tenantID)
+	sync := search.NewIndexSyncManager(engine, nil, nil, products, variants, nil, nil, settings)
+
+	for _, id := range []string{"rebuild-test-1", "rebuild-test-2", "rebuild-test-3"} {
+		if err := products.Save(models.NewProduct(id, "Running Shoe", "Nike", "shoes")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var progress [][2]int
+	err = sync.Rebuild(search.PRODUCTS_INDEX_NAME, nil, func(indexed, total int) {
+		progress = append(progress, [2]int{indexed, total})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if engine.staging.indexedProducts != 3 || engine.live != 0 {
+		t.Errorf("expected 3 products in the staging copy only, got %d staged and %d live", engine.staging.indexedProducts, engine.live)
+	}
+	if !engine.committed {
+		t.Error("expected the staging copy to be swapped in")
+	}
+	if facets, _, err := products.FindIndexedFacets("rebuild-test-1"); engine.recordedEarly || err != nil || facets == nil {
+		t.Errorf("expected the facet data to be recorded once swapped in only, got %+v, %v", facets, err)
+	}
+	if last := progress[len(progress)-1]; last != [2]int{3, 3} {
+		t.Errorf("expected final progress 3/3, got %v", progress)
+	}
+}
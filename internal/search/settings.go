@@ -0,0 +1,97 @@
+package search
+
+import (
+	"fmt"
+	"mini-search-platform/internal/models"
+	"strings"
+)
+
+// DefaultRankingRules are the ranking rules of Meilisearch. Custom ranking
+// rules may reorder them and add sort fields, e.g. created_at:desc.
+var DefaultRankingRules = []string{"words", "typo", "proximity", "attribute", "sort", "exactness"}
+
+// NormalizeSearchSettings lowercases and trims the synonyms and stop words
+// and checks the ranking rules against the sort fields of the index.
+func (s IndexSettings) NormalizeSearchSettings(settings *models.SearchSettings) error {
+	synonyms := make(map[string][]string, len(settings.Synonyms))
+	for word, alternatives := range settings.Synonyms {
+		word = normalizeWord(word)
+		if word == "" {
+			return fmt.Errorf("synonyms: empty word")
+		}
+
+		normalized := []string{}
+		for _, alternative := range alternatives {
+			alternative = normalizeWord(alternative)
+			if alternative == "" {
+				return fmt.Errorf("synonyms of '%s': empty synonym", word)
+			}
+			if alternative != word && !contains(normalized, alternative) {
+				normalized = append(normalized, alternative)
+			}
+		}
+		if len(normalized) == 0 {
+			return fmt.Errorf("synonyms of '%s': no synonym", word)
+		}
+
+		synonyms[word] = append(synonyms[word], normalized...)
+	}
+	settings.Synonyms = synonyms
+
+	stopWords := []string{}
+	for _, word := range settings.StopWords {
+		word = normalizeWord(word)
+		if word == "" {
+			return fmt.Errorf("stop words: empty word")
+		}
+		if !contains(stopWords, word) {
+			stopWords = append(stopWords, word)
+		}
+	}
+	settings.StopWords = stopWords
+
+	rankingRules := []string{}
+	for _, rule := range settings.RankingRules {
+		rule = strings.TrimSpace(rule)
+		if !contains(DefaultRankingRules, rule) {
+			field, direction, _ := strings.Cut(rule, ":")
+			if _, ok := s.SortAttribute(field); !ok || (direction != "asc" && direction != "desc") {
+				return fmt.Errorf("ranking rules: unknown rule '%s', expected one of %s or a sort field with :asc or :desc", rule, strings.Join(DefaultRankingRules, ", "))
+			}
+		}
+		if contains(rankingRules, rule) {
+			return fmt.Errorf("ranking rules: '%s' is listed more than once", rule)
+		}
+		rankingRules = append(rankingRules, rule)
+	}
+	settings.RankingRules = rankingRules
+
+	return nil
+}
+
+// WithSearchSettings returns a copy of the index settings tuned with the
+// tenant's search settings. Their stop words are added to the ones of the
+// index, and sort fields in their ranking rules are replaced by the
+// attributes they sort by.
+func (s IndexSettings) WithSearchSettings(settings *models.SearchSettings) IndexSettings {
+	if settings == nil {
+		return s
+	}
+
+	s.StopWords = append(append([]string{}, s.StopWords...), settings.StopWords...)
+	s.Synonyms = settings.Synonyms
+	s.RankingRules = nil
+	for _, rule := range settings.RankingRules {
+		if field, direction, found := strings.Cut(rule, ":"); found {
+			attribute, _ := s.SortAttribute(field)
+			rule = attribute + ":" + direction
+		}
+		s.RankingRules = append(s.RankingRules, rule)
+	}
+
+	return s
+}
+
+func normalizeWord(word string) string {
+	return strings.ToLower(strings.Join(strings.Fields(word), " "))
+}
@@ -0,0 +1,78 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SortRelevance orders hits by relevance only. It cannot be combined with
+// other sort keys.
+const SortRelevance = "relevance"
+
+// SortFieldError reports the sort key a sort was rejected for.
+type SortFieldError struct {
+	Field  string
+	Reason string
+}
+
+func (e *SortFieldError) Error() string {
+	return fmt.Sprintf("invalid sort field '%s': %s", e.Field, e.Reason)
+}
+
+// ParseSort builds the engine sort rules from a comma-separated list of sort
+// keys like author:asc,created_at:desc. Keys are the sortable attributes of
+// the index, see SortAttribute, and are applied in order; the direction
+// defaults to asc. An empty sort or
+// relevance ranks hits by relevance only.
+func (s IndexSettings) ParseSort(param string) ([]string, error) {
+	if strings.TrimSpace(param) == "" {
+		return nil, nil
+	}
+
+	keys := strings.Split(param, ",")
+	rules := []string{}
+	seen := map[string]bool{}
+	for _, key := range keys {
+		field, direction, found := strings.Cut(strings.TrimSpace(key), ":")
+		if field == SortRelevance {
+			if found || len(keys) > 1 {
+				return nil, &SortFieldError{Field: field, Reason: "cannot be combined with a direction or other sort fields"}
+			}
+			return nil, nil
+		}
+
+		attribute, ok := s.SortAttribute(field)
+		if !ok {
+			reason := "unknown field"
+			if s.isAttribute(attribute) {
+				reason = "attribute is not sortable"
+			}
+			return nil, &SortFieldError{Field: field, Reason: reason}
+		}
+		if seen[field] {
+			return nil, &SortFieldError{Field: field, Reason: "sorted by more than once"}
+		}
+		seen[field] = true
+
+		if !found {
+			direction = "asc"
+		}
+		if direction != "asc" && direction != "desc" {
+			return nil, &SortFieldError{Field: field, Reason: fmt.Sprintf("direction '%s' is neither asc nor desc", direction)}
+		}
+
+		rules = append(rules, attribute+":"+direction)
+	}
+
+	return rules, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,48 @@
+package search_test
+
+import (
+	"errors"
+	"mini-search-platform/internal/search"
+	"reflect"
+	"testing"
+)
+
+func TestParseSort(t *testing.T) {
+	sort, err := search.ArticlesIndexSettings.ParseSort("author:asc, created_at:desc,title")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"author:asc", "created_at_timestamp:desc", "title:asc"}
+	if !reflect.DeepEqual(sort, expected) {
+		t.Errorf("expected %v, got %v", expected, sort)
+	}
+
+	// every sortable attribute the engines are configured with is a key
+	for _, settings := range []search.IndexSettings{search.ArticlesIndexSettings, search.ProductsIndexSettings} {
+		for _, attribute := range settings.Sortable {
+			if sort, err := settings.ParseSort(attribute + ":desc"); err != nil || !reflect.DeepEqual(sort, []string{attribute + ":desc"}) {
+				t.Errorf("expected sortable attribute %s to be a sort key, got %v, %v", attribute, sort, err)
+			}
+		}
+	}
+
+	if sort, err := search.ArticlesIndexSettings.ParseSort("relevance"); err != nil || sort != nil {
+		t.Errorf("expected relevance to clear the sort, got %v, %v", sort, err)
+	}
+
+	for param, field := range map[string]string{
+		"body:asc":             "body",
+		"price:asc":            "price",
+		"title:up":             "title",
+		"title:asc,title:desc": "title",
+		"relevance,title:asc":  "relevance",
+	} {
+		_, err := search.ArticlesIndexSettings.ParseSort(param)
+
+		var fieldErr *search.SortFieldError
+		if !errors.As(err, &fieldErr) || fieldErr.Field != field {
+			t.Errorf("%s: expected an error for field '%s', got %v", param, field, err)
+		}
+	}
+}
@@ -0,0 +1,506 @@
+package search
+
+import (
+	"database/sql"
+	"errors"
+	"mini-search-platform/internal/models"
+	"strconv"
+)
+
+// catalogueSyncPageSize is the number of articles or products indexed at once
+// when a new catalogue is populated.
+const catalogueSyncPageSize = 100
+
+type IndexSyncManager struct {
+	Engine                 SearchEngine
+	ArticlesRepository     models.ArticleRepository
+	TagsRepository         models.TagsRepository
+	ProductsRepository     models.ProductRepository
+	VariantsRepository     models.VariantRepository
+	CataloguesRepository   models.CataloguesRepository
+	TranslationsRepository models.TranslationsRepository
+	SettingsRepository     models.SearchSettingsRepository
+}
+
+func NewIndexSyncManager(engine SearchEngine, articlesRepository models.ArticleRepository, tagsRepository models.TagsRepository, productsRepository models.ProductRepository, variantsRepository models.VariantRepository, cataloguesRepository models.CataloguesRepository, translationsRepository models.TranslationsRepository, settingsRepository models.SearchSettingsRepository) *IndexSyncManager {
+	return &IndexSyncManager{
+		Engine:                 engine,
+		ArticlesRepository:     articlesRepository,
+		TagsRepository:         tagsRepository,
+		ProductsRepository:     productsRepository,
+		VariantsRepository:     variantsRepository,
+		CataloguesRepository:   cataloguesRepository,
+		TranslationsRepository: translationsRepository,
+		SettingsRepository:     settingsRepository,
+	}
+}
+
+// ForTenant returns a manager that syncs the tenant's data into the
+// tenant's own indexes.
+func (m *IndexSyncManager) ForTenant(tenantID int) *IndexSyncManager {
+	return &IndexSyncManager{
+		Engine:                 m.Engine.ForTenant(tenantID),
+		ArticlesRepository:     m.ArticlesRepository.ForTenant(tenantID),
+		TagsRepository:         m.TagsRepository.ForTenant(tenantID),
+		ProductsRepository:     m.ProductsRepository.ForTenant(tenantID),
+		VariantsRepository:     m.VariantsRepository.ForTenant(tenantID),
+		CataloguesRepository:   m.CataloguesRepository.ForTenant(tenantID),
+		TranslationsRepository: m.TranslationsRepository.ForTenant(tenantID),
+		SettingsRepository:     m.SettingsRepository.ForTenant(tenantID),
+	}
+}
+
+func (m *IndexSyncManager) SyncAfterTagsChanged(tagToSync *models.Tag) error {
+	articles, err := m.ArticlesRepository.FindByTag(tagToSync)
+	if err != nil {
+		return err
+	}
+
+	return m.indexArticles(articles)
+}
+
+func (m *IndexSyncManager) SyncAfterArticlesChanged(articlesToSync []*models.Article) error {
+	err := m.indexArticles(articlesToSync)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *IndexSyncManager) SyncAfterArticlesDeleted(idsToSync []int) error {
+	if err := m.Engine.DeleteArticles(idsToSync); err != nil {
+		return err
+	}
+
+	catalogues, err := m.CataloguesRepository.FindAll()
+	if err != nil {
+		return err
+	}
+
+	for _, catalogue := range catalogues {
+		if err := m.Engine.ForCatalogue(catalogue).DeleteArticles(idsToSync); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *IndexSyncManager) SyncAfterProductsChanged(productsToSync []*models.Product) error {
+	documents := make([]*ProductDocument, 0, len(productsToSync))
+	for _, product := range productsToSync {
+		variants, err := m.VariantsRepository.FindByArticleId(product.ArticleID)
+		if err != nil {
+			return err
+		}
+		documents = append(documents, NewProductDocument(product, variants))
+	}
+
+	if err := m.indexProducts(documents); err != nil {
+		return err
+	}
+
+	for i, document := range documents {
+		if err := m.recordIndexedFacets(document, productsToSync[i].IndexedFacetVersion); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SyncAfterVariantChanged reindexes the product of the given variant only
+// if the aggregated facet data differs from the one last indexed. It reports
+// whether the product was reindexed.
+func (m *IndexSyncManager) SyncAfterVariantChanged(variant *models.Variant) (bool, error) {
+	return m.SyncAfterProductFacetsChanged(variant.ArticleID)
+}
+
+// SyncAfterProductFacetsChanged reindexes the product only if the facet data
+// aggregated from its current variants differs from the one last indexed.
+func (m *IndexSyncManager) SyncAfterProductFacetsChanged(articleID string) (bool, error) {
+	return m.syncProductFacets(articleID, false)
+}
+
+// syncProductFacets indexes the product with the facet data of its current
+// variants, unless force is false and it is indexed with that data already.
+//
+// Syncs of the same product may run at the same time, in several outbox
+// workers or next to a rebuild, and their writes to the index may land in
+// any order. The facet data is therefore recorded with a compare-and-set on
+// the version read before the variants: if another sync recorded its data
+// in the meantime, it is unknown which write landed last, so the product is
+// indexed once more from its then current variants.
+func (m *IndexSyncManager) syncProductFacets(articleID string, force bool) (bool, error) {
+	for {
+		indexed, version, err := m.ProductsRepository.FindIndexedFacets(articleID)
+		if err != nil {
+			return false, err
+		}
+
+		product, err := m.ProductsRepository.FindById(articleID)
+		if err != nil {
+			return false, err
+		}
+
+		variants, err := m.VariantsRepository.FindByArticleId(articleID)
+		if err != nil {
+			return false, err
+		}
+
+		document := NewProductDocument(product, variants)
+		if !force && indexed != nil && indexed.Equal(document.FacetData) {
+			return false, nil
+		}
+
+		if err := m.indexProducts([]*ProductDocument{document}); err != nil {
+			return false, err
+		}
+
+		saved, err := m.ProductsRepository.SaveIndexedFacets(articleID, document.FacetData, version)
+		if err != nil || saved {
+			return saved, err
+		}
+		force = true
+	}
+}
+
+// recordIndexedFacets records the facet data the product was indexed with,
+// given the version read together with the product, before its variants.
+// If another sync recorded its data since, the product is synced again, see
+// syncProductFacets.
+func (m *IndexSyncManager) recordIndexedFacets(document *ProductDocument, version int) error {
+	saved, err := m.ProductsRepository.SaveIndexedFacets(document.ArticleID, document.FacetData, version)
+	if err != nil || saved {
+		return err
+	}
+
+	// a deleted product is removed from the index by its own sync
+	_, err = m.syncProductFacets(document.ArticleID, true)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+
+	return err
+}
+
+func (m *IndexSyncManager) SyncAfterProductsDeleted(articleIDsToSync []string) error {
+	if err := m.Engine.DeleteProducts(articleIDsToSync); err != nil {
+		return err
+	}
+
+	catalogues, err := m.CataloguesRepository.FindAll()
+	if err != nil {
+		return err
+	}
+
+	for _, catalogue := range catalogues {
+		if err := m.Engine.ForCatalogue(catalogue).DeleteProducts(articleIDsToSync); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SyncAfterTenantCreated provisions the default indexes of a new tenant.
+func (m *IndexSyncManager) SyncAfterTenantCreated() error {
+	return m.Engine.CreateIndexes()
+}
+
+// EnsureIndexes creates the missing indexes of the tenant and of its
+// catalogues and brings their settings up to date with the tenant's search
+// settings. Engines only apply the settings that differ, see Bootstrap.
+func (m *IndexSyncManager) EnsureIndexes() error {
+	catalogues, err := m.CataloguesRepository.FindAll()
+	if err != nil {
+		return err
+	}
+
+	engines := []SearchEngine{m.Engine}
+	for _, catalogue := range catalogues {
+		engines = append(engines, m.Engine.ForCatalogue(catalogue))
+	}
+	for _, engine := range engines {
+		if err := engine.CreateIndexes(); err != nil {
+			return err
+		}
+	}
+
+	for _, index := range []string{ARTICLES_INDEX_NAME, PRODUCTS_INDEX_NAME} {
+		settings, err := m.searchSettings(index)
+		if err != nil {
+			return err
+		}
+		for _, engine := range engines {
+			if err := engine.UpdateSettings(index, settings); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// SyncAfterCatalogueCreated provisions the indexes of a new catalogue and
+// fills them with all articles and products of the tenant, localized where
+// a translation already exists.
+func (m *IndexSyncManager) SyncAfterCatalogueCreated(catalogue *models.Catalogue) error {
+	engine := m.Engine.ForCatalogue(catalogue)
+	if err := engine.CreateIndexes(); err != nil {
+		return err
+	}
+
+	for _, index := range []string{ARTICLES_INDEX_NAME, PRODUCTS_INDEX_NAME} {
+		if err := m.applySearchSettings(engine, index); err != nil {
+			return err
+		}
+	}
+
+	for lastID := 0; ; {
+		articles, err := m.ArticlesRepository.FindAfter(lastID, catalogueSyncPageSize)
+		if err != nil {
+			return err
+		}
+		if len(articles) == 0 {
+			break
+		}
+		lastID = articles[len(articles)-1].ID
+		if err := m.indexCatalogueArticles(catalogue, articles); err != nil {
+			return err
+		}
+	}
+
+	for lastArticleID := ""; ; {
+		products, err := m.ProductsRepository.FindAfter(lastArticleID, catalogueSyncPageSize)
+		if err != nil {
+			return err
+		}
+		if len(products) == 0 {
+			break
+		}
+		lastArticleID = products[len(products)-1].ArticleID
+
+		documents := make([]*ProductDocument, 0, len(products))
+		for _, product := range products {
+			variants, err := m.VariantsRepository.FindByArticleId(product.ArticleID)
+			if err != nil {
+				return err
+			}
+			documents = append(documents, NewProductDocument(product, variants))
+		}
+		if err := m.indexCatalogueProducts(catalogue, documents); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SyncAfterSearchSettingsChanged applies the search settings of the index
+// to the tenant's index and to the localized index of every catalogue.
+func (m *IndexSyncManager) SyncAfterSearchSettingsChanged(index string) error {
+	settings, err := m.searchSettings(index)
+	if err != nil {
+		return err
+	}
+
+	if err := m.Engine.UpdateSettings(index, settings); err != nil {
+		return err
+	}
+
+	catalogues, err := m.CataloguesRepository.FindAll()
+	if err != nil {
+		return err
+	}
+
+	for _, catalogue := range catalogues {
+		if err := m.Engine.ForCatalogue(catalogue).UpdateSettings(index, settings); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// searchSettings returns the search settings of the index, the default
+// ones if the tenant has not changed them.
+func (m *IndexSyncManager) searchSettings(index string) (*models.SearchSettings, error) {
+	settings, err := m.SettingsRepository.FindByIndex(index)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.NewSearchSettings(index), nil
+	}
+
+	return settings, err
+}
+
+// applySearchSettings applies the search settings of the index to a freshly
+// created index, unless it has the default settings.
+func (m *IndexSyncManager) applySearchSettings(engine SearchEngine, index string) error {
+	settings, err := m.SettingsRepository.FindByIndex(index)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return engine.UpdateSettings(index, settings)
+}
+
+// SyncAfterTranslationChanged reindexes the translated entity in the index
+// of its catalogue only.
+func (m *IndexSyncManager) SyncAfterTranslationChanged(catalogue *models.Catalogue, translation *models.Translation) error {
+	switch translation.EntityType {
+	case models.EntityArticle:
+		id, err := strconv.Atoi(translation.EntityID)
+		if err != nil {
+			return err
+		}
+		article, err := m.ArticlesRepository.FindById(id)
+		if err != nil {
+			return err
+		}
+		return m.indexCatalogueArticles(catalogue, []*models.Article{article})
+	case models.EntityProduct:
+		product, err := m.ProductsRepository.FindById(translation.EntityID)
+		if err != nil {
+			return err
+		}
+		variants, err := m.VariantsRepository.FindByArticleId(product.ArticleID)
+		if err != nil {
+			return err
+		}
+		return m.indexCatalogueProducts(catalogue, []*ProductDocument{NewProductDocument(product, variants)})
+	}
+
+	return nil
+}
+
+// indexArticles indexes the articles into the tenant's default index and
+// into the index of every catalogue.
+func (m *IndexSyncManager) indexArticles(articles []*models.Article) error {
+	if err := m.Engine.IndexArticles(articles); err != nil {
+		return err
+	}
+
+	catalogues, err := m.CataloguesRepository.FindAll()
+	if err != nil {
+		return err
+	}
+
+	for _, catalogue := range catalogues {
+		if err := m.indexCatalogueArticles(catalogue, articles); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *IndexSyncManager) indexCatalogueArticles(catalogue *models.Catalogue, articles []*models.Article) error {
+	localized, err := m.localizeArticles(catalogue, articles)
+	if err != nil {
+		return err
+	}
+
+	return m.Engine.ForCatalogue(catalogue).IndexArticles(localized)
+}
+
+func (m *IndexSyncManager) localizeArticles(catalogue *models.Catalogue, articles []*models.Article) ([]*models.Article, error) {
+	ids := make([]string, len(articles))
+	for i, article := range articles {
+		ids[i] = strconv.Itoa(article.ID)
+	}
+
+	translations, err := m.TranslationsRepository.FindByEntities(catalogue.ID, models.EntityArticle, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	localized := make([]*models.Article, len(articles))
+	for i, article := range articles {
+		localized[i] = LocalizeArticle(article, translations[ids[i]])
+	}
+
+	return localized, nil
+}
+
+// indexProducts indexes the product documents into the tenant's default
+// index and into the index of every catalogue.
+func (m *IndexSyncManager) indexProducts(documents []*ProductDocument) error {
+	if err := m.Engine.IndexProducts(documents); err != nil {
+		return err
+	}
+
+	catalogues, err := m.CataloguesRepository.FindAll()
+	if err != nil {
+		return err
+	}
+
+	for _, catalogue := range catalogues {
+		if err := m.indexCatalogueProducts(catalogue, documents); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *IndexSyncManager) indexCatalogueProducts(catalogue *models.Catalogue, documents []*ProductDocument) error {
+	localized, err := m.localizeProducts(catalogue, documents)
+	if err != nil {
+		return err
+	}
+
+	return m.Engine.ForCatalogue(catalogue).IndexProducts(localized)
+}
+
+func (m *IndexSyncManager) localizeProducts(catalogue *models.Catalogue, documents []*ProductDocument) ([]*ProductDocument, error) {
+	ids := make([]string, len(documents))
+	for i, document := range documents {
+		ids[i] = document.ArticleID
+	}
+
+	translations, err := m.TranslationsRepository.FindByEntities(catalogue.ID, models.EntityProduct, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	localized := make([]*ProductDocument, len(documents))
+	for i, document := range documents {
+		localized[i] = LocalizeProductDocument(document, translations[ids[i]])
+	}
+
+	return localized, nil
+}
+
+// LocalizeArticle returns a copy of the article with the title and body of
+// the translation. Without a finished translation the original content is
+// kept.
+func LocalizeArticle(article *models.Article, translation *models.Translation) *models.Article {
+	localized := *article
+	if !translation.IsTranslated() {
+		return &localized
+	}
+
+	localized.Title = translation.Title
+	if translation.Body != "" {
+		localized.Body = translation.Body
+	}
+
+	return &localized
+}
+
+// LocalizeProductDocument returns a copy of the document with the title of
+// the translation. Without a finished translation the original title is
+// kept.
+func LocalizeProductDocument(document *ProductDocument, translation *models.Translation) *ProductDocument {
+	localized := *document
+	if translation.IsTranslated() {
+		localized.Title = translation.Title
+	}
+
+	return &localized
+}
@@ -0,0 +1,271 @@
+package translation
+
+import (
+	"context"
+	"mini-search-platform/internal/models"
+	"mini-search-platform/pkg/retry"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultSourceLanguage is the language articles and products are
+	// ingested in.
+	DefaultSourceLanguage = "en"
+
+	// DefaultAttemptTimeout bounds a single call to the translator.
+	DefaultAttemptTimeout = 5 * time.Second
+
+	// DefaultRetryTimeout bounds all attempts to translate one entity.
+	DefaultRetryTimeout = 10 * time.Second
+
+	enrichPageSize = 100
+)
+
+// Enricher translates the titles and bodies of articles and products into
+// the language of every catalogue of the tenant. Finished translations are
+// reindexed through the index outbox.
+// Translations are recorded as pending first, so that a slow or failing
+// translator never blocks ingestion and unfinished work stays visible.
+type Enricher struct {
+	Translator             Translator
+	SourceLanguage         string
+	AttemptTimeout         time.Duration
+	RetryTimeout           time.Duration
+	ArticlesRepository     models.ArticleRepository
+	ProductsRepository     models.ProductRepository
+	CataloguesRepository   models.CataloguesRepository
+	TranslationsRepository models.TranslationsRepository
+}
+
+func NewEnricher(translator Translator, articlesRepository models.ArticleRepository, productsRepository models.ProductRepository, cataloguesRepository models.CataloguesRepository, translationsRepository models.TranslationsRepository) *Enricher {
+	return &Enricher{
+		Translator:             translator,
+		SourceLanguage:         DefaultSourceLanguage,
+		AttemptTimeout:         DefaultAttemptTimeout,
+		RetryTimeout:           DefaultRetryTimeout,
+		ArticlesRepository:     articlesRepository,
+		ProductsRepository:     productsRepository,
+		CataloguesRepository:   cataloguesRepository,
+		TranslationsRepository: translationsRepository,
+	}
+}
+
+func (e *Enricher) ForTenant(tenantID int) *Enricher {
+	return &Enricher{
+		Translator:             e.Translator,
+		SourceLanguage:         e.SourceLanguage,
+		AttemptTimeout:         e.AttemptTimeout,
+		RetryTimeout:           e.RetryTimeout,
+		ArticlesRepository:     e.ArticlesRepository.ForTenant(tenantID),
+		ProductsRepository:     e.ProductsRepository.ForTenant(tenantID),
+		CataloguesRepository:   e.CataloguesRepository.ForTenant(tenantID),
+		TranslationsRepository: e.TranslationsRepository.ForTenant(tenantID),
+	}
+}
+
+func (e *Enricher) EnrichArticles(articles []*models.Article) error {
+	catalogues, err := e.CataloguesRepository.FindAll()
+	if err != nil {
+		return err
+	}
+
+	for _, catalogue := range catalogues {
+		if err := e.enrichArticles(catalogue, articles); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *Enricher) EnrichProducts(products []*models.Product) error {
+	catalogues, err := e.CataloguesRepository.FindAll()
+	if err != nil {
+		return err
+	}
+
+	for _, catalogue := range catalogues {
+		if err := e.enrichProducts(catalogue, products); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// EnrichCatalogue translates all articles and products of the tenant into
+// the language of a new catalogue.
+func (e *Enricher) EnrichCatalogue(catalogue *models.Catalogue) error {
+	for lastID := 0; ; {
+		articles, err := e.ArticlesRepository.FindAfter(lastID, enrichPageSize)
+		if err != nil {
+			return err
+		}
+		if len(articles) == 0 {
+			break
+		}
+		lastID = articles[len(articles)-1].ID
+		if err := e.enrichArticles(catalogue, articles); err != nil {
+			return err
+		}
+	}
+
+	for lastArticleID := ""; ; {
+		products, err := e.ProductsRepository.FindAfter(lastArticleID, enrichPageSize)
+		if err != nil {
+			return err
+		}
+		if len(products) == 0 {
+			break
+		}
+		lastArticleID = products[len(products)-1].ArticleID
+		if err := e.enrichProducts(catalogue, products); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RetryPending translates again every pending translation of the catalogue.
+func (e *Enricher) RetryPending(catalogue *models.Catalogue) error {
+	pending, err := e.TranslationsRepository.FindByStatus(catalogue.ID, models.TranslationPending)
+	if err != nil {
+		return err
+	}
+
+	for _, translation := range pending {
+		switch translation.EntityType {
+		case models.EntityArticle:
+			id, err := strconv.Atoi(translation.EntityID)
+			if err != nil {
+				return err
+			}
+			article, err := e.ArticlesRepository.FindById(id)
+			if err != nil {
+				return err
+			}
+			err = e.translate(catalogue, translation, article.Title, article.Body)
+			if err != nil {
+				return err
+			}
+		case models.EntityProduct:
+			product, err := e.ProductsRepository.FindById(translation.EntityID)
+			if err != nil {
+				return err
+			}
+			err = e.translate(catalogue, translation, product.Title, "")
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (e *Enricher) enrichArticles(catalogue *models.Catalogue, articles []*models.Article) error {
+	for _, article := range articles {
+		entityID := strconv.Itoa(article.ID)
+		translation, ok, err := e.begin(catalogue, models.EntityArticle, entityID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if err := e.translate(catalogue, translation, article.Title, article.Body); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *Enricher) enrichProducts(catalogue *models.Catalogue, products []*models.Product) error {
+	for _, product := range products {
+		translation, ok, err := e.begin(catalogue, models.EntityProduct, product.ArticleID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if err := e.translate(catalogue, translation, product.Title, ""); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// begin records a pending machine translation of the entity. It reports
+// false if the entity does not need one, either because the catalogue is in
+// the source language or because a manual translation exists.
+func (e *Enricher) begin(catalogue *models.Catalogue, entityType, entityID string) (*models.Translation, bool, error) {
+	if catalogue.Language == e.SourceLanguage {
+		return nil, false, nil
+	}
+
+	existing, err := e.TranslationsRepository.FindByEntities(catalogue.ID, entityType, []string{entityID})
+	if err != nil {
+		return nil, false, err
+	}
+	if translation := existing[entityID]; translation != nil && translation.Source == models.TranslationManual {
+		return nil, false, nil
+	}
+
+	translation := models.NewPendingTranslation(catalogue.ID, entityType, entityID)
+	if err := e.TranslationsRepository.Save(translation); err != nil {
+		return nil, false, err
+	}
+
+	return translation, true, nil
+}
+
+// translate runs the translator with retries. On success the translation is
+// stored, which queues it for indexing; on failure it is left pending with the number of
+// attempts and the last error, and no error is returned so that the other
+// entities still get translated.
+func (e *Enricher) translate(catalogue *models.Catalogue, translation *models.Translation, title, body string) error {
+	var translatedTitle, translatedBody string
+	var lastErr error
+	attempts := translation.Attempts
+
+	operation := func() error {
+		attempts++
+
+		ctx, cancel := context.WithTimeout(context.Background(), e.AttemptTimeout)
+		defer cancel()
+
+		translatedTitle, lastErr = e.Translator.Translate(ctx, title, e.SourceLanguage, catalogue.Language)
+		if lastErr != nil {
+			return lastErr
+		}
+
+		translatedBody = ""
+		if body != "" {
+			translatedBody, lastErr = e.Translator.Translate(ctx, body, e.SourceLanguage, catalogue.Language)
+		}
+		return lastErr
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.RetryTimeout)
+	defer cancel()
+	retry.WithBackoff(ctx, operation)
+
+	translation.Attempts = attempts
+	translation.UpdatedAt = time.Now().Format(time.RFC3339)
+	if lastErr != nil {
+		translation.Status = models.TranslationPending
+		translation.LastError = lastErr.Error()
+		return e.TranslationsRepository.Save(translation)
+	}
+
+	translation.Title = translatedTitle
+	translation.Body = translatedBody
+	translation.Status = models.TranslationTranslated
+	translation.LastError = ""
+	return e.TranslationsRepository.Save(translation)
+}
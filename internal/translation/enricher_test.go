@@ -0,0 +1,104 @@
+package translation_test
+
+import (
+	"context"
+	"errors"
+	"mini-search-platform/internal/adapters"
+	"mini-search-platform/internal/database"
+	"mini-search-platform/internal/models"
+	"mini-search-platform/internal/translation"
+	"mini-search-platform/pkg/sqlite"
+	"testing"
+	"time"
+)
+
+type failingTranslator struct{}
+
+func (t *failingTranslator) Translate(ctx context.Context, text, sourceLanguage, targetLanguage string) (string, error) {
+	return "", errors.New("translation service unavailable")
+}
+
+func TestEnrichProducts(t *testing.T) {
+	db, err := sqlite.Open(sqlite.MemoryDSN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlite.Close(db)
+
+	if err := database.Create(db); err != nil {
+		t.Fatal(err)
+	}
+
+	const tenantID = 8008
+	articles := adapters.NewSQLliteArticleRepository(db).ForTenant(tenantID)
+	products := adapters.NewSQLliteProductsRepository(db).ForTenant(tenantID)
+	catalogues := adapters.NewSQLliteCataloguesRepository(db).ForTenant(tenantID)
+	translations := adapters.NewSQLliteTranslationsRepository(db).ForTenant(tenantID)
+
+	catalogue := models.NewCatalogue("de-DE", "de", "Germany")
+	catalogue.ID, err = catalogues.Save(catalogue)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	product := models.NewProduct("enrich-test-1", "Black Running Shoes", "Nike", "shoes")
+	if err := products.Save(product); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("failing translator leaves translation pending", func(t *testing.T) {
+		enricher := translation.NewEnricher(&failingTranslator{}, articles, products, catalogues, translations)
+		enricher.RetryTimeout = 10 * time.Millisecond
+
+		if err := enricher.EnrichProducts([]*models.Product{product}); err != nil {
+			t.Fatal(err)
+		}
+
+		pending, err := translations.FindByStatus(catalogue.ID, models.TranslationPending)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pending) != 1 {
+			t.Fatalf("expected 1 pending translation, got %d", len(pending))
+		}
+		if pending[0].Attempts < 1 || pending[0].LastError == "" {
+			t.Errorf("expected attempts and last error to be recorded, got %+v", pending[0])
+		}
+	})
+
+	t.Run("retry translates pending translations", func(t *testing.T) {
+		enricher := translation.NewEnricher(adapters.NewLocalTranslator(), articles, products, catalogues, translations)
+
+		if err := enricher.RetryPending(catalogue); err != nil {
+			t.Fatal(err)
+		}
+
+		translated, err := translations.Find(catalogue.ID, models.EntityProduct, product.ArticleID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if translated.Status != models.TranslationTranslated || translated.Title != "Schwarz Lauf Schuhe" {
+			t.Errorf("expected translated title 'Schwarz Lauf Schuhe', got %+v", translated)
+		}
+	})
+
+	t.Run("manual translations are never overwritten", func(t *testing.T) {
+		manual := models.NewTranslation(catalogue.ID, models.EntityProduct, product.ArticleID, "Laufschuhe in Schwarz", "")
+		if err := translations.Save(manual); err != nil {
+			t.Fatal(err)
+		}
+
+		enricher := translation.NewEnricher(adapters.NewLocalTranslator(), articles, products, catalogues, translations)
+		if err := enricher.EnrichProducts([]*models.Product{product}); err != nil {
+			t.Fatal(err)
+		}
+
+		found, err := translations.Find(catalogue.ID, models.EntityProduct, product.ArticleID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found.Source != models.TranslationManual || found.Title != manual.Title {
+			t.Errorf("expected manual translation to be kept, got %+v", found)
+		}
+	})
+}
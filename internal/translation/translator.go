@@ -0,0 +1,10 @@
+package translation
+
+import "context"
+
+// Translator translates text between languages given as ISO 639-1 codes.
+// Implementations may call slow or unreliable external services and must
+// honour the cancellation of the context.
+type Translator interface {
+	Translate(ctx context.Context, text, sourceLanguage, targetLanguage string) (string, error)
+}
@@ -0,0 +1,104 @@
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	StateClosed   = "closed"
+	StateOpen     = "open"
+	StateHalfOpen = "half-open"
+)
+
+var ErrOpen = errors.New("circuit breaker is open")
+
+// Breaker is a circuit breaker. It opens after Threshold consecutive
+// failures and rejects calls for OpenTimeout; then a single trial call is let
+// through (half-open), which closes the breaker again on success.
+type Breaker struct {
+	Threshold   int
+	OpenTimeout time.Duration
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	trial    bool
+	now      func() time.Time
+}
+
+func New(threshold int, openTimeout time.Duration) *Breaker {
+	return &Breaker{
+		Threshold:   threshold,
+		OpenTimeout: openTimeout,
+		state:       StateClosed,
+		now:         time.Now,
+	}
+}
+
+// Snapshot describes the state of a breaker.
+type Snapshot struct {
+	State    string `json:"state"`
+	Failures int    `json:"failures"`
+	OpenedAt string `json:"opened_at,omitempty"`
+}
+
+// Allow reports whether a call may go through. Every allowed call has to be
+// followed by Success or Failure.
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		if b.now().Sub(b.openedAt) < b.OpenTimeout {
+			return false
+		}
+		b.state = StateHalfOpen
+		b.trial = true
+		return true
+	case StateHalfOpen:
+		if b.trial {
+			return false
+		}
+		b.trial = true
+		return true
+	}
+
+	return true
+}
+
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = StateClosed
+	b.failures = 0
+	b.trial = false
+}
+
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.trial = false
+	if b.state == StateHalfOpen || b.failures >= b.Threshold {
+		b.state = StateOpen
+		b.openedAt = b.now()
+	}
+}
+
+func (b *Breaker) Snapshot() Snapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	snapshot := Snapshot{State: b.state, Failures: b.failures}
+	if b.state != StateClosed {
+		snapshot.OpenedAt = b.openedAt.Format(time.RFC3339)
+	}
+
+	return snapshot
+}
@@ -2,17 +2,52 @@ package sqlite
 
 import (
 	"database/sql"
+	"net/url"
+	"strings"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
-func Init() (*sql.DB, error) {
-	db, err := sql.Open("sqlite3", "file:articles.db?cache=shared&mode=memory")
+// MemoryDSN names a shared in-memory database. Its data is gone once the
+// last connection is closed, which makes it a fit for tests.
+const MemoryDSN = "file:articles.db?cache=shared&mode=memory"
+
+// DefaultBusyTimeout is how long, in milliseconds, a connection waits for
+// the lock of another connection before failing with "database is locked".
+const DefaultBusyTimeout = "5000"
+
+// Open opens the database of the DSN. Unless the DSN sets them, files are
+// opened in WAL mode, so that reads do not block writes, with a busy
+// timeout, and transactions take the write lock when they begin, so that
+// concurrent writers wait for each other instead of failing.
+func Open(dsn string) (*sql.DB, error) {
+	return sql.Open("sqlite3", withDefaults(dsn))
+}
+
+func withDefaults(dsn string) string {
+	path, rawQuery, _ := strings.Cut(dsn, "?")
+	params, err := url.ParseQuery(rawQuery)
 	if err != nil {
-		return nil, err
+		// leave malformed DSNs to the driver to report
+		return dsn
+	}
+
+	memory := params.Get("mode") == "memory" || strings.Contains(path, ":memory:")
+	set := func(name, value string, aliases ...string) {
+		for _, alias := range append(aliases, name) {
+			if params.Has(alias) {
+				return
+			}
+		}
+		params.Set(name, value)
+	}
+	if !memory {
+		set("_journal_mode", "WAL", "_journal")
 	}
+	set("_busy_timeout", DefaultBusyTimeout, "_timeout")
+	set("_txlock", "immediate")
 
-	return db, nil
+	return path + "?" + params.Encode()
 }
 
 func Close(db *sql.DB) error {
@@ -0,0 +1,25 @@
+package token
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+const prefix = "msp_"
+
+// Generate returns a new random API token. Only its hash should be stored.
+func Generate() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return prefix + hex.EncodeToString(secret), nil
+}
+
+// Hash returns the hex encoded SHA-256 digest of a token.
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}